@@ -2,25 +2,33 @@ package main
 
 import (
 	"html/template"
+	"sort"
 	"strings"
-	"sync"
 
 	"millions-of-words/models"
 	"millions-of-words/words"
+	"millions-of-words/words/pos"
+	"millions-of-words/words/rhyme"
+	"millions-of-words/words/sentiment"
 )
 
 const (
-	maxTopWords = 20
-)
+	maxTopWords         = 20
+	maxTopPhrases       = 10
+	maxDistinctiveWords = 10
+	maxSimilarAlbums    = 6
 
-var (
-	albumDetailsCache sync.Map
+	// maxMoodAlbums is how many albums each home page mood ranking shows.
+	maxMoodAlbums = 5
+	// minMoodWords keeps near-instrumental albums out of the mood rankings,
+	// where a single "love" would put them on top.
+	minMoodWords = 100
 )
 
-func filterAlbumsByQuery(query string) []models.BandcampAlbumData {
+func (s *siteState) filterAlbumsByQuery(query string) []models.BandcampAlbumData {
 	var filtered []models.BandcampAlbumData
 	query = strings.ToLower(query)
-	for _, album := range albums {
+	for _, album := range s.albums {
 		if !album.Enabled {
 			continue
 		}
@@ -32,19 +40,25 @@ func filterAlbumsByQuery(query string) []models.BandcampAlbumData {
 	return filtered
 }
 
-func prepareAlbumDetails(album models.BandcampAlbumData) map[string]interface{} {
-	if cachedDetails, ok := albumDetailsCache.Load(album.ID); ok {
-		return cachedDetails.(map[string]interface{})
+func (s *siteState) albumsByArtist(artistSlug string) []models.BandcampAlbumData {
+	var byArtist []models.BandcampAlbumData
+	for _, album := range s.albums {
+		if album.Enabled && album.ArtistSlug == artistSlug {
+			byArtist = append(byArtist, album)
+		}
 	}
+	return byArtist
+}
 
-	var enabledTracks []models.BandcampTrackData
-	for i := range album.Tracks {
-		album.Tracks[i].Lyrics = words.NormalizeText(album.Tracks[i].Lyrics)
-		enabledTracks = append(enabledTracks, album.Tracks[i])
+func (s *siteState) prepareAlbumDetails(album models.BandcampAlbumData, view string) map[string]interface{} {
+	cacheKey := album.ID + ":" + view
+	if cachedDetails, ok := s.albumDetails.Load(cacheKey); ok {
+		return cachedDetails.(map[string]interface{})
 	}
-	album.Tracks = enabledTracks
 
-	album.AlbumWordFrequencies = words.AggregateWordFrequencies(album)
+	album.Tracks = normalizedTracks(album.Tracks)
+
+	album.AlbumWordFrequencies = s.applyFrequencyView(words.AggregateWordFrequencies(album), view)
 	if len(album.AlbumWordFrequencies) > maxTopWords {
 		album.AlbumWordFrequencies = album.AlbumWordFrequencies[:maxTopWords]
 	}
@@ -56,16 +70,14 @@ func prepareAlbumDetails(album models.BandcampAlbumData) map[string]interface{}
 
 	tracksWithDetails := make([]models.TrackWithDetails, 0, len(album.Tracks))
 	for i, track := range album.Tracks {
-		trackDetails := calculateTrackDetails(track)
+		trackDetails := s.calculateTrackDetails(album.ID, track)
 		trackDetails.TrackNumber = i + 1
+		trackDetails.SortedWordCounts = s.applyFrequencyView(trackDetails.SortedWordCounts, view)
 		tracksWithDetails = append(tracksWithDetails, trackDetails)
 	}
 
-	fuckCount := 0
-	for _, track := range album.Tracks {
-		lyrics := strings.ToLower(track.Lyrics)
-		fuckCount += strings.Count(lyrics, "fuck")
-	}
+	artistAlbums := s.albumsByArtist(album.ArtistSlug)
+	distinctive := s.corpusVocabulary().Distinctive([]models.BandcampAlbumData{album})
 
 	result := map[string]interface{}{
 		"Album":             album,
@@ -73,15 +85,99 @@ func prepareAlbumDetails(album models.BandcampAlbumData) map[string]interface{}
 		"TracksWithDetails": tracksWithDetails,
 		"AlbumWPM":          calculateWPM(float64(album.TotalWords), float64(album.TotalLength)),
 		"Enabled":           album.Enabled,
-		"FuckCount":         fuckCount,
+		"AlbumProfanity":    s.lexicon.Album(album),
+		"AlbumPOS":          pos.AnalyzeAlbum(album),
+		"ArtistRichness":    words.ArtistLexicalRichness(artistAlbums),
+		"ArtistAlbumCount":  len(artistAlbums),
+		"AlbumReadability":  words.AlbumReadability(album),
+		"AlbumRhymes":       rhyme.AnalyzeAlbum(album),
+		"AlbumSentiment":    sentiment.AlbumSentiment(album),
+		"AlbumBigrams":      topPhrases(words.AlbumNGrams(album, 2)),
+		"AlbumTrigrams":     topPhrases(words.AlbumNGrams(album, 3)),
+		"SentimentArc":      sentiment.Arc(album),
+		"AlbumRepetition":   words.AlbumRepetition(album),
+		"AlbumLanguages":    words.LanguageBreakdown([]models.BandcampAlbumData{album}),
+		"AlbumDistinctive":  topDistinctive(distinctive),
+		"AlbumTFIDF":        topDistinctive(words.RankDistinctive(distinctive, words.RankByTFIDF)),
+		"ArtistDistinctive": topDistinctive(s.corpusVocabulary().Distinctive(artistAlbums)),
+		"SimilarAlbums":     s.similarAlbums(album),
+		"FrequencyView":     view,
+		"FrequencyViews":    frequencyViews,
+	}
+
+	s.albumDetails.Store(cacheKey, result)
+	return result
+}
+
+// normalizedTracks is a copy of the tracks with their lyrics normalised, for
+// the album page and the API to show and count. Tracks are shared with the
+// loaded album list, so they are never normalised in place.
+func normalizedTracks(tracks []models.BandcampTrackData) []models.BandcampTrackData {
+	normalized := make([]models.BandcampTrackData, len(tracks))
+	for i, track := range tracks {
+		track.Lyrics = words.NormalizeText(track.Lyrics)
+		normalized[i] = track
 	}
+	return normalized
+}
+
+// corpusVocabulary holds the per-album word counts that distinctive words
+// and similar albums are scored against.
+func (s *siteState) corpusVocabulary() *words.Corpus {
+	if corpus := s.corpus.Load(); corpus != nil {
+		return corpus
+	}
+	corpus := words.NewCorpus(s.albums)
+	s.corpus.Store(corpus)
+	return corpus
+}
+
+// similarAlbum is an entry in an album's "lyrically similar" list.
+type similarAlbum struct {
+	Album      models.BandcampAlbumData
+	Similarity float64
+}
 
-	albumDetailsCache.Store(album.ID, result)
+// Percent is the similarity as a whole percentage, for display.
+func (s similarAlbum) Percent() int {
+	return int(s.Similarity*100 + 0.5)
+}
+
+// similarAlbums lists the albums whose vocabulary is closest to the album's.
+func (s *siteState) similarAlbums(album models.BandcampAlbumData) []similarAlbum {
+	byID := make(map[string]models.BandcampAlbumData, len(s.albums))
+	for _, a := range s.albums {
+		byID[a.ID] = a
+	}
+
+	var result []similarAlbum
+	for _, similar := range s.corpusVocabulary().Similar(album, maxSimilarAlbums) {
+		if other, ok := byID[similar.AlbumID]; ok {
+			result = append(result, similarAlbum{Album: other, Similarity: similar.Similarity})
+		}
+	}
 	return result
 }
 
-func calculateTrackDetails(track models.BandcampTrackData) models.TrackWithDetails {
-	sortedWordCounts, vowels, consonants, wordLengths := words.CalculateAndSortWordFrequencies(track.Lyrics, track.IgnoredWords)
+// topDistinctive keeps the first few distinctive words of a ranked list.
+func topDistinctive(ranked []models.DistinctiveWord) []models.DistinctiveWord {
+	if len(ranked) > maxDistinctiveWords {
+		ranked = ranked[:maxDistinctiveWords]
+	}
+	return ranked
+}
+
+// topPhrases keeps the strongest collocations for the album page.
+func topPhrases(ngrams []models.NGram) []models.NGram {
+	ranked := words.RankNGrams(ngrams, words.RankByLogLikelihood)
+	if len(ranked) > maxTopPhrases {
+		ranked = ranked[:maxTopPhrases]
+	}
+	return ranked
+}
+
+func (s *siteState) calculateTrackDetails(albumID string, track models.BandcampTrackData) models.TrackWithDetails {
+	sortedWordCounts, vowels, consonants, wordLengths := words.TrackWordFrequencies(track)
 
 	wordCount := 0
 	for _, wc := range sortedWordCounts {
@@ -95,10 +191,13 @@ func calculateTrackDetails(track models.BandcampTrackData) models.TrackWithDetai
 
 	wpm := calculateWPM(float64(wordCount), float64(track.TotalLength))
 
-	lyrics := template.HTML(track.Lyrics)
+	// Normalising decodes HTML entities, so the lyrics must be escaped again
+	// before they are marked safe for the page.
+	lyrics := template.HTML(template.HTMLEscapeString(track.Lyrics))
 	totalCharacters := len(track.Lyrics)
 	totalCharactersNoSpaces := len(strings.ReplaceAll(strings.ReplaceAll(strings.ReplaceAll(track.Lyrics, " ", ""), "\n", ""), "\r", ""))
 	totalLines := len(strings.Split(strings.ReplaceAll(track.Lyrics, "\r\n", "\n"), "\n"))
+	sections, repetition := words.TrackRepetition(track)
 
 	return models.TrackWithDetails{
 		Track:                   track,
@@ -113,6 +212,15 @@ func calculateTrackDetails(track models.BandcampTrackData) models.TrackWithDetai
 		TotalCharacters:         totalCharacters,
 		TotalCharactersNoSpaces: totalCharactersNoSpaces,
 		TotalLines:              totalLines,
+		POS:                     pos.AnalyzeTrack(albumID, track),
+		Richness:                words.TrackLexicalRichness(track),
+		Readability:             words.TrackReadability(track),
+		Rhymes:                  rhyme.AnalyzeTrack(track),
+		Profanity:               s.lexicon.Track(track),
+		Sentiment:               sentiment.TrackSentiment(track),
+		Sections:                sections,
+		Repetition:              repetition,
+		Language:                words.LanguageName(words.TrackLanguage(track)),
 	}
 }
 
@@ -122,3 +230,61 @@ func calculateWPM(words, minutes float64) float64 {
 	}
 	return 0
 }
+
+type emotionLeader struct {
+	Emotion string
+	Album   models.BandcampAlbumData
+	Share   float64
+}
+
+// Percent is the leader's share of its emotion words, for display.
+func (l emotionLeader) Percent() float64 {
+	return l.Share * 100
+}
+
+// moodRankings are the corpus-wide sentiment rankings on the home page.
+type moodRankings struct {
+	MostPositive   []models.BandcampAlbumData
+	MostNegative   []models.BandcampAlbumData
+	EmotionLeaders []emotionLeader
+}
+
+// rankAlbumsByMood ranks albums by the sentiment the store calculated when it
+// loaded them, so it does not re-read any lyrics.
+func rankAlbumsByMood(allAlbums []models.BandcampAlbumData) moodRankings {
+	var candidates []models.BandcampAlbumData
+	for _, album := range allAlbums {
+		if album.Sentiment.Words >= minMoodWords {
+			candidates = append(candidates, album)
+		}
+	}
+
+	var rankings moodRankings
+	if len(candidates) == 0 {
+		return rankings
+	}
+
+	sorted := append([]models.BandcampAlbumData(nil), candidates...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Sentiment.Score > sorted[j].Sentiment.Score
+	})
+	n := min(maxMoodAlbums, len(sorted))
+	rankings.MostPositive = sorted[:n]
+	for i := len(sorted) - 1; i >= len(sorted)-n; i-- {
+		rankings.MostNegative = append(rankings.MostNegative, sorted[i])
+	}
+
+	for _, emotion := range sentiment.Emotions() {
+		leader := emotionLeader{Emotion: emotion}
+		for _, album := range candidates {
+			if share := sentiment.EmotionShare(album.Sentiment, emotion); share > leader.Share {
+				leader.Album = album
+				leader.Share = share
+			}
+		}
+		if leader.Share > 0 {
+			rankings.EmotionLeaders = append(rankings.EmotionLeaders, leader)
+		}
+	}
+	return rankings
+}
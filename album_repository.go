@@ -0,0 +1,126 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"millions-of-words/models"
+)
+
+// albumSortFields are the columns the all albums table can be sorted by.
+var albumSortFields = []string{
+	"date_added", "name", "words", "unique", "length", "wpt",
+	"ttr", "mattr", "mtld", "yulesk", "hapax", "honore",
+}
+
+// albumRepository serves the all albums page, so the page never goes back to
+// the store. It holds the enabled albums in every order the table can show
+// them in, worked out once for each siteState, so sorting costs the same
+// however many albums there are. Filtering still looks at every album, but
+// only at names lowercased up front.
+type albumRepository struct {
+	orders  map[string][]*models.BandcampAlbumData
+	entries []albumEntry
+}
+
+type albumEntry struct {
+	album      *models.BandcampAlbumData
+	artistName string
+	albumName  string
+}
+
+func newAlbumRepository(albums []models.BandcampAlbumData) *albumRepository {
+	all := append([]models.BandcampAlbumData(nil), albums...)
+	r := &albumRepository{
+		orders:  make(map[string][]*models.BandcampAlbumData, 2*len(albumSortFields)),
+		entries: make([]albumEntry, len(all)),
+	}
+
+	for i := range all {
+		r.entries[i] = albumEntry{
+			album:      &all[i],
+			artistName: strings.ToLower(all[i].ArtistName),
+			albumName:  strings.ToLower(all[i].AlbumName),
+		}
+	}
+	// Filter results keep the order of the default sort.
+	sort.SliceStable(r.entries, func(i, j int) bool {
+		return albumLess(r.entries[j].album, r.entries[i].album, "date_added")
+	})
+
+	for _, field := range albumSortFields {
+		for _, dir := range []string{"asc", "desc"} {
+			order := make([]*models.BandcampAlbumData, len(all))
+			for i := range all {
+				order[i] = &all[i]
+			}
+			sort.SliceStable(order, func(i, j int) bool {
+				if dir == "desc" {
+					return albumLess(order[j], order[i], field)
+				}
+				return albumLess(order[i], order[j], field)
+			})
+			r.orders[field+" "+dir] = order
+		}
+	}
+	return r
+}
+
+// Sorted lists the albums by the field, ascending unless dir is "desc".
+// Unknown fields sort by date added.
+func (r *albumRepository) Sorted(field, dir string) []*models.BandcampAlbumData {
+	if dir != "desc" {
+		dir = "asc"
+	}
+	if order, ok := r.orders[field+" "+dir]; ok {
+		return order
+	}
+	return r.orders["date_added "+dir]
+}
+
+// Filter lists the albums whose artist or album name contains search, newest
+// first. An empty search lists every album.
+func (r *albumRepository) Filter(search string) []*models.BandcampAlbumData {
+	if search == "" {
+		return r.Sorted("date_added", "desc")
+	}
+
+	search = strings.ToLower(search)
+	var filtered []*models.BandcampAlbumData
+	for _, entry := range r.entries {
+		if strings.Contains(entry.artistName, search) || strings.Contains(entry.albumName, search) {
+			filtered = append(filtered, entry.album)
+		}
+	}
+	return filtered
+}
+
+// albumLess reports whether a sorts before b on the field, ascending.
+func albumLess(a, b *models.BandcampAlbumData, field string) bool {
+	switch field {
+	case "name":
+		return strings.ToLower(a.ArtistName+" "+a.AlbumName) < strings.ToLower(b.ArtistName+" "+b.AlbumName)
+	case "words":
+		return a.TotalWords < b.TotalWords
+	case "unique":
+		return a.TotalUniqueWords < b.TotalUniqueWords
+	case "length":
+		return a.TotalLength < b.TotalLength
+	case "wpt":
+		return a.AverageWordsPerTrack < b.AverageWordsPerTrack
+	case "ttr":
+		return a.Richness.TTR < b.Richness.TTR
+	case "mattr":
+		return a.Richness.MATTR < b.Richness.MATTR
+	case "mtld":
+		return a.Richness.MTLD < b.Richness.MTLD
+	case "yulesk":
+		return a.Richness.YulesK < b.Richness.YulesK
+	case "hapax":
+		return a.Richness.HapaxLegomena < b.Richness.HapaxLegomena
+	case "honore":
+		return a.Richness.Honore < b.Richness.Honore
+	default:
+		return a.DateAdded < b.DateAdded
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"millions-of-words/loaders"
+	"millions-of-words/models"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func albumIDs(albums []*models.BandcampAlbumData) []string {
+	ids := []string{}
+	for _, album := range albums {
+		ids = append(ids, album.ID)
+	}
+	return ids
+}
+
+func TestAlbumRepositorySortsByRichness(t *testing.T) {
+	repo := newAlbumRepository([]models.BandcampAlbumData{
+		{ID: "1", Richness: models.LexicalRichness{MTLD: 40, YulesK: 120}},
+		{ID: "2", Richness: models.LexicalRichness{MTLD: 90, YulesK: 80}},
+		{ID: "3", Richness: models.LexicalRichness{MTLD: 65, YulesK: 200}},
+	})
+
+	assert.Equal(t, []string{"2", "3", "1"}, albumIDs(repo.Sorted("mtld", "desc")))
+	assert.Equal(t, []string{"2", "1", "3"}, albumIDs(repo.Sorted("yulesk", "asc")))
+}
+
+func TestAlbumRepositorySortsAndFilters(t *testing.T) {
+	repo := newAlbumRepository([]models.BandcampAlbumData{
+		{ID: "b", ArtistName: "Burzum", AlbumName: "Filosofem", DateAdded: "2024-02-01", TotalWords: 300},
+		{ID: "d", ArtistName: "Darkthrone", AlbumName: "Transilvanian Hunger", DateAdded: "2024-03-01", TotalWords: 100},
+		{ID: "e", ArtistName: "Emperor", AlbumName: "Anthems", DateAdded: "2024-01-01", TotalWords: 200},
+	})
+
+	assert.Equal(t, []string{"d", "b", "e"}, albumIDs(repo.Sorted("date_added", "desc")))
+	assert.Equal(t, []string{"d", "e", "b"}, albumIDs(repo.Sorted("words", "asc")))
+	assert.Equal(t, []string{"b", "d", "e"}, albumIDs(repo.Sorted("name", "")))
+	assert.Equal(t, []string{"e", "b", "d"}, albumIDs(repo.Sorted("unknown", "asc")))
+
+	assert.Equal(t, []string{"d", "e"}, albumIDs(repo.Filter("TH")))
+	assert.Equal(t, []string{"e"}, albumIDs(repo.Filter("anthems")))
+	assert.Empty(t, repo.Filter("mayhem"))
+	assert.Equal(t, []string{"d", "b", "e"}, albumIDs(repo.Filter("")))
+}
+
+// countingStore counts full album loads, which the all albums page should
+// never need.
+type countingStore struct {
+	loaders.Store
+	loads int
+}
+
+func (s *countingStore) LoadAlbumsData(limit ...int) ([]models.BandcampAlbumData, error) {
+	s.loads++
+	return s.Store.LoadAlbumsData(limit...)
+}
+
+func (s *countingStore) LoadAllAlbumsData(limit ...int) ([]models.BandcampAlbumData, error) {
+	s.loads++
+	return s.Store.LoadAllAlbumsData(limit...)
+}
+
+func withAlbumTable(t testing.TB, count int) *countingStore {
+	generated := make([]models.BandcampAlbumData, count)
+	for i := range generated {
+		generated[i] = models.BandcampAlbumData{
+			ID:         fmt.Sprint(i),
+			ArtistName: fmt.Sprintf("Artist %d", i),
+			AlbumName:  fmt.Sprintf("Album %d", i),
+			DateAdded:  fmt.Sprintf("2024-01-01 00:00:%05d", i),
+			TotalWords: (i * 7919) % 1000,
+			Enabled:    true,
+		}
+	}
+	replaceAlbums(generated)
+
+	previous := store
+	counting := &countingStore{Store: previous}
+	store = counting
+	t.Cleanup(func() {
+		store = previous
+		replaceAlbums(nil)
+	})
+	return counting
+}
+
+func serveAlbumTable(handler echo.HandlerFunc, target string) (*httptest.ResponseRecorder, error) {
+	e := echo.New()
+	e.Renderer = &MockRenderer{}
+	rec := httptest.NewRecorder()
+	err := handler(e.NewContext(httptest.NewRequest(http.MethodGet, target, nil), rec))
+	return rec, err
+}
+
+func TestAlbumTableHandlersDoNotLoadAlbums(t *testing.T) {
+	counting := withAlbumTable(t, 10)
+
+	for target, handler := range map[string]echo.HandlerFunc{
+		"/all-albums":                          allAlbumsHandler,
+		"/all-albums/sort?sort=words&dir=desc": sortAlbumsHandler,
+		"/all-albums/filter?search=artist+1":   filterAlbumsHandler,
+	} {
+		rec, err := serveAlbumTable(handler, target)
+		if assert.NoError(t, err, target) {
+			assert.Equal(t, http.StatusOK, rec.Code, target)
+		}
+	}
+	assert.Zero(t, counting.loads)
+}
+
+// BenchmarkAlbumTableHandlers serves the all albums table for corpora of
+// different sizes. Listing and sorting take the same time at every size;
+// filtering grows only with a scan of lowercase names.
+func BenchmarkAlbumTableHandlers(b *testing.B) {
+	requests := []struct {
+		name    string
+		handler echo.HandlerFunc
+		target  string
+	}{
+		{"all", allAlbumsHandler, "/all-albums"},
+		{"sort", sortAlbumsHandler, "/all-albums/sort?sort=words&dir=desc"},
+		{"filter", filterAlbumsHandler, "/all-albums/filter?search=artist+12"},
+	}
+
+	for _, request := range requests {
+		for _, count := range []int{100, 1000, 10000} {
+			b.Run(fmt.Sprintf("%s/albums=%d", request.name, count), func(b *testing.B) {
+				counting := withAlbumTable(b, count)
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					if _, err := serveAlbumTable(request.handler, request.target); err != nil {
+						b.Fatal(err)
+					}
+				}
+				b.ReportMetric(float64(counting.loads)/float64(b.N), "loads/op")
+			})
+		}
+	}
+}
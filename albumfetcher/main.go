@@ -19,6 +19,7 @@ import (
 	"time"
 
 	"millions-of-words/models"
+	"millions-of-words/words"
 
 	"github.com/PuerkitoBio/goquery"
 	_ "modernc.org/sqlite"
@@ -187,7 +188,7 @@ func processTracklist(doc *goquery.Document) ([]models.BandcampTrackData, time.D
 
 		totalAlbumDuration += trackDuration
 
-		lyrics := strings.TrimSpace(s.Next().Find("div").Text())
+		lyrics := strings.TrimSpace(words.NormalizeText(s.Next().Find("div").Text()))
 		if strings.HasPrefix(lyrics, "lyrics") || strings.Contains(lyrics, "buy track") {
 			lyrics = ""
 		}
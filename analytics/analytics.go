@@ -0,0 +1,157 @@
+// Package analytics computes the numbers shown for every album in lists and
+// tables, so that stores can keep them next to the albums instead of
+// re-tokenising every lyric each time albums are loaded.
+package analytics
+
+import (
+	"hash/fnv"
+	"strings"
+
+	"millions-of-words/models"
+	"millions-of-words/words"
+	"millions-of-words/words/sentiment"
+)
+
+// Version is the version of the algorithms behind the stored numbers. Bump it
+// whenever a change to the words or sentiment packages, or to Compute, would
+// change any of them; stored analytics of an older version are then
+// recomputed in the background.
+const Version = 1
+
+// Album is everything Compute derives from an album's lyrics.
+type Album struct {
+	Version int `json:"version"`
+	// Fingerprint identifies the lyrics the numbers were computed from, so
+	// tracks edited outside the site are noticed on the next load.
+	Fingerprint             uint64                 `json:"fingerprint"`
+	Tracks                  []Track                `json:"tracks"`
+	TotalWords              int                    `json:"total_words"`
+	TotalUniqueWords        int                    `json:"total_unique_words"`
+	TotalVowels             int                    `json:"total_vowels"`
+	TotalConsonants         int                    `json:"total_consonants"`
+	TotalCharacters         int                    `json:"total_characters"`
+	TotalCharactersNoSpaces int                    `json:"total_characters_no_spaces"`
+	TotalLines              int                    `json:"total_lines"`
+	WordLengthDistribution  map[int]int            `json:"word_length_distribution"`
+	Richness                models.LexicalRichness `json:"richness"`
+	Sentiment               models.Sentiment       `json:"sentiment"`
+}
+
+// Track holds the numbers of one track, in album order.
+type Track struct {
+	Name                    string `json:"name"`
+	Language                string `json:"language"`
+	TotalWords              int    `json:"total_words"`
+	TotalCharacters         int    `json:"total_characters"`
+	TotalCharactersNoSpaces int    `json:"total_characters_no_spaces"`
+	TotalLines              int    `json:"total_lines"`
+}
+
+// Compute tokenises every track of the album and works out its numbers.
+func Compute(album models.BandcampAlbumData) Album {
+	result := Album{
+		Version:                Version,
+		Fingerprint:            Fingerprint(album),
+		Tracks:                 make([]Track, len(album.Tracks)),
+		WordLengthDistribution: make(map[int]int),
+	}
+	uniqueWords := make(map[string]struct{})
+
+	// Copy the tracks so detected languages do not leak into the caller's album.
+	album.Tracks = append([]models.BandcampTrackData(nil), album.Tracks...)
+	for i, track := range album.Tracks {
+		if track.Language == "" {
+			track.Language = words.DetectLanguage(track.Lyrics)
+			album.Tracks[i] = track
+		}
+		wordCounts, vowels, consonants, lengths := words.TrackWordFrequencies(track)
+		totalWords := len(strings.Fields(track.Lyrics))
+		totalLines := len(strings.Split(track.Lyrics, "\n"))
+
+		result.TotalWords += totalWords
+		result.TotalVowels += vowels
+		result.TotalConsonants += consonants
+		result.TotalCharacters += len(track.Lyrics)
+		result.TotalCharactersNoSpaces += len(track.Lyrics) - strings.Count(track.Lyrics, " ")
+		result.TotalLines += totalLines
+
+		for l, c := range lengths {
+			result.WordLengthDistribution[l] += c
+		}
+		for _, wc := range wordCounts {
+			uniqueWords[wc.Word] = struct{}{}
+		}
+
+		result.Tracks[i] = Track{
+			Name:                    track.Name,
+			Language:                track.Language,
+			TotalWords:              totalWords,
+			TotalCharacters:         len(track.Lyrics),
+			TotalCharactersNoSpaces: len(strings.ReplaceAll(strings.ReplaceAll(track.Lyrics, " ", ""), "\n", "")),
+			TotalLines:              totalLines,
+		}
+	}
+
+	result.TotalUniqueWords = len(uniqueWords)
+	result.Richness = words.AlbumLexicalRichness(album)
+	result.Sentiment = sentiment.AlbumSentiment(album)
+	return result
+}
+
+// Fingerprint hashes everything Compute reads, in track order. It is far
+// cheaper than Compute, so stores can check stored numbers on every load.
+func Fingerprint(album models.BandcampAlbumData) uint64 {
+	h := fnv.New64a()
+	for _, track := range album.Tracks {
+		for _, field := range []string{track.Name, track.Lyrics, track.IgnoredWords, track.Language} {
+			h.Write([]byte(field))
+			h.Write([]byte{0})
+		}
+	}
+	return h.Sum64()
+}
+
+// Fresh reports whether the stored numbers were computed by the current
+// algorithms from the album's current lyrics.
+func (a Album) Fresh(album models.BandcampAlbumData) bool {
+	return a.Version == Version && a.Matches(album)
+}
+
+// Matches reports whether the stored numbers were computed from the album's
+// current lyrics, by whichever version of the algorithms.
+func (a Album) Matches(album models.BandcampAlbumData) bool {
+	return len(a.Tracks) == len(album.Tracks) && a.Fingerprint == Fingerprint(album)
+}
+
+// Apply copies the numbers onto the album and its tracks. Tracks without a
+// stored language get the detected one.
+func (a Album) Apply(album *models.BandcampAlbumData) {
+	for i := range album.Tracks {
+		if i >= len(a.Tracks) {
+			break
+		}
+		track, stored := &album.Tracks[i], a.Tracks[i]
+		if track.Language == "" {
+			track.Language = stored.Language
+		}
+		track.TotalWords = stored.TotalWords
+		track.TotalCharacters = stored.TotalCharacters
+		track.TotalCharactersNoSpaces = stored.TotalCharactersNoSpaces
+		track.TotalLines = stored.TotalLines
+	}
+
+	album.TotalWords = a.TotalWords
+	album.TotalUniqueWords = a.TotalUniqueWords
+	album.TotalVowelCount = a.TotalVowels
+	album.TotalConsonantCount = a.TotalConsonants
+	album.TotalCharacters = a.TotalCharacters
+	album.TotalCharactersNoSpaces = a.TotalCharactersNoSpaces
+	album.TotalLines = a.TotalLines
+	album.WordLengthDistribution = a.WordLengthDistribution
+	album.AverageWordsPerTrack = 0
+	if len(album.Tracks) > 0 {
+		album.AverageWordsPerTrack = a.TotalWords / len(album.Tracks)
+	}
+	album.Richness = a.Richness
+	album.Sentiment = a.Sentiment
+}
@@ -0,0 +1,73 @@
+package analytics
+
+import (
+	"testing"
+
+	"millions-of-words/models"
+	"millions-of-words/words"
+)
+
+func testAlbum() models.BandcampAlbumData {
+	return models.BandcampAlbumData{
+		ID: "a",
+		Tracks: []models.BandcampTrackData{
+			{Name: "Fire", Lyrics: "burn the witch\nburn", Language: "en"},
+			{Name: "Ice", Lyrics: "cold cold night"},
+			{Name: "Intro"},
+		},
+	}
+}
+
+func TestComputeAndApply(t *testing.T) {
+	album := testAlbum()
+	computed := Compute(album)
+	if album.Tracks[1].Language != "" {
+		t.Error("Compute changed the languages of the album it was given")
+	}
+
+	computed.Apply(&album)
+	if album.TotalWords != 7 || album.TotalUniqueWords != 5 || album.TotalLines != 4 {
+		t.Errorf("totals = %d words, %d unique, %d lines; want 7, 5, 4", album.TotalWords, album.TotalUniqueWords, album.TotalLines)
+	}
+	if album.AverageWordsPerTrack != 2 {
+		t.Errorf("AverageWordsPerTrack = %d, want 2", album.AverageWordsPerTrack)
+	}
+	if album.WordLengthDistribution[4] != 4 {
+		t.Errorf("WordLengthDistribution = %v, want four 4-letter words", album.WordLengthDistribution)
+	}
+	if fire := album.Tracks[0]; fire.TotalWords != 4 || fire.TotalLines != 2 || fire.TotalCharactersNoSpaces != 16 {
+		t.Errorf("Fire = %+v", fire)
+	}
+	if album.Tracks[1].Language != words.Undetermined {
+		t.Errorf("Ice language = %q, want the detected %q", album.Tracks[1].Language, words.Undetermined)
+	}
+	if album.Richness.Tokens != 7 || album.Sentiment.Words != 7 {
+		t.Errorf("richness and sentiment counted %d and %d words, want 7", album.Richness.Tokens, album.Sentiment.Words)
+	}
+}
+
+func TestMatchesAndFresh(t *testing.T) {
+	album := testAlbum()
+	computed := Compute(album)
+	if !computed.Fresh(album) {
+		t.Error("freshly computed analytics are not fresh")
+	}
+
+	old := computed
+	old.Version = Version - 1
+	if old.Fresh(album) || !old.Matches(album) {
+		t.Error("analytics of an older version should match the lyrics but not be fresh")
+	}
+
+	edited := testAlbum()
+	edited.Tracks[1].Lyrics = "warm warm night"
+	if computed.Matches(edited) {
+		t.Error("analytics still match after the lyrics changed")
+	}
+
+	edited = testAlbum()
+	edited.Tracks = edited.Tracks[:2]
+	if computed.Matches(edited) {
+		t.Error("analytics still match after a track was removed")
+	}
+}
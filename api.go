@@ -0,0 +1,552 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+
+	"millions-of-words/models"
+	"millions-of-words/words"
+	"millions-of-words/words/sentiment"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	defaultAPIPageSize = 50
+	maxAPIPageSize     = 200
+	// A concordance returns this many occurrences unless asked for more, up
+	// to maxSearchResults.
+	defaultConcordanceLimit = 50
+)
+
+type apiPage struct {
+	Data    interface{} `json:"data"`
+	Page    int         `json:"page"`
+	PerPage int         `json:"per_page"`
+	Total   int         `json:"total"`
+}
+
+// apiConcordance is one window of a word's occurrences. Total and PerAlbum
+// still cover every occurrence.
+type apiConcordance struct {
+	words.Concordance
+	Offset int `json:"offset"`
+	Limit  int `json:"limit"`
+}
+
+type apiError struct {
+	Error string `json:"error"`
+}
+
+type apiAlbumMetrics struct {
+	TotalWords              int                    `json:"total_words"`
+	TotalUniqueWords        int                    `json:"total_unique_words"`
+	AverageWordsPerTrack    int                    `json:"average_words_per_track"`
+	TotalVowels             int                    `json:"total_vowels"`
+	TotalConsonants         int                    `json:"total_consonants"`
+	TotalCharacters         int                    `json:"total_characters"`
+	TotalCharactersNoSpaces int                    `json:"total_characters_no_spaces"`
+	TotalLines              int                    `json:"total_lines"`
+	WordsPerMinute          float64                `json:"words_per_minute"`
+	WordLengthDistribution  map[int]int            `json:"word_length_distribution"`
+	LexicalRichness         models.LexicalRichness `json:"lexical_richness"`
+	Sentiment               models.Sentiment       `json:"sentiment"`
+}
+
+type apiAlbum struct {
+	ID               string          `json:"id"`
+	Slug             string          `json:"slug"`
+	ArtistName       string          `json:"artist_name"`
+	ArtistSlug       string          `json:"artist_slug"`
+	AlbumName        string          `json:"album_name"`
+	ImageUrl         string          `json:"image_url"`
+	BandcampUrl      string          `json:"bandcamp_url"`
+	MetalArchivesURL string          `json:"metal_archives_url"`
+	ReleaseDate      string          `json:"release_date"`
+	DateAdded        string          `json:"date_added"`
+	Genre            string          `json:"genre"`
+	Country          string          `json:"country"`
+	Label            string          `json:"label"`
+	TotalLength      int             `json:"total_length"`
+	TrackCount       int             `json:"track_count"`
+	Metrics          apiAlbumMetrics `json:"metrics"`
+}
+
+type apiTrack struct {
+	Name                    string                 `json:"name"`
+	TrackNumber             int                    `json:"track_number"`
+	TotalLength             int                    `json:"total_length"`
+	Lyrics                  string                 `json:"lyrics"`
+	TotalWords              int                    `json:"total_words"`
+	UniqueWords             int                    `json:"unique_words"`
+	WordsPerMinute          float64                `json:"words_per_minute"`
+	VowelCount              int                    `json:"vowel_count"`
+	ConsonantCount          int                    `json:"consonant_count"`
+	TotalCharacters         int                    `json:"total_characters"`
+	TotalCharactersNoSpaces int                    `json:"total_characters_no_spaces"`
+	TotalLines              int                    `json:"total_lines"`
+	WordLengthDistribution  map[int]int            `json:"word_length_distribution"`
+	WordCounts              []models.WordCount     `json:"word_counts"`
+	POS                     models.POSCounts       `json:"pos"`
+	LexicalRichness         models.LexicalRichness `json:"lexical_richness"`
+	Readability             models.Readability     `json:"readability"`
+	Rhymes                  models.RhymeAnalysis   `json:"rhymes"`
+	Profanity               models.ProfanityCounts `json:"profanity"`
+	Sentiment               models.Sentiment       `json:"sentiment"`
+	Bigrams                 []models.NGram         `json:"bigrams"`
+	Trigrams                []models.NGram         `json:"trigrams"`
+	Sections                []models.LyricsSection `json:"sections"`
+	Repetition              models.Repetition      `json:"repetition"`
+	Language                string                 `json:"language"`
+}
+
+type apiAlbumDetails struct {
+	apiAlbum
+	TopWords     []models.WordCount      `json:"top_words"`
+	Profanity    models.ProfanityCounts  `json:"profanity"`
+	SentimentArc []models.SentimentPoint `json:"sentiment_arc"`
+	Repetition   models.Repetition       `json:"repetition"`
+	Bigrams      []models.NGram          `json:"bigrams"`
+	Trigrams     []models.NGram          `json:"trigrams"`
+	Similar      []apiSimilarAlbum       `json:"similar_albums"`
+	Tracks       []apiTrack              `json:"tracks"`
+}
+
+type apiSimilarAlbum struct {
+	Slug       string  `json:"slug"`
+	ArtistName string  `json:"artist_name"`
+	AlbumName  string  `json:"album_name"`
+	Similarity float64 `json:"similarity"`
+}
+
+type apiSimilarityMatrix struct {
+	Albums []string    `json:"albums"`
+	Matrix [][]float64 `json:"matrix"`
+}
+
+type apiComparedAlbum struct {
+	apiAlbum
+	TopWords         []models.WordCount `json:"top_words"`
+	ExclusiveWords   []models.WordCount `json:"exclusive_words"`
+	ExclusiveTotal   int                `json:"exclusive_total"`
+	WordLengthShares []float64          `json:"word_length_shares"`
+}
+
+type apiComparison struct {
+	Albums      []apiComparedAlbum `json:"albums"`
+	SharedWords []models.WordCount `json:"shared_words"`
+	SharedTotal int                `json:"shared_total"`
+	WordLengths []int              `json:"word_lengths"`
+}
+
+// apiArtist is an artist with numbers for their whole discography. Albums are
+// oldest release first.
+type apiArtist struct {
+	models.Artist
+	TotalTracks      int                      `json:"total_tracks"`
+	TotalWords       int                      `json:"total_words"`
+	UniqueWords      int                      `json:"unique_words"`
+	TotalLength      int                      `json:"total_length"`
+	WordsPerMinute   float64                  `json:"words_per_minute"`
+	LexicalRichness  models.LexicalRichness   `json:"lexical_richness"`
+	Profanity        models.ProfanityCounts   `json:"profanity"`
+	TopWords         []models.WordCount       `json:"top_words"`
+	DistinctiveWords []models.DistinctiveWord `json:"distinctive_words"`
+	Albums           []apiAlbum               `json:"albums"`
+}
+
+// apiTimeline is a vocabulary timeline as points and as Chart.js data.
+type apiTimeline struct {
+	Points []models.TimelinePoint `json:"points"`
+	Chart  timelineChart          `json:"chart"`
+}
+
+type apiDistinctiveWords struct {
+	Album  []models.DistinctiveWord `json:"album"`
+	Artist []models.DistinctiveWord `json:"artist"`
+}
+
+func setupAPIRoutes(e *echo.Echo) {
+	api := e.Group("/api/v1")
+	api.GET("/albums", apiAlbumsHandler)
+	api.GET("/albums/:slug", apiAlbumHandler)
+	api.GET("/albums/:slug/tracks", apiAlbumTracksHandler)
+	api.GET("/albums/:slug/distinctive", apiAlbumDistinctiveHandler)
+	api.GET("/artists/:slug", apiArtistHandler)
+	api.GET("/artists/:slug/timeline", apiArtistTimelineHandler)
+	api.GET("/similarity", apiSimilarityHandler)
+	api.GET("/timeline", apiTimelineHandler)
+	api.GET("/compare", apiCompareHandler)
+	api.GET("/words", apiWordsHandler)
+	api.GET("/languages", apiLanguagesHandler)
+	api.GET("/words/:word", apiWordHandler)
+	api.GET("/phrases", apiPhrasesHandler)
+	api.GET("/search", apiSearchHandler)
+}
+
+func apiAlbumsHandler(c echo.Context) error {
+	albums := currentState().albums
+	page, perPage := parsePagination(c)
+	start, end := pageBounds(page, perPage, len(albums))
+
+	result := make([]apiAlbum, 0, end-start)
+	for _, album := range albums[start:end] {
+		result = append(result, toAPIAlbum(album))
+	}
+
+	return c.JSON(http.StatusOK, apiPage{Data: result, Page: page, PerPage: perPage, Total: len(albums)})
+}
+
+func apiAlbumHandler(c echo.Context) error {
+	s := currentState()
+	album, ok := s.findAlbumBySlug(c.Param("slug"))
+	if !ok {
+		return c.JSON(http.StatusNotFound, apiError{Error: "album not found"})
+	}
+
+	return c.JSON(http.StatusOK, s.apiAlbumDetails(album, parseFrequencyView(c)))
+}
+
+func apiAlbumTracksHandler(c echo.Context) error {
+	s := currentState()
+	album, ok := s.findAlbumBySlug(c.Param("slug"))
+	if !ok {
+		return c.JSON(http.StatusNotFound, apiError{Error: "album not found"})
+	}
+
+	return c.JSON(http.StatusOK, s.apiAlbumDetails(album, parseFrequencyView(c)).Tracks)
+}
+
+// apiAlbumDetails analyses every track of the album, so the result is cached
+// alongside the album pages. Like the album page, it shows and counts the
+// normalised lyrics.
+func (s *siteState) apiAlbumDetails(album models.BandcampAlbumData, view string) apiAlbumDetails {
+	cacheKey := album.ID + ":" + view
+	if cached, ok := s.apiAlbums.Load(cacheKey); ok {
+		return cached.(apiAlbumDetails)
+	}
+	album.Tracks = normalizedTracks(album.Tracks)
+
+	topWords := s.applyFrequencyView(words.AggregateWordFrequencies(album), view)
+	if len(topWords) > maxTopWords {
+		topWords = topWords[:maxTopWords]
+	}
+
+	details := apiAlbumDetails{
+		apiAlbum:     toAPIAlbum(album),
+		TopWords:     topWords,
+		Profanity:    s.lexicon.Album(album),
+		SentimentArc: sentiment.Arc(album),
+		Repetition:   words.AlbumRepetition(album),
+		Bigrams:      words.AlbumNGrams(album, 2),
+		Trigrams:     words.AlbumNGrams(album, 3),
+		Similar:      toAPISimilarAlbums(s.similarAlbums(album)),
+		Tracks:       s.toAPITracks(album, view),
+	}
+	s.apiAlbums.Store(cacheKey, details)
+	return details
+}
+
+// apiArtistHandler returns an artist's profile and albums.
+func apiArtistHandler(c echo.Context) error {
+	slug := c.Param("slug")
+	s := currentState()
+	profile, ok := s.prepareArtistProfile(slug, parseFrequencyView(c))
+	if !ok {
+		return c.JSON(http.StatusNotFound, apiError{Error: "artist not found"})
+	}
+
+	result := apiArtist{
+		Artist:           s.findArtist(slug),
+		TotalTracks:      profile.TotalTracks,
+		TotalWords:       profile.TotalWords,
+		UniqueWords:      profile.UniqueWords,
+		TotalLength:      profile.TotalLength,
+		WordsPerMinute:   profile.WPM,
+		LexicalRichness:  profile.Richness,
+		Profanity:        profile.Profanity,
+		TopWords:         profile.TopWords,
+		DistinctiveWords: profile.Distinctive,
+		Albums:           make([]apiAlbum, 0, len(profile.Albums)),
+	}
+	for _, a := range profile.Albums {
+		result.Albums = append(result.Albums, toAPIAlbum(a.Album))
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+func apiTimelineHandler(c echo.Context) error {
+	points := currentState().corpusTimeline()
+	return c.JSON(http.StatusOK, apiTimeline{Points: points, Chart: chartTimeline(points)})
+}
+
+func apiArtistTimelineHandler(c echo.Context) error {
+	points := currentState().artistTimeline(c.Param("slug"))
+	if points == nil {
+		return c.JSON(http.StatusNotFound, apiError{Error: "artist not found"})
+	}
+	return c.JSON(http.StatusOK, apiTimeline{Points: points, Chart: chartTimeline(points)})
+}
+
+// apiAlbumDistinctiveHandler lists the words that set an album and its
+// artist apart from the rest of the corpus, ranked by ?by=logodds (the
+// default) or ?by=tfidf.
+func apiAlbumDistinctiveHandler(c echo.Context) error {
+	s := currentState()
+	album, ok := s.findAlbumBySlug(c.Param("slug"))
+	if !ok {
+		return c.JSON(http.StatusNotFound, apiError{Error: "album not found"})
+	}
+
+	by := words.RankByLogOdds
+	if c.QueryParam("by") == words.RankByTFIDF {
+		by = words.RankByTFIDF
+	}
+
+	corpus := s.corpusVocabulary()
+	return c.JSON(http.StatusOK, apiDistinctiveWords{
+		Album:  words.RankDistinctive(corpus.Distinctive([]models.BandcampAlbumData{album}), by),
+		Artist: words.RankDistinctive(corpus.Distinctive(s.albumsByArtist(album.ArtistSlug)), by),
+	})
+}
+
+// apiSimilarityHandler returns the cosine similarity of every pair of albums.
+// Rows and columns follow the albums list, identified by slug.
+func apiSimilarityHandler(c echo.Context) error {
+	s := currentState()
+	ids, matrix := s.corpusVocabulary().SimilarityMatrix()
+
+	slugs := make(map[string]string, len(s.albums))
+	for _, album := range s.albums {
+		slugs[album.ID] = album.Slug
+	}
+	names := make([]string, 0, len(ids))
+	for _, id := range ids {
+		names = append(names, slugs[id])
+	}
+
+	return c.JSON(http.StatusOK, apiSimilarityMatrix{Albums: names, Matrix: matrix})
+}
+
+func apiCompareHandler(c echo.Context) error {
+	comparison, err := currentState().compareAlbums(parseCompareSlugs(c.QueryParam("albums")), parseFrequencyView(c))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, apiError{Error: err.Error()})
+	}
+
+	result := apiComparison{
+		SharedWords: comparison.Shared,
+		SharedTotal: comparison.SharedTotal,
+		WordLengths: comparison.WordLengths,
+	}
+	for _, compared := range comparison.Albums {
+		result.Albums = append(result.Albums, apiComparedAlbum{
+			apiAlbum:         toAPIAlbum(compared.Album),
+			TopWords:         compared.TopWords,
+			ExclusiveWords:   compared.Exclusive,
+			ExclusiveTotal:   compared.ExclusiveTotal,
+			WordLengthShares: compared.WordLengthShares,
+		})
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+func apiWordsHandler(c echo.Context) error {
+	s := currentState()
+	wordFrequencies := s.applyFrequencyView(s.languageWordFrequencies(parseLanguage(c)), parseFrequencyView(c))
+	page, perPage := parsePagination(c)
+	start, end := pageBounds(page, perPage, len(wordFrequencies))
+
+	return c.JSON(http.StatusOK, apiPage{
+		Data:    wordFrequencies[start:end],
+		Page:    page,
+		PerPage: perPage,
+		Total:   len(wordFrequencies),
+	})
+}
+
+func apiLanguagesHandler(c echo.Context) error {
+	return c.JSON(http.StatusOK, currentState().corpusLanguages())
+}
+
+func apiPhrasesHandler(c echo.Context) error {
+	n, rank := parsePhraseQuery(c)
+	phrases := words.RankNGrams(currentState().corpusPhrases(n), rank)
+	page, perPage := parsePagination(c)
+	start, end := pageBounds(page, perPage, len(phrases))
+
+	return c.JSON(http.StatusOK, apiPage{
+		Data:    phrases[start:end],
+		Page:    page,
+		PerPage: perPage,
+		Total:   len(phrases),
+	})
+}
+
+// apiWordHandler returns where a word is used, limit occurrences at a time
+// starting at offset.
+func apiWordHandler(c echo.Context) error {
+	concordance := words.BuildConcordance(c.Param("word"), currentState().albumsContaining(c.Param("word")), concordanceContextLines(c))
+	offset, limit := parseConcordanceWindow(c)
+	start := min(offset, len(concordance.Occurrences))
+	end := min(start+limit, len(concordance.Occurrences))
+	concordance.Occurrences = concordance.Occurrences[start:end]
+
+	return c.JSON(http.StatusOK, apiConcordance{Concordance: concordance, Offset: offset, Limit: limit})
+}
+
+func apiSearchHandler(c echo.Context) error {
+	results, err := currentState().index.Search(c.QueryParam("q"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, apiError{Error: err.Error()})
+	}
+
+	page, perPage := parsePagination(c)
+	start, end := pageBounds(page, perPage, len(results))
+
+	return c.JSON(http.StatusOK, apiPage{
+		Data:    results[start:end],
+		Page:    page,
+		PerPage: perPage,
+		Total:   len(results),
+	})
+}
+
+func (s *siteState) findAlbumBySlug(slug string) (models.BandcampAlbumData, bool) {
+	for _, album := range s.albums {
+		if album.Slug == slug {
+			return album, true
+		}
+	}
+	return models.BandcampAlbumData{}, false
+}
+
+func parsePagination(c echo.Context) (int, int) {
+	page, err := strconv.Atoi(c.QueryParam("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	perPage, err := strconv.Atoi(c.QueryParam("per_page"))
+	if err != nil || perPage < 1 {
+		perPage = defaultAPIPageSize
+	}
+	if perPage > maxAPIPageSize {
+		perPage = maxAPIPageSize
+	}
+
+	return page, perPage
+}
+
+// parseConcordanceWindow reads the offset and limit of a concordance request.
+// The limit is capped at maxSearchResults.
+func parseConcordanceWindow(c echo.Context) (int, int) {
+	offset, err := strconv.Atoi(c.QueryParam("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	limit, err := strconv.Atoi(c.QueryParam("limit"))
+	if err != nil || limit < 1 {
+		limit = defaultConcordanceLimit
+	}
+	if limit > maxSearchResults {
+		limit = maxSearchResults
+	}
+
+	return offset, limit
+}
+
+func pageBounds(page, perPage, total int) (int, int) {
+	start := (page - 1) * perPage
+	if start > total {
+		start = total
+	}
+	end := start + perPage
+	if end > total {
+		end = total
+	}
+	return start, end
+}
+
+func toAPIAlbum(album models.BandcampAlbumData) apiAlbum {
+	return apiAlbum{
+		ID:               album.ID,
+		Slug:             album.Slug,
+		ArtistName:       album.ArtistName,
+		ArtistSlug:       album.ArtistSlug,
+		AlbumName:        album.AlbumName,
+		ImageUrl:         album.ImageUrl,
+		BandcampUrl:      album.BandcampUrl,
+		MetalArchivesURL: album.MetalArchivesURL,
+		ReleaseDate:      album.ReleaseDate,
+		DateAdded:        album.DateAdded,
+		Genre:            album.Genre,
+		Country:          album.Country,
+		Label:            album.Label,
+		TotalLength:      album.TotalLength,
+		TrackCount:       len(album.Tracks),
+		Metrics: apiAlbumMetrics{
+			TotalWords:              album.TotalWords,
+			TotalUniqueWords:        album.TotalUniqueWords,
+			AverageWordsPerTrack:    album.AverageWordsPerTrack,
+			TotalVowels:             album.TotalVowelCount,
+			TotalConsonants:         album.TotalConsonantCount,
+			TotalCharacters:         album.TotalCharacters,
+			TotalCharactersNoSpaces: album.TotalCharactersNoSpaces,
+			TotalLines:              album.TotalLines,
+			WordsPerMinute:          calculateWPM(float64(album.TotalWords), float64(album.TotalLength)),
+			WordLengthDistribution:  album.WordLengthDistribution,
+			LexicalRichness:         album.Richness,
+			Sentiment:               album.Sentiment,
+		},
+	}
+}
+
+func toAPISimilarAlbums(similar []similarAlbum) []apiSimilarAlbum {
+	result := make([]apiSimilarAlbum, 0, len(similar))
+	for _, s := range similar {
+		result = append(result, apiSimilarAlbum{
+			Slug:       s.Album.Slug,
+			ArtistName: s.Album.ArtistName,
+			AlbumName:  s.Album.AlbumName,
+			Similarity: s.Similarity,
+		})
+	}
+	return result
+}
+
+func (s *siteState) toAPITracks(album models.BandcampAlbumData, view string) []apiTrack {
+	tracks := make([]apiTrack, 0, len(album.Tracks))
+	for _, track := range album.Tracks {
+		details := s.calculateTrackDetails(album.ID, track)
+		tracks = append(tracks, apiTrack{
+			Name:                    track.Name,
+			TrackNumber:             track.TrackNumber,
+			TotalLength:             track.TotalLength,
+			Lyrics:                  track.Lyrics,
+			TotalWords:              details.TotalWords,
+			UniqueWords:             details.UniqueWords,
+			WordsPerMinute:          details.WordsPerMinute,
+			VowelCount:              details.VowelCount,
+			ConsonantCount:          details.ConsonantCount,
+			TotalCharacters:         details.TotalCharacters,
+			TotalCharactersNoSpaces: details.TotalCharactersNoSpaces,
+			TotalLines:              details.TotalLines,
+			WordLengthDistribution:  details.WordLengthDistribution,
+			WordCounts:              s.applyFrequencyView(details.SortedWordCounts, view),
+			POS:                     details.POS,
+			LexicalRichness:         details.Richness,
+			Readability:             details.Readability,
+			Rhymes:                  details.Rhymes,
+			Profanity:               details.Profanity,
+			Sentiment:               details.Sentiment,
+			Bigrams:                 words.TrackNGrams(track, 2),
+			Trigrams:                words.TrackNGrams(track, 3),
+			Sections:                details.Sections,
+			Repetition:              details.Repetition,
+			Language:                words.TrackLanguage(track),
+		})
+	}
+	return tracks
+}
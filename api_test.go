@@ -0,0 +1,515 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"millions-of-words/models"
+	"millions-of-words/search"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func apiTestAlbums() []models.BandcampAlbumData {
+	return []models.BandcampAlbumData{
+		{ID: "1", Slug: "artist1-album1", ArtistName: "Artist1", ArtistSlug: "artist1", AlbumName: "Album1", TotalWords: 3, Enabled: true,
+			Tracks: []models.BandcampTrackData{{Name: "Song", TrackNumber: 1, Lyrics: "test word test"}}},
+		{ID: "2", Slug: "artist2-album2", ArtistName: "Artist2", ArtistSlug: "artist2", AlbumName: "Album2", Enabled: true},
+		{ID: "3", Slug: "artist3-album3", ArtistName: "Artist3", ArtistSlug: "artist3", AlbumName: "Album3", Enabled: true},
+	}
+}
+
+func setAPITestAlbums() {
+	replaceAlbums(apiTestAlbums())
+}
+
+func TestAPIAlbumsHandlerPaginates(t *testing.T) {
+	setAPITestAlbums()
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/albums?page=2&per_page=2", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	if assert.NoError(t, apiAlbumsHandler(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var page struct {
+			Data    []apiAlbum `json:"data"`
+			Page    int        `json:"page"`
+			PerPage int        `json:"per_page"`
+			Total   int        `json:"total"`
+		}
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &page))
+		assert.Equal(t, 2, page.Page)
+		assert.Equal(t, 3, page.Total)
+		if assert.Len(t, page.Data, 1) {
+			assert.Equal(t, "artist3-album3", page.Data[0].Slug)
+		}
+	}
+}
+
+func TestAPIAlbumHandler(t *testing.T) {
+	setAPITestAlbums()
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("/api/v1/albums/:slug")
+	c.SetParamNames("slug")
+	c.SetParamValues("artist1-album1")
+
+	if assert.NoError(t, apiAlbumHandler(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var album apiAlbumDetails
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &album))
+		assert.Equal(t, 3, album.Metrics.TotalWords)
+		assert.Equal(t, []models.WordCount{{Word: "test", Count: 2}, {Word: "word", Count: 1}}, album.TopWords)
+		if assert.Len(t, album.Tracks, 1) {
+			assert.Equal(t, 3, album.Tracks[0].TotalWords)
+			assert.Equal(t, 2, album.Tracks[0].UniqueWords)
+		}
+	}
+}
+
+func TestAPIAlbumHandlerNormalizesLyrics(t *testing.T) {
+	replaceAlbums([]models.BandcampAlbumData{{
+		ID: "fancy", Slug: "fancy-album", ArtistName: "Fancy", AlbumName: "Album", Enabled: true,
+		Tracks: []models.BandcampTrackData{{Name: "Song", TrackNumber: 1, Lyrics: "𝐟𝐢𝐫𝐞 &amp; i\u200bce"}},
+	}})
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("/api/v1/albums/:slug")
+	c.SetParamNames("slug")
+	c.SetParamValues("fancy-album")
+
+	if assert.NoError(t, apiAlbumHandler(c)) {
+		var album apiAlbumDetails
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &album))
+		if assert.Len(t, album.Tracks, 1) {
+			assert.Equal(t, "fire & ice", album.Tracks[0].Lyrics)
+			assert.Equal(t, 2, album.Tracks[0].TotalWords)
+		}
+	}
+	assert.Contains(t, currentState().albums[0].Tracks[0].Lyrics, "&amp;", "the loaded album was normalised in place")
+}
+
+func TestAPIAlbumHandlerRepetition(t *testing.T) {
+	albums := apiTestAlbums()
+	albums[0].Tracks[0].Lyrics = "[Chorus]\nburn it down (x2)\n\n[Verse]\nnight\n\n[Chorus]"
+	replaceAlbums(albums)
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("slug")
+	c.SetParamValues("artist1-album1")
+
+	if assert.NoError(t, apiAlbumHandler(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var album apiAlbumDetails
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &album))
+		assert.Equal(t, 13, album.Repetition.WordsWithRepeats)
+		assert.Equal(t, 4, album.Repetition.WordsWithoutRepeats)
+		if assert.Len(t, album.Tracks, 1) && assert.Len(t, album.Tracks[0].Sections, 3) {
+			assert.Equal(t, "Chorus", album.Tracks[0].Sections[2].Label)
+			assert.Equal(t, 0, album.Tracks[0].Sections[2].RepeatOf)
+			assert.Equal(t, 1, album.Tracks[0].Repetition.RepeatedSections)
+		}
+	}
+}
+
+func TestAPIAlbumHandlerNotFound(t *testing.T) {
+	setAPITestAlbums()
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("slug")
+	c.SetParamValues("missing")
+
+	if assert.NoError(t, apiAlbumHandler(c)) {
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	}
+}
+
+func TestAPIAlbumDistinctiveHandler(t *testing.T) {
+	replaceAlbums([]models.BandcampAlbumData{
+		{ID: "1", Slug: "war", ArtistName: "Host", ArtistSlug: "host", Enabled: true, Tracks: []models.BandcampTrackData{{Lyrics: "the sword the sword the night"}}},
+		{ID: "2", Slug: "sea", ArtistName: "Tide", ArtistSlug: "tide", Enabled: true, Tracks: []models.BandcampTrackData{{Lyrics: "the waves the waves the night"}}},
+	})
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/?by=tfidf", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("slug")
+	c.SetParamValues("war")
+
+	if assert.NoError(t, apiAlbumDistinctiveHandler(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var distinctive apiDistinctiveWords
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &distinctive))
+		if assert.NotEmpty(t, distinctive.Album) {
+			assert.Equal(t, "sword", distinctive.Album[0].Word)
+			assert.Equal(t, 2, distinctive.Album[0].Count)
+		}
+		assert.Equal(t, distinctive.Album, distinctive.Artist)
+	}
+}
+
+func TestAPISimilarityHandler(t *testing.T) {
+	replaceAlbums([]models.BandcampAlbumData{
+		{ID: "1", Slug: "fire", Enabled: true, Tracks: []models.BandcampTrackData{{Lyrics: "fire and ice"}}},
+		{ID: "2", Slug: "stone", Enabled: true, Tracks: []models.BandcampTrackData{{Lyrics: "fire and stone"}}},
+	})
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/similarity", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	if assert.NoError(t, apiSimilarityHandler(c)) {
+		var similarity apiSimilarityMatrix
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &similarity))
+		assert.Equal(t, []string{"fire", "stone"}, similarity.Albums)
+		if assert.Len(t, similarity.Matrix, 2) {
+			assert.Equal(t, 1.0, similarity.Matrix[0][0])
+			assert.Equal(t, similarity.Matrix[0][1], similarity.Matrix[1][0])
+		}
+	}
+}
+
+func TestAPICompareHandler(t *testing.T) {
+	setAPITestAlbums()
+	e := echo.New()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/compare?albums=artist1-album1,artist2-album2", nil)
+	rec := httptest.NewRecorder()
+	if assert.NoError(t, apiCompareHandler(e.NewContext(req, rec))) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var comparison apiComparison
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &comparison))
+		if assert.Len(t, comparison.Albums, 2) {
+			assert.Equal(t, "artist1-album1", comparison.Albums[0].Slug)
+			assert.Equal(t, 2, comparison.Albums[0].ExclusiveTotal)
+		}
+		assert.Empty(t, comparison.SharedWords)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/compare?albums=artist1-album1", nil)
+	rec = httptest.NewRecorder()
+	if assert.NoError(t, apiCompareHandler(e.NewContext(req, rec))) {
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	}
+}
+
+func TestAPIArtistHandler(t *testing.T) {
+	setAPITestAlbums()
+	e := echo.New()
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("slug")
+	c.SetParamValues("artist1")
+	if assert.NoError(t, apiArtistHandler(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var artist apiArtist
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &artist))
+		assert.Equal(t, "Artist1", artist.Name)
+		assert.Equal(t, 3, artist.TotalWords)
+		if assert.Len(t, artist.Albums, 1) {
+			assert.Equal(t, "artist1", artist.Albums[0].ArtistSlug)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	c.SetParamNames("slug")
+	c.SetParamValues("missing")
+	if assert.NoError(t, apiArtistHandler(c)) {
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	}
+}
+
+func TestAPITimelineHandler(t *testing.T) {
+	replaceAlbums([]models.BandcampAlbumData{
+		{ID: "1", Slug: "later", ArtistSlug: "artist", AlbumName: "Later", ReleaseDate: "2003-01-01", Enabled: true,
+			Tracks: []models.BandcampTrackData{{Lyrics: "fire and ice"}}},
+		{ID: "2", Slug: "debut", ArtistSlug: "artist", AlbumName: "Debut", ReleaseDate: "1999-01-01", Enabled: true,
+			Tracks: []models.BandcampTrackData{{Lyrics: "fire"}}},
+	})
+	e := echo.New()
+
+	rec := httptest.NewRecorder()
+	if assert.NoError(t, apiTimelineHandler(e.NewContext(httptest.NewRequest(http.MethodGet, "/api/v1/timeline", nil), rec))) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var timeline apiTimeline
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &timeline))
+		assert.Equal(t, []string{"1999", "2003"}, timeline.Chart.Labels)
+		if assert.Len(t, timeline.Points, 2) {
+			assert.Equal(t, 2, timeline.Points[1].NewWords)
+			assert.Equal(t, 3, timeline.Points[1].Vocabulary)
+		}
+		if assert.Len(t, timeline.Chart.Datasets, 3) {
+			assert.Equal(t, []float64{1, 2}, timeline.Chart.Datasets[1].Data)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+	c.SetParamNames("slug")
+	c.SetParamValues("artist")
+	if assert.NoError(t, apiArtistTimelineHandler(c)) {
+		var timeline apiTimeline
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &timeline))
+		assert.Equal(t, []string{"Debut (1999)", "Later (2003)"}, timeline.Chart.Labels)
+	}
+
+	rec = httptest.NewRecorder()
+	c = e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+	c.SetParamNames("slug")
+	c.SetParamValues("missing")
+	if assert.NoError(t, apiArtistTimelineHandler(c)) {
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	}
+}
+
+func TestAPIWordsHandler(t *testing.T) {
+	setAPITestAlbums()
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/words?per_page=1", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	if assert.NoError(t, apiWordsHandler(c)) {
+		var page struct {
+			Data  []models.WordCount `json:"data"`
+			Total int                `json:"total"`
+		}
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &page))
+		assert.Equal(t, 2, page.Total)
+		assert.Equal(t, []models.WordCount{{Word: "test", Count: 2}}, page.Data)
+	}
+}
+
+func TestAPIWordHandlerLimitsOccurrences(t *testing.T) {
+	replaceAlbums([]models.BandcampAlbumData{{
+		ID: "fire", Slug: "fire-album", Enabled: true,
+		Tracks: []models.BandcampTrackData{{Name: "Song", TrackNumber: 1, Lyrics: "fire\nfire\nfire\nfire\nfire"}},
+	}})
+	e := echo.New()
+
+	for _, tc := range []struct {
+		query       string
+		offset      int
+		limit       int
+		occurrences int
+	}{
+		{"", 0, defaultConcordanceLimit, 5},
+		{"?offset=1&limit=2", 1, 2, 2},
+		{"?offset=4&limit=2", 4, 2, 1},
+		{"?offset=10", 10, defaultConcordanceLimit, 0},
+		{"?limit=100000&offset=-3", 0, maxSearchResults, 5},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/words/fire"+tc.query, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("word")
+		c.SetParamValues("fire")
+
+		if assert.NoError(t, apiWordHandler(c)) {
+			var concordance apiConcordance
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &concordance))
+			assert.Equal(t, 5, concordance.Total, tc.query)
+			assert.Equal(t, tc.offset, concordance.Offset, tc.query)
+			assert.Equal(t, tc.limit, concordance.Limit, tc.query)
+			assert.Len(t, concordance.Occurrences, tc.occurrences, tc.query)
+		}
+	}
+}
+
+func setLanguageTestAlbums() {
+	albums := apiTestAlbums()
+	albums[0].Tracks[0].Language = "en"
+	albums[1].Tracks = []models.BandcampTrackData{
+		{Name: "Lied", TrackNumber: 1, Lyrics: "nacht nacht feuer", Language: "de"},
+		{Name: "Song", TrackNumber: 2, Lyrics: "night", Language: "en"},
+	}
+	replaceAlbums(albums)
+}
+
+func TestAPIWordsHandlerLanguage(t *testing.T) {
+	setLanguageTestAlbums()
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/words?lang=DE", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	if assert.NoError(t, apiWordsHandler(c)) {
+		var page struct {
+			Data  []models.WordCount `json:"data"`
+			Total int                `json:"total"`
+		}
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &page))
+		assert.Equal(t, []models.WordCount{{Word: "nacht", Count: 2}, {Word: "feuer", Count: 1}}, page.Data)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/words?lang=xx", nil)
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+
+	if assert.NoError(t, apiWordsHandler(c)) {
+		assert.Contains(t, rec.Body.String(), `"total":0`)
+	}
+}
+
+func TestAPILanguagesHandler(t *testing.T) {
+	setLanguageTestAlbums()
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/languages", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	if assert.NoError(t, apiLanguagesHandler(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var languages []models.LanguageShare
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &languages))
+		assert.Equal(t, []models.LanguageShare{
+			{Language: "en", Name: "English", Albums: 2, Tracks: 2, TotalWords: 4, UniqueWords: 3},
+			{Language: "de", Name: "German", Albums: 1, Tracks: 1, TotalWords: 3, UniqueWords: 2},
+		}, languages)
+	}
+}
+
+func TestAPIWordsHandlerViews(t *testing.T) {
+	replaceAlbums([]models.BandcampAlbumData{
+		{ID: "1", Enabled: true, Tracks: []models.BandcampTrackData{
+			{Lyrics: "the fire burns\nthe burning fire\nwe burn"},
+		}},
+	})
+
+	tests := []struct {
+		view string
+		want []models.WordCount
+	}{
+		{"filtered", []models.WordCount{{Word: "fire", Count: 2}, {Word: "burn", Count: 1}, {Word: "burning", Count: 1}, {Word: "burns", Count: 1}}},
+		{"lemmatized", []models.WordCount{{Word: "burn", Count: 3}, {Word: "fire", Count: 2}}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.view, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/words?view="+tc.view, nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			if assert.NoError(t, apiWordsHandler(c)) {
+				var page struct {
+					Data []models.WordCount `json:"data"`
+				}
+				assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &page))
+				assert.Equal(t, tc.want, page.Data)
+			}
+		})
+	}
+}
+
+func TestAPIPhrasesHandler(t *testing.T) {
+	replaceAlbums([]models.BandcampAlbumData{
+		{ID: "1", Enabled: true, Tracks: []models.BandcampTrackData{
+			{Lyrics: "black sun rising\nunder the black sun\nblack sun rising"},
+		}},
+	})
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/phrases?n=2&rank=count", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	if assert.NoError(t, apiPhrasesHandler(c)) {
+		var page struct {
+			Data  []models.NGram `json:"data"`
+			Total int            `json:"total"`
+		}
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &page))
+		assert.Equal(t, 2, page.Total)
+		assert.Equal(t, "black sun", page.Data[0].Phrase)
+		assert.Equal(t, 3, page.Data[0].Count)
+		assert.Equal(t, "sun rising", page.Data[1].Phrase)
+	}
+}
+
+func TestAPISearchHandler(t *testing.T) {
+	replaceAlbums([]models.BandcampAlbumData{
+		{Slug: "a", Tracks: []models.BandcampTrackData{{Name: "One", Lyrics: "into the fire"}, {Name: "Two", Lyrics: "cold night"}}},
+	})
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/search?q=fire", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	if assert.NoError(t, apiSearchHandler(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var page struct {
+			Data  []search.Result `json:"data"`
+			Total int             `json:"total"`
+		}
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &page))
+		assert.Equal(t, 1, page.Total)
+		if assert.Len(t, page.Data, 1) {
+			assert.Equal(t, "One", page.Data[0].TrackName)
+		}
+	}
+}
+
+func TestAPISearchHandlerRejectsInvalidQuery(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/search?q=NOT+fire", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	if assert.NoError(t, apiSearchHandler(c)) {
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	}
+}
+
+func TestAPIAlbumHandlerCachesUntilAlbumChanges(t *testing.T) {
+	setAPITestAlbums()
+	e := echo.New()
+	get := func() apiAlbumDetails {
+		rec := httptest.NewRecorder()
+		c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+		c.SetParamNames("slug")
+		c.SetParamValues("artist1-album1")
+		var album apiAlbumDetails
+		if assert.NoError(t, apiAlbumHandler(c)) {
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &album))
+		}
+		return album
+	}
+
+	first := get()
+	_, cached := currentState().apiAlbums.Load("1:" + viewRaw)
+	assert.True(t, cached)
+	assert.Equal(t, first, get())
+
+	edited := apiTestAlbums()[0]
+	edited.Tracks[0].Lyrics = "night night night night"
+	applyAlbumChange(edited)
+
+	_, cached = currentState().apiAlbums.Load("1:" + viewRaw)
+	assert.False(t, cached)
+	if album := get(); assert.Len(t, album.Tracks, 1) {
+		assert.Equal(t, 4, album.Tracks[0].TotalWords)
+	}
+}
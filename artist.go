@@ -0,0 +1,159 @@
+package main
+
+import (
+	"log"
+	"sort"
+
+	"millions-of-words/models"
+	"millions-of-words/words"
+)
+
+// artistProfile is the discography-wide numbers for one artist.
+type artistProfile struct {
+	// Albums are the artist's enabled albums, oldest release first.
+	Albums      []artistAlbum
+	TotalTracks int
+	TotalWords  int
+	UniqueWords int
+	TotalLength int
+	WPM         float64
+	Richness    models.LexicalRichness
+	Profanity   models.ProfanityCounts
+	TopWords    []models.WordCount
+	Distinctive []models.DistinctiveWord
+}
+
+// artistAlbum is one row of an artist's chronological album list.
+type artistAlbum struct {
+	Album     models.BandcampAlbumData
+	WPM       float64
+	Profanity models.ProfanityCounts
+}
+
+// Year is the album's release year, or "" when the date is unknown.
+func (a artistAlbum) Year() string {
+	if len(a.Album.ReleaseDate) < 4 {
+		return ""
+	}
+	return a.Album.ReleaseDate[:4]
+}
+
+// albumsChronologically sorts albums by release date. Albums without one go
+// last, in the order they came.
+func albumsChronologically(albums []models.BandcampAlbumData) []models.BandcampAlbumData {
+	sorted := append([]models.BandcampAlbumData(nil), albums...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i].ReleaseDate, sorted[j].ReleaseDate
+		if a == "" || b == "" {
+			return b == "" && a != ""
+		}
+		return a < b
+	})
+	return sorted
+}
+
+// artistFromAlbums stands in for an artist that has no stored record yet,
+// taking the name, country and genre from the first album that has them.
+func artistFromAlbums(slug string, albums []models.BandcampAlbumData) models.Artist {
+	artist := models.Artist{Slug: slug}
+	for _, album := range albums {
+		if artist.Name == "" {
+			artist.Name = album.ArtistName
+		}
+		if artist.Country == "" {
+			artist.Country = album.Country
+		}
+		if artist.Genre == "" {
+			artist.Genre = album.Genre
+		}
+	}
+	return artist
+}
+
+func (s *siteState) prepareArtistProfile(slug, view string) (artistProfile, bool) {
+	cacheKey := slug + ":" + view
+	if cached, ok := s.artistPages.Load(cacheKey); ok {
+		return cached.(artistProfile), true
+	}
+
+	artistAlbums := s.albumsByArtist(slug)
+	if len(artistAlbums) == 0 {
+		return artistProfile{}, false
+	}
+
+	profile := artistProfile{
+		Richness:    words.ArtistLexicalRichness(artistAlbums),
+		Profanity:   s.lexicon.Corpus(artistAlbums),
+		Distinctive: topDistinctive(s.corpusVocabulary().Distinctive(artistAlbums)),
+	}
+
+	counts := make(map[string]int)
+	for _, album := range albumsChronologically(artistAlbums) {
+		profile.TotalTracks += len(album.Tracks)
+		profile.TotalWords += album.TotalWords
+		profile.TotalLength += album.TotalLength
+		for _, wc := range words.AggregateWordFrequencies(album) {
+			counts[wc.Word] += wc.Count
+		}
+		profile.Albums = append(profile.Albums, artistAlbum{
+			Album:     album,
+			WPM:       calculateWPM(float64(album.TotalWords), float64(album.TotalLength)),
+			Profanity: s.lexicon.Album(album),
+		})
+	}
+	profile.UniqueWords = len(counts)
+	profile.WPM = calculateWPM(float64(profile.TotalWords), float64(profile.TotalLength))
+	profile.TopWords = s.applyFrequencyView(words.MapToSortedList(counts), view)
+	if len(profile.TopWords) > maxTopWords {
+		profile.TopWords = profile.TopWords[:maxTopWords]
+	}
+
+	s.artistPages.Store(cacheKey, profile)
+	return profile, true
+}
+
+// findArtist returns the stored artist, or one made up from its albums when
+// the store has no record of it.
+func (s *siteState) findArtist(slug string) models.Artist {
+	artist, err := store.GetArtistBySlug(slug)
+	if err != nil {
+		return artistFromAlbums(slug, s.albumsByArtist(slug))
+	}
+	return artist
+}
+
+// loadArtists adds a stored artist for every artist that so far only exists
+// as a name on its albums, so that the admin can edit it.
+func loadArtists() {
+	existing, err := store.LoadArtists()
+	if err != nil {
+		log.Printf("Error loading artists: %v", err)
+		return
+	}
+
+	known := make(map[string]bool, len(existing))
+	for _, artist := range existing {
+		known[artist.Slug] = true
+	}
+
+	bySlug := make(map[string][]models.BandcampAlbumData)
+	var slugs []string
+	for _, album := range currentState().albums {
+		if known[album.ArtistSlug] || album.ArtistSlug == "" {
+			continue
+		}
+		if _, ok := bySlug[album.ArtistSlug]; !ok {
+			slugs = append(slugs, album.ArtistSlug)
+		}
+		bySlug[album.ArtistSlug] = append(bySlug[album.ArtistSlug], album)
+	}
+
+	if len(slugs) > 0 {
+		log.Printf("Adding %d artists from album data", len(slugs))
+	}
+	for _, slug := range slugs {
+		if err := store.SaveArtist(artistFromAlbums(slug, bySlug[slug])); err != nil {
+			log.Printf("Error saving artist %q: %v", slug, err)
+		}
+	}
+}
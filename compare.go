@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"millions-of-words/models"
+	"millions-of-words/words"
+)
+
+const (
+	maxComparedAlbums = 6
+	maxCompareWords   = 30
+)
+
+// albumComparison lines up the stats and vocabulary of a few albums.
+type albumComparison struct {
+	Albums []comparedAlbum
+	// Shared are the words every album uses, by combined count.
+	Shared      []models.WordCount
+	SharedTotal int
+	// WordLengths is the x axis of the word length overlay. Each album's
+	// WordLengthShares has one entry per length.
+	WordLengths []int
+}
+
+type comparedAlbum struct {
+	Album            models.BandcampAlbumData
+	WPM              float64
+	TopWords         []models.WordCount
+	Exclusive        []models.WordCount
+	ExclusiveTotal   int
+	WordLengthShares []float64
+}
+
+// parseCompareSlugs splits the comma separated albums parameter, dropping
+// blanks and repeats.
+func parseCompareSlugs(param string) []string {
+	var slugs []string
+	seen := make(map[string]bool)
+	for _, slug := range strings.Split(param, ",") {
+		slug = strings.TrimSpace(slug)
+		if slug != "" && !seen[slug] {
+			seen[slug] = true
+			slugs = append(slugs, slug)
+		}
+	}
+	return slugs
+}
+
+// compareAlbums looks up the albums by slug and compares them, with word
+// counts shown in the given frequency view.
+func (s *siteState) compareAlbums(slugs []string, view string) (albumComparison, error) {
+	if len(slugs) < 2 {
+		return albumComparison{}, fmt.Errorf("pick at least two albums to compare")
+	}
+	if len(slugs) > maxComparedAlbums {
+		return albumComparison{}, fmt.Errorf("at most %d albums can be compared at once", maxComparedAlbums)
+	}
+
+	var comparison albumComparison
+	frequencies := make([]map[string]int, 0, len(slugs))
+	lengths := make(map[int]bool)
+	for _, slug := range slugs {
+		album, ok := s.findAlbumBySlug(slug)
+		if !ok {
+			return albumComparison{}, fmt.Errorf("album not found: %s", slug)
+		}
+
+		counts := s.applyFrequencyView(words.AggregateWordFrequencies(album), view)
+		byWord := make(map[string]int, len(counts))
+		for _, wc := range counts {
+			byWord[wc.Word] = wc.Count
+		}
+		frequencies = append(frequencies, byWord)
+
+		for length := range album.WordLengthDistribution {
+			lengths[length] = true
+		}
+
+		comparison.Albums = append(comparison.Albums, comparedAlbum{
+			Album:    album,
+			WPM:      calculateWPM(float64(album.TotalWords), float64(album.TotalLength)),
+			TopWords: truncateWordCounts(counts, maxCompareWords),
+		})
+	}
+
+	shared := make(map[string]int)
+	for word := range frequencies[0] {
+		total := 0
+		for _, byWord := range frequencies {
+			count, ok := byWord[word]
+			if !ok {
+				total = 0
+				break
+			}
+			total += count
+		}
+		if total > 0 {
+			shared[word] = total
+		}
+	}
+	comparison.SharedTotal = len(shared)
+	comparison.Shared = truncateWordCounts(words.MapToSortedList(shared), maxCompareWords)
+
+	for i, byWord := range frequencies {
+		exclusive := make(map[string]int)
+		for word, count := range byWord {
+			if !usedByOthers(frequencies, i, word) {
+				exclusive[word] = count
+			}
+		}
+		comparison.Albums[i].ExclusiveTotal = len(exclusive)
+		comparison.Albums[i].Exclusive = truncateWordCounts(words.MapToSortedList(exclusive), maxCompareWords)
+	}
+
+	for length := range lengths {
+		comparison.WordLengths = append(comparison.WordLengths, length)
+	}
+	sort.Ints(comparison.WordLengths)
+	for i := range comparison.Albums {
+		comparison.Albums[i].WordLengthShares = wordLengthShares(comparison.Albums[i].Album.WordLengthDistribution, comparison.WordLengths)
+	}
+
+	return comparison, nil
+}
+
+func usedByOthers(frequencies []map[string]int, self int, word string) bool {
+	for i, byWord := range frequencies {
+		if i != self && byWord[word] > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// wordLengthShares turns a word length distribution into percentages, so
+// albums of different lengths can share one chart.
+func wordLengthShares(distribution map[int]int, lengths []int) []float64 {
+	total := 0
+	for _, count := range distribution {
+		total += count
+	}
+
+	shares := make([]float64, len(lengths))
+	if total == 0 {
+		return shares
+	}
+	for i, length := range lengths {
+		shares[i] = float64(distribution[length]) * 100 / float64(total)
+	}
+	return shares
+}
+
+func truncateWordCounts(counts []models.WordCount, limit int) []models.WordCount {
+	if counts == nil {
+		return []models.WordCount{}
+	}
+	if len(counts) > limit {
+		return counts[:limit]
+	}
+	return counts
+}
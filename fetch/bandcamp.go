@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"millions-of-words/models"
+	"millions-of-words/words"
 
 	"github.com/PuerkitoBio/goquery"
 )
@@ -51,7 +52,8 @@ func FetchFromBandcamp(url string) (models.BandcampAlbumData, error) {
 
 	return models.BandcampAlbumData{
 		ID:                strings.TrimSpace(artistName) + " - " + strings.TrimSpace(albumName),
-		Slug:              generateSlug(strings.TrimSpace(artistName) + " - " + strings.TrimSpace(albumName)),
+		Slug:              GenerateSlug(strings.TrimSpace(artistName) + " - " + strings.TrimSpace(albumName)),
+		ArtistSlug:        GenerateSlug(strings.TrimSpace(artistName)),
 		ArtistName:        strings.TrimSpace(artistName),
 		AlbumName:         strings.TrimSpace(albumName),
 		ImageUrl:          imageUrl,
@@ -85,7 +87,7 @@ func processTracklist(doc *goquery.Document) ([]models.BandcampTrackData, time.D
 
 		totalAlbumDuration += trackDuration
 
-		lyrics := strings.TrimSpace(s.Next().Find("div").Text())
+		lyrics := strings.TrimSpace(words.NormalizeText(s.Next().Find("div").Text()))
 		if strings.HasPrefix(lyrics, "lyrics") || strings.Contains(lyrics, "buy track") {
 			lyrics = ""
 		}
@@ -166,7 +168,8 @@ func calculateAverageColor(imageData []byte) (string, error) {
 
 var multipleHyphens = regexp.MustCompile(`-+`)
 
-func generateSlug(id string) string {
+// GenerateSlug turns a name into the lowercase, hyphenated form used in URLs.
+func GenerateSlug(id string) string {
 	slug := strings.ToLower(id)
 	slug = strings.ReplaceAll(slug, "&", "and")
 	slug = strings.ReplaceAll(slug, ",", "")
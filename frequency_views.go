@@ -0,0 +1,74 @@
+package main
+
+import (
+	"log"
+
+	"millions-of-words/models"
+	"millions-of-words/words"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Word frequency lists can be shown as counted, without stopwords, or with
+// inflections grouped under their base form.
+const (
+	viewRaw        = "raw"
+	viewFiltered   = "filtered"
+	viewLemmatized = "lemmatized"
+)
+
+type frequencyView struct {
+	Key         string
+	Label       string
+	Description string
+}
+
+var frequencyViews = []frequencyView{
+	{viewRaw, "All words", "Every word as it appears in the lyrics"},
+	{viewFiltered, "No stopwords", "Leaves out words like \"the\", \"and\" and \"of\""},
+	{viewLemmatized, "Lemmatised", "Leaves out stopwords and counts burn, burns, burning and burned together"},
+}
+
+// loadStopwords reads the admin's stopwords from the store. If the store
+// cannot be read only the built-in stopwords are used.
+func loadStopwords() {
+	extra, err := store.LoadStopwords()
+	if err != nil {
+		log.Printf("Error loading stopwords, using built-in list: %v", err)
+	}
+	setStopwords(words.NewStopwords(extra))
+}
+
+// lemmatizer is built from the corpus vocabulary on first use.
+func (s *siteState) lemmatizer() *words.Lemmatizer {
+	if lemmatizer := s.lemmas.Load(); lemmatizer != nil {
+		return lemmatizer
+	}
+	lemmatizer := words.NewLemmatizer(s.index.WordFrequencies())
+	s.lemmas.Store(lemmatizer)
+	return lemmatizer
+}
+
+// parseFrequencyView reads the view query parameter, defaulting to raw counts.
+func parseFrequencyView(c echo.Context) string {
+	switch view := c.QueryParam("view"); view {
+	case viewFiltered, viewLemmatized:
+		return view
+	default:
+		return viewRaw
+	}
+}
+
+// applyFrequencyView returns the frequencies as the view shows them. Raw
+// frequencies are returned as they are and must not be modified.
+func (s *siteState) applyFrequencyView(frequencies []models.WordCount, view string) []models.WordCount {
+	switch view {
+	case viewFiltered:
+		return s.stopwords.Filter(frequencies)
+	case viewLemmatized:
+		// Grouping first means a lemma like "do" (from "done") is filtered too.
+		return s.stopwords.Filter(s.lemmatizer().Group(frequencies))
+	default:
+		return frequencies
+	}
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"log"
+	"sync"
+
+	"millions-of-words/loaders"
+	"millions-of-words/models"
+	"millions-of-words/words"
+	"millions-of-words/words/pos"
+	"millions-of-words/words/profanity"
+)
+
+// indexedStore wraps a Store so that every album write also publishes a new
+// siteState with just that album changed, instead of reloading and
+// re-tokenising the whole corpus.
+type indexedStore struct {
+	loaders.Store
+}
+
+func (s indexedStore) SaveAlbum(album models.BandcampAlbumData) error {
+	if err := s.Store.SaveAlbum(album); err != nil {
+		return err
+	}
+	s.addArtist(album)
+	s.refreshAlbum(s.Store.GetAlbumBySlug(album.Slug))
+	return nil
+}
+
+// addArtist stores the artist of a newly saved album unless it is already
+// known, so that an import never leaves an album pointing at nothing.
+func (s indexedStore) addArtist(album models.BandcampAlbumData) {
+	if album.ArtistSlug == "" {
+		return
+	}
+	if _, err := s.Store.GetArtistBySlug(album.ArtistSlug); err == nil {
+		return
+	}
+	artist := artistFromAlbums(album.ArtistSlug, []models.BandcampAlbumData{album})
+	if err := s.Store.SaveArtist(artist); err != nil {
+		log.Printf("Error saving artist for album %s: %v", album.ID, err)
+	}
+}
+
+func (s indexedStore) UpdateTrack(req models.UpdateTrackRequest) error {
+	if err := s.Store.UpdateTrack(req); err != nil {
+		return err
+	}
+	s.refreshAlbum(s.Store.GetAlbumByID(req.AlbumID))
+	return nil
+}
+
+func (s indexedStore) UpdateAlbum(req models.UpdateAlbumRequest) error {
+	if err := s.Store.UpdateAlbum(req); err != nil {
+		return err
+	}
+	s.refreshAlbum(s.Store.GetAlbumByID(req.AlbumID))
+	return nil
+}
+
+func (s indexedStore) SaveProfanityTerm(term models.ProfanityTerm) error {
+	if err := s.Store.SaveProfanityTerm(term); err != nil {
+		return err
+	}
+	s.refreshProfanityLexicon()
+	return nil
+}
+
+func (s indexedStore) DeleteProfanityTerm(id int64) error {
+	if err := s.Store.DeleteProfanityTerm(id); err != nil {
+		return err
+	}
+	s.refreshProfanityLexicon()
+	return nil
+}
+
+func (s indexedStore) SaveStopword(word string) error {
+	if err := s.Store.SaveStopword(word); err != nil {
+		return err
+	}
+	s.refreshStopwords()
+	return nil
+}
+
+func (s indexedStore) DeleteStopword(word string) error {
+	if err := s.Store.DeleteStopword(word); err != nil {
+		return err
+	}
+	s.refreshStopwords()
+	return nil
+}
+
+// refreshStopwords rebuilds the stopword list after an admin edit.
+func (s indexedStore) refreshStopwords() {
+	extra, err := s.Store.LoadStopwords()
+	if err != nil {
+		log.Printf("Error reloading stopwords after write: %v", err)
+		return
+	}
+	setStopwords(words.NewStopwords(extra))
+}
+
+// refreshProfanityLexicon rebuilds the lexicon after an admin edit.
+func (s indexedStore) refreshProfanityLexicon() {
+	terms, err := s.Store.LoadProfanityTerms()
+	if err != nil {
+		log.Printf("Error reloading profanity terms after write: %v", err)
+		return
+	}
+	setProfanityLexicon(profanity.NewLexicon(terms))
+}
+
+// setStopwords publishes a state with the new stopwords. Word lists on the
+// album and artist pages are filtered with them, so the caches start over.
+func setStopwords(stopwords words.Stopwords) {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	current := currentState()
+	publishState(current.reconfigured(current.lexicon, stopwords))
+}
+
+// setProfanityLexicon publishes a state with the new lexicon, recounting the
+// home page profanity.
+func setProfanityLexicon(lexicon *profanity.Lexicon) {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	current := currentState()
+	publishState(current.reconfigured(lexicon, current.stopwords))
+}
+
+// The write already succeeded, so a failed re-read is only logged; the change
+// shows up on the next full load.
+func (s indexedStore) refreshAlbum(album models.BandcampAlbumData, err error) {
+	if err != nil {
+		log.Printf("Error reloading album after write: %v", err)
+		return
+	}
+	applyAlbumChange(album)
+}
+
+// applyAlbumChange publishes a state with the latest version of one album in
+// the album list and index. Disabled albums are dropped from both. New albums
+// go first, to match the newest-first order of LoadAlbumsData. The index is
+// updated in place, so the state being replaced sees the change too. The new
+// state starts with empty caches, as album pages also show artist-wide
+// numbers and one album changing can make the cached details of other albums
+// stale.
+func applyAlbumChange(album models.BandcampAlbumData) {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	current := currentState()
+	updated := make([]models.BandcampAlbumData, 0, len(current.albums)+1)
+	if album.Enabled && !containsAlbum(current.albums, album.ID) {
+		updated = append(updated, album)
+	}
+	for _, existing := range current.albums {
+		if existing.ID != album.ID {
+			updated = append(updated, existing)
+		} else if album.Enabled {
+			updated = append(updated, album)
+		}
+	}
+
+	if album.Enabled {
+		current.index.SetAlbum(album)
+	} else {
+		current.index.RemoveAlbum(album.ID)
+	}
+	pos.ClearCache()
+
+	publishState(newSiteState(updated, current.index, current.lexicon, current.stopwords))
+	// The album vectors and similarity matrix take time to build, so build
+	// them in the background instead of holding up the write or the next
+	// album page. A page that needs them before the build is done builds its
+	// own, duplicating the work rather than waiting for it.
+	requestSimilarityBuild()
+}
+
+var (
+	// similarityBuilds holds at most one pending build, so a burst of writes,
+	// such as an admin edit saving every changed track, costs one build for
+	// the state at the end of the burst rather than one per write.
+	similarityBuilds       = make(chan struct{}, 1)
+	startSimilarityBuilder sync.Once
+)
+
+// requestSimilarityBuild has the background builder build the album vectors
+// and similarity matrix of the latest state.
+func requestSimilarityBuild() {
+	startSimilarityBuilder.Do(func() { go buildSimilarities() })
+	select {
+	case similarityBuilds <- struct{}{}:
+	default:
+		// A build is already pending and will pick up the latest state.
+	}
+}
+
+// buildSimilarities builds one state at a time, always the one published
+// when the build starts, so states replaced while it waited are skipped.
+func buildSimilarities() {
+	for range similarityBuilds {
+		currentState().corpusVocabulary().SimilarityMatrix()
+	}
+}
+
+func containsAlbum(albums []models.BandcampAlbumData, id string) bool {
+	for _, album := range albums {
+		if album.ID == id {
+			return true
+		}
+	}
+	return false
+}
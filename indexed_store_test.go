@@ -0,0 +1,173 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"millions-of-words/models"
+	"millions-of-words/words"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIndexedStoreKeepsIndexInStep(t *testing.T) {
+	replaceAlbums(nil)
+	before := currentState()
+	s := indexedStore{Store: store}
+
+	err := s.SaveAlbum(models.BandcampAlbumData{
+		ID:         "indexed",
+		Slug:       "indexed-artist-indexed-album",
+		ArtistName: "Indexed Artist",
+		AlbumName:  "Indexed Album",
+		Enabled:    true,
+		Tracks: []models.BandcampTrackData{
+			{Name: "Opening", TrackNumber: 1, Lyrics: "fire and ice"},
+		},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Empty(t, before.albums, "a published state was changed")
+	state := currentState()
+	assert.Len(t, state.albums, 1)
+	assert.Equal(t, []string{"indexed"}, albumIDs(state.table.Filter("indexed")))
+	assert.Equal(t, 1, state.index.Count("fire"))
+	assert.Equal(t, 3, state.homePage.TotalWords)
+	if ids, _ := state.corpusVocabulary().SimilarityMatrix(); assert.NotNil(t, state.corpus.Load()) {
+		assert.Equal(t, []string{"indexed"}, ids)
+	}
+
+	err = s.UpdateTrack(models.UpdateTrackRequest{AlbumID: "indexed", TrackName: "Opening", TrackNumber: 1, Lyrics: "only ice remains"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	state = currentState()
+	assert.Equal(t, 0, state.index.Count("fire"))
+	assert.Equal(t, 1, state.index.Count("remains"))
+	assert.Equal(t, "only ice remains", state.albums[0].Tracks[0].Lyrics)
+
+	err = s.UpdateAlbum(models.UpdateAlbumRequest{AlbumID: "indexed", Enabled: "false"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	state = currentState()
+	assert.Empty(t, state.albums)
+	assert.Empty(t, state.table.Sorted("date_added", "desc"))
+	assert.Equal(t, 0, state.index.Count("ice"))
+	assert.Equal(t, 0, state.homePage.TotalAlbums)
+}
+
+func TestAlbumChangesBuildSimilaritiesOfLatestState(t *testing.T) {
+	replaceAlbums(nil)
+	for _, id := range []string{"a", "b", "c", "d"} {
+		applyAlbumChange(models.BandcampAlbumData{
+			ID:      id,
+			Slug:    "burst-" + id,
+			Enabled: true,
+			Tracks:  []models.BandcampTrackData{{Name: "Track", TrackNumber: 1, Lyrics: "burst of writes " + id}},
+		})
+	}
+	latest := currentState()
+	assert.Eventually(t, func() bool { return latest.corpus.Load() != nil }, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestIndexedStoreReloadsProfanityLexicon(t *testing.T) {
+	replaceAlbums([]models.BandcampAlbumData{{
+		ID:      "profane",
+		Enabled: true,
+		Tracks:  []models.BandcampTrackData{{Lyrics: "darn it all, darned thing"}},
+	}})
+	s := indexedStore{Store: store}
+
+	if !assert.NoError(t, s.SaveProfanityTerm(models.ProfanityTerm{Word: "darn*", Category: "mild"})) {
+		return
+	}
+	profanity := currentState().homePage.Profanity
+	assert.Equal(t, 2, profanity.Total)
+	assert.Equal(t, []models.CategoryCount{{Category: "mild", Count: 2}}, profanity.Categories)
+
+	terms, err := store.LoadProfanityTerms()
+	if !assert.NoError(t, err) {
+		return
+	}
+	for _, term := range terms {
+		if !assert.NoError(t, s.DeleteProfanityTerm(term.ID)) {
+			return
+		}
+	}
+	assert.Equal(t, 0, currentState().homePage.Profanity.Total)
+}
+
+func TestIndexedStoreReloadsStopwords(t *testing.T) {
+	s := indexedStore{Store: store}
+
+	if !assert.NoError(t, s.SaveStopword("yeah")) {
+		return
+	}
+	assert.True(t, currentState().stopwords.Contains("yeah"))
+	assert.True(t, currentState().stopwords.Contains("the"))
+
+	if !assert.NoError(t, s.DeleteStopword("yeah")) {
+		return
+	}
+	assert.False(t, currentState().stopwords.Contains("yeah"))
+}
+
+// Run with -race: pages and API requests read the published state while the
+// albums are reloaded, recomputed and edited underneath them.
+func TestRequestsDuringReloads(t *testing.T) {
+	if !assert.NoError(t, loadAlbums()) {
+		return
+	}
+	e := echo.New()
+	e.Renderer = &MockRenderer{}
+	e.GET("/", indexHandler)
+	e.GET("/albums", allAlbumsHandler)
+	e.GET("/search", searchHandler)
+	e.GET("/api/v1/albums", apiAlbumsHandler)
+	e.GET("/api/v1/albums/:slug", apiAlbumHandler)
+	e.GET("/api/v1/albums/:slug/tracks", apiAlbumTracksHandler)
+	e.GET("/api/v1/similarity", apiSimilarityHandler)
+	e.GET("/api/v1/words", apiWordsHandler)
+	e.GET("/api/v1/search", apiSearchHandler)
+	paths := []string{
+		"/", "/albums", "/search?q=test", "/api/v1/albums",
+		"/api/v1/albums/artist1-album1", "/api/v1/albums/artist1-album1/tracks?view=lemmatized",
+		"/api/v1/similarity", "/api/v1/words", "/api/v1/search?q=word",
+	}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for _, path := range paths {
+		wg.Add(1)
+		go func(path string) {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				rec := httptest.NewRecorder()
+				e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+				assert.Equal(t, http.StatusOK, rec.Code, path)
+			}
+		}(path)
+	}
+
+	s := indexedStore{Store: store}
+	for i := 0; i < 20; i++ {
+		assert.NoError(t, loadAlbums())
+		recomputeAnalytics()
+		assert.NoError(t, s.UpdateTrack(models.UpdateTrackRequest{AlbumID: "1", TrackName: "Track1", TrackNumber: 1, Lyrics: "test word test"}))
+		setStopwords(words.NewStopwords([]string{"word"}))
+		setStopwords(words.NewStopwords(nil))
+	}
+	close(done)
+	wg.Wait()
+}
@@ -6,29 +6,34 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"text/template"
 	"time"
 
 	"millions-of-words/fetch"
-	loader "millions-of-words/loaders/supabase"
+	"millions-of-words/loaders"
 	"millions-of-words/models"
+	"millions-of-words/words"
+	"millions-of-words/words/profanity"
 
 	"github.com/labstack/echo/v4"
 )
 
 type Handler struct {
 	templates TemplateRenderer
+	store     loaders.Store
 }
 
 type TemplateRenderer interface {
 	Render(w io.Writer, name string, data interface{}, c echo.Context) error
 }
 
-func NewHandler(templates TemplateRenderer) *Handler {
+func NewHandler(templates TemplateRenderer, store loaders.Store) *Handler {
 	return &Handler{
 		templates: templates,
+		store:     store,
 	}
 }
 
@@ -40,7 +45,7 @@ func (h *Handler) AdminHandler(c echo.Context) error {
 		}, c)
 	}
 
-	user, err := loader.ValidateSession(cookie.Value)
+	user, err := h.store.ValidateSession(cookie.Value)
 	if err != nil || user == nil {
 		return h.templates.Render(c.Response().Writer, "admin/pages/login.html", map[string]interface{}{
 			"Title": "Admin Login - Millions of Words",
@@ -48,7 +53,7 @@ func (h *Handler) AdminHandler(c echo.Context) error {
 	}
 
 	// Only fetch artist and album names for all albums (fast)
-	allAlbums, err := loader.FetchAlbumNamesOnly()
+	allAlbums, err := h.store.FetchAlbumNamesOnly()
 	if err != nil {
 		log.Printf("Error loading albums: %v", err)
 		return err
@@ -65,7 +70,7 @@ func (h *Handler) AdminAuthHandler(c echo.Context) error {
 	email := c.FormValue("email")
 	password := c.FormValue("password")
 
-	user, err := loader.SignInWithEmail(email, password)
+	user, err := h.store.SignInWithEmail(email, password)
 	if err != nil {
 		return c.HTML(http.StatusUnauthorized, `
 			<div class="text-red-500 text-center p-2">Invalid email or password</div>
@@ -87,7 +92,7 @@ func (h *Handler) AdminAuthHandler(c echo.Context) error {
 }
 
 func (h *Handler) AdminImportHandler(c echo.Context) error {
-	if err := validateAuth(c); err != nil {
+	if err := h.validateAuth(c); err != nil {
 		return err
 	}
 
@@ -95,7 +100,7 @@ func (h *Handler) AdminImportHandler(c echo.Context) error {
 }
 
 func (h *Handler) FetchMetalArchivesHandler(c echo.Context) error {
-	if err := validateAuth(c); err != nil {
+	if err := h.validateAuth(c); err != nil {
 		return err
 	}
 
@@ -115,7 +120,7 @@ func (h *Handler) FetchMetalArchivesHandler(c echo.Context) error {
 }
 
 func (h *Handler) ValidateMetalArchivesUrlHandler(c echo.Context) error {
-	if err := validateAuth(c); err != nil {
+	if err := h.validateAuth(c); err != nil {
 		return err
 	}
 
@@ -146,7 +151,7 @@ func (h *Handler) LogoutHandler(c echo.Context) error {
 }
 
 func (h *Handler) ImportAlbumHandler(c echo.Context) error {
-	if err := validateAuth(c); err != nil {
+	if err := h.validateAuth(c); err != nil {
 		return err
 	}
 
@@ -172,7 +177,7 @@ func (h *Handler) ImportAlbumHandler(c echo.Context) error {
 			continue
 		}
 
-		exists, err := loader.AlbumUrlExists(url)
+		exists, err := h.store.AlbumUrlExists(url)
 		if err != nil {
 			results = append(results, fmt.Sprintf(`
 				<div class="bg-red-500/10 border border-red-500 text-red-500 p-2 rounded text-sm">
@@ -198,7 +203,7 @@ func (h *Handler) ImportAlbumHandler(c echo.Context) error {
 			continue
 		}
 
-		if err := loader.SaveAlbum(albumData); err != nil {
+		if err := h.store.SaveAlbum(albumData); err != nil {
 			results = append(results, fmt.Sprintf(`
 				<div class="bg-red-500/10 border border-red-500 text-red-500 p-2 rounded text-sm">
 					Error saving %s: %v
@@ -232,11 +237,11 @@ func (h *Handler) ImportAlbumHandler(c echo.Context) error {
 }
 
 func (h *Handler) AlbumListHandler(c echo.Context) error {
-	if err := validateAuth(c); err != nil {
+	if err := h.validateAuth(c); err != nil {
 		return err
 	}
 	// Only fetch artist and album names for all albums
-	albums, err := loader.FetchAlbumNamesOnly()
+	albums, err := h.store.FetchAlbumNamesOnly()
 	if err != nil {
 		return c.HTML(500, "Failed to load albums")
 	}
@@ -246,7 +251,7 @@ func (h *Handler) AlbumListHandler(c echo.Context) error {
 }
 
 func (h *Handler) ImportStartHandler(c echo.Context) error {
-	if err := validateAuth(c); err != nil {
+	if err := h.validateAuth(c); err != nil {
 		return err
 	}
 	urls := strings.Split(c.FormValue("bandcampUrls"), "\n")
@@ -302,7 +307,7 @@ func (h *Handler) ImportStartHandler(c echo.Context) error {
 }
 
 func (h *Handler) ImportProcessHandler(c echo.Context) error {
-	if err := validateAuth(c); err != nil {
+	if err := h.validateAuth(c); err != nil {
 		return err
 	}
 	albumIndex, _ := strconv.Atoi(c.FormValue("albumIndex"))
@@ -324,7 +329,7 @@ func (h *Handler) ImportProcessHandler(c echo.Context) error {
 		color = "text-red-400"
 		icon = "✗"
 	} else {
-		exists, err := loader.AlbumUrlExists(url)
+		exists, err := h.store.AlbumUrlExists(url)
 		if err != nil {
 			status = "Error"
 			color = "text-red-400"
@@ -339,7 +344,7 @@ func (h *Handler) ImportProcessHandler(c echo.Context) error {
 				status = "Fetch Error"
 				color = "text-red-400"
 				icon = "✗"
-			} else if err := loader.SaveAlbum(albumData); err != nil {
+			} else if err := h.store.SaveAlbum(albumData); err != nil {
 				status = "Save Error"
 				color = "text-red-400"
 				icon = "✗"
@@ -365,11 +370,11 @@ func (h *Handler) ImportProcessHandler(c echo.Context) error {
 }
 
 func (h *Handler) AlbumEditFormHandler(c echo.Context) error {
-	if err := validateAuth(c); err != nil {
+	if err := h.validateAuth(c); err != nil {
 		return err
 	}
 	albumID := c.Param("id")
-	album, err := loader.GetAlbumByID(albumID)
+	album, err := h.store.GetAlbumByID(albumID)
 	if err != nil {
 		return c.HTML(404, "Album not found")
 	}
@@ -379,7 +384,7 @@ func (h *Handler) AlbumEditFormHandler(c echo.Context) error {
 }
 
 func (h *Handler) AlbumEditPostHandler(c echo.Context) error {
-	if err := validateAuth(c); err != nil {
+	if err := h.validateAuth(c); err != nil {
 		return err
 	}
 	albumID := c.Param("id")
@@ -399,12 +404,12 @@ func (h *Handler) AlbumEditPostHandler(c echo.Context) error {
 		Notes:       notes,
 	}
 
-	if err := loader.UpdateAlbum(albumReq); err != nil {
+	if err := h.store.UpdateAlbum(albumReq); err != nil {
 		log.Printf("Error updating album: %v", err)
 		return c.HTML(http.StatusOK, `<div class="text-red-500">Error: Failed to update album</div>`)
 	}
 
-	album, err := loader.GetAlbumByID(albumID)
+	album, err := h.store.GetAlbumByID(albumID)
 	if err == nil {
 		for _, track := range album.Tracks {
 			lyricsField := "lyrics_" + strconv.Itoa(track.TrackNumber)
@@ -416,7 +421,7 @@ func (h *Handler) AlbumEditPostHandler(c echo.Context) error {
 					TrackNumber: track.TrackNumber,
 					Lyrics:      lyrics,
 				}
-				if err := loader.UpdateTrack(trackReq); err != nil {
+				if err := h.store.UpdateTrack(trackReq); err != nil {
 					log.Printf("Error updating track %d: %v", track.TrackNumber, err)
 				}
 			}
@@ -427,7 +432,7 @@ func (h *Handler) AlbumEditPostHandler(c echo.Context) error {
 }
 
 func (h *Handler) TrackEditPostHandler(c echo.Context) error {
-	if err := validateAuth(c); err != nil {
+	if err := h.validateAuth(c); err != nil {
 		return err
 	}
 	albumID := c.Param("album_id")
@@ -446,12 +451,12 @@ func (h *Handler) TrackEditPostHandler(c echo.Context) error {
 		TrackNumber: trackNumber,
 		Lyrics:      lyrics,
 	}
-	if err := loader.UpdateTrack(trackReq); err != nil {
+	if err := h.store.UpdateTrack(trackReq); err != nil {
 		log.Printf("Error updating track %d: %v", trackNumber, err)
 		return c.HTML(http.StatusOK, `<div class=\"text-red-500\">Error: Failed to update track</div>`)
 	}
 
-	album, err := loader.GetAlbumByID(albumID)
+	album, err := h.store.GetAlbumByID(albumID)
 	if err != nil {
 		return c.HTML(http.StatusOK, `<div class=\"text-red-500\">Error: Failed to reload album</div>`)
 	}
@@ -476,13 +481,171 @@ func (h *Handler) TrackEditPostHandler(c echo.Context) error {
 	return c.HTML(http.StatusOK, buf.String())
 }
 
-func validateAuth(c echo.Context) error {
+func (h *Handler) ProfanityListHandler(c echo.Context) error {
+	if err := h.validateAuth(c); err != nil {
+		return err
+	}
+	return h.renderProfanityList(c, "")
+}
+
+func (h *Handler) ProfanityAddHandler(c echo.Context) error {
+	if err := h.validateAuth(c); err != nil {
+		return err
+	}
+
+	term, err := profanity.NormalizeTerm(models.ProfanityTerm{
+		Word:     c.FormValue("word"),
+		Category: c.FormValue("category"),
+	})
+	if err != nil {
+		return h.renderProfanityList(c, err.Error())
+	}
+
+	if err := h.store.SaveProfanityTerm(term); err != nil {
+		log.Printf("Error saving profanity term: %v", err)
+		return h.renderProfanityList(c, "Failed to save term")
+	}
+	return h.renderProfanityList(c, "")
+}
+
+func (h *Handler) ProfanityDeleteHandler(c echo.Context) error {
+	if err := h.validateAuth(c); err != nil {
+		return err
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.HTML(http.StatusBadRequest, "Invalid term ID")
+	}
+
+	if err := h.store.DeleteProfanityTerm(id); err != nil {
+		log.Printf("Error deleting profanity term %d: %v", id, err)
+		return h.renderProfanityList(c, "Failed to remove term")
+	}
+	return h.renderProfanityList(c, "")
+}
+
+func (h *Handler) renderProfanityList(c echo.Context, errorMessage string) error {
+	terms, err := h.store.LoadProfanityTerms()
+	if err != nil {
+		log.Printf("Error loading profanity terms: %v", err)
+		return c.HTML(http.StatusInternalServerError, "Failed to load profanity terms")
+	}
+	return h.templates.Render(c.Response().Writer, "admin/components/profanity-list", map[string]interface{}{
+		"Terms":      terms,
+		"Categories": profanity.Categories(terms),
+		"Error":      errorMessage,
+	}, c)
+}
+
+func (h *Handler) StopwordListHandler(c echo.Context) error {
+	if err := h.validateAuth(c); err != nil {
+		return err
+	}
+	return h.renderStopwordList(c, "")
+}
+
+func (h *Handler) StopwordAddHandler(c echo.Context) error {
+	if err := h.validateAuth(c); err != nil {
+		return err
+	}
+
+	word := words.CleanWord(strings.TrimSpace(c.FormValue("word")))
+	if word == "" || strings.ContainsAny(word, " \t") {
+		return h.renderStopwordList(c, "Enter a single word")
+	}
+	if words.IsStopword(word) {
+		return h.renderStopwordList(c, fmt.Sprintf("%q is already a built-in stopword", word))
+	}
+
+	if err := h.store.SaveStopword(word); err != nil {
+		log.Printf("Error saving stopword: %v", err)
+		return h.renderStopwordList(c, "Failed to save stopword")
+	}
+	return h.renderStopwordList(c, "")
+}
+
+func (h *Handler) StopwordDeleteHandler(c echo.Context) error {
+	if err := h.validateAuth(c); err != nil {
+		return err
+	}
+
+	word, err := url.PathUnescape(c.Param("word"))
+	if err != nil {
+		return c.HTML(http.StatusBadRequest, "Invalid stopword")
+	}
+
+	if err := h.store.DeleteStopword(word); err != nil {
+		log.Printf("Error deleting stopword %q: %v", word, err)
+		return h.renderStopwordList(c, "Failed to remove stopword")
+	}
+	return h.renderStopwordList(c, "")
+}
+
+func (h *Handler) renderStopwordList(c echo.Context, errorMessage string) error {
+	extra, err := h.store.LoadStopwords()
+	if err != nil {
+		log.Printf("Error loading stopwords: %v", err)
+		return c.HTML(http.StatusInternalServerError, "Failed to load stopwords")
+	}
+	return h.templates.Render(c.Response().Writer, "admin/components/stopword-list", map[string]interface{}{
+		"Stopwords": extra,
+		"BuiltIn":   words.DefaultStopwords(),
+		"Error":     errorMessage,
+	}, c)
+}
+
+func (h *Handler) ArtistListHandler(c echo.Context) error {
+	if err := h.validateAuth(c); err != nil {
+		return err
+	}
+	return h.renderArtistList(c, "")
+}
+
+func (h *Handler) ArtistEditHandler(c echo.Context) error {
+	if err := h.validateAuth(c); err != nil {
+		return err
+	}
+
+	artist := models.Artist{
+		Slug:             c.Param("slug"),
+		Name:             strings.TrimSpace(c.FormValue("name")),
+		Country:          strings.TrimSpace(c.FormValue("country")),
+		Genre:            strings.TrimSpace(c.FormValue("genre")),
+		BandcampUrl:      strings.TrimSpace(c.FormValue("bandcampUrl")),
+		MetalArchivesURL: strings.TrimSpace(c.FormValue("metalArchivesUrl")),
+		Website:          strings.TrimSpace(c.FormValue("website")),
+	}
+	if artist.Name == "" {
+		return h.renderArtistList(c, "An artist needs a name")
+	}
+
+	if err := h.store.SaveArtist(artist); err != nil {
+		log.Printf("Error saving artist %q: %v", artist.Slug, err)
+		return h.renderArtistList(c, "Failed to save artist")
+	}
+	return h.renderArtistList(c, "")
+}
+
+func (h *Handler) renderArtistList(c echo.Context, errorMessage string) error {
+	artists, err := h.store.LoadArtists()
+	if err != nil {
+		log.Printf("Error loading artists: %v", err)
+		return c.HTML(http.StatusInternalServerError, "Failed to load artists")
+	}
+	return h.templates.Render(c.Response().Writer, "admin/components/artist-list", map[string]interface{}{
+		"Artists": artists,
+		"Error":   errorMessage,
+	}, c)
+}
+
+func (h *Handler) validateAuth(c echo.Context) error {
 	cookie, err := c.Cookie("session")
 	if err != nil {
 		return echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
 	}
 
-	user, err := loader.ValidateSession(cookie.Value)
+	user, err := h.store.ValidateSession(cookie.Value)
 	if err != nil || user == nil {
 		return echo.NewHTTPError(http.StatusUnauthorized, "Invalid session")
 	}
@@ -19,4 +19,12 @@ func SetupRoutes(e *echo.Echo, h *Handler) {
 	admin.GET("/content/album-edit/:id", h.AlbumEditFormHandler)
 	admin.POST("/content/album-edit/:id", h.AlbumEditPostHandler)
 	admin.POST("/content/track-edit/:album_id/:track_number", h.TrackEditPostHandler)
+	admin.GET("/content/profanities", h.ProfanityListHandler)
+	admin.POST("/content/profanities", h.ProfanityAddHandler)
+	admin.DELETE("/content/profanities/:id", h.ProfanityDeleteHandler)
+	admin.GET("/content/stopwords", h.StopwordListHandler)
+	admin.POST("/content/stopwords", h.StopwordAddHandler)
+	admin.DELETE("/content/stopwords/:word", h.StopwordDeleteHandler)
+	admin.GET("/content/artists", h.ArtistListHandler)
+	admin.POST("/content/artists/:slug", h.ArtistEditHandler)
 }
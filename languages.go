@@ -0,0 +1,51 @@
+package main
+
+import (
+	"strings"
+
+	"millions-of-words/models"
+	"millions-of-words/words"
+
+	"github.com/labstack/echo/v4"
+)
+
+func (s *siteState) corpusLanguages() []models.LanguageShare {
+	if cached, ok := s.languages.Load(""); ok {
+		return cached.([]models.LanguageShare)
+	}
+	languages := words.LanguageBreakdown(s.albums)
+	s.languages.Store("", languages)
+	return languages
+}
+
+// languageWordFrequencies counts the words of every track in the language,
+// or of the whole corpus when language is "". Languages the corpus does not
+// have count nothing, and are not cached.
+func (s *siteState) languageWordFrequencies(language string) []models.WordCount {
+	if language == "" {
+		return s.index.WordFrequencies()
+	}
+	if cached, ok := s.languages.Load(language); ok {
+		return cached.([]models.WordCount)
+	}
+	if !s.hasLanguage(language) {
+		return []models.WordCount{}
+	}
+	frequencies := words.CorpusWordFrequencies(words.FilterLanguage(s.albums, language))
+	s.languages.Store(language, frequencies)
+	return frequencies
+}
+
+func (s *siteState) hasLanguage(language string) bool {
+	for _, share := range s.corpusLanguages() {
+		if share.Language == language {
+			return true
+		}
+	}
+	return false
+}
+
+// parseLanguage reads the "lang" query parameter, an ISO 639-1 code.
+func parseLanguage(c echo.Context) string {
+	return strings.ToLower(strings.TrimSpace(c.QueryParam("lang")))
+}
@@ -0,0 +1,146 @@
+package loader
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"millions-of-words/analytics"
+	"millions-of-words/models"
+)
+
+// loadAnalytics reads the stored analytics of every album, by album ID.
+func (s *Store) loadAnalytics() (map[string]analytics.Album, error) {
+	rows, err := s.db.Query(`SELECT album_id, metrics FROM album_analytics`)
+	if err != nil {
+		return nil, fmt.Errorf("error querying album analytics: %w", err)
+	}
+	defer rows.Close()
+
+	stored := make(map[string]analytics.Album)
+	for rows.Next() {
+		var albumID, metrics string
+		if err := rows.Scan(&albumID, &metrics); err != nil {
+			return nil, fmt.Errorf("error scanning album analytics row: %w", err)
+		}
+		var album analytics.Album
+		if err := json.Unmarshal([]byte(metrics), &album); err != nil {
+			log.Printf("Ignoring unreadable analytics of album %s: %v", albumID, err)
+			continue
+		}
+		stored[albumID] = album
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating album analytics rows: %w", err)
+	}
+	return stored, nil
+}
+
+func (s *Store) getAnalytics(albumID string) (analytics.Album, bool, error) {
+	var metrics string
+	err := s.db.QueryRow(`SELECT metrics FROM album_analytics WHERE album_id = ?`, albumID).Scan(&metrics)
+	if err == sql.ErrNoRows {
+		return analytics.Album{}, false, nil
+	}
+	if err != nil {
+		return analytics.Album{}, false, fmt.Errorf("error fetching album analytics: %w", err)
+	}
+
+	var stored analytics.Album
+	if err := json.Unmarshal([]byte(metrics), &stored); err != nil {
+		log.Printf("Ignoring unreadable analytics of album %s: %v", albumID, err)
+		return analytics.Album{}, false, nil
+	}
+	return stored, true, nil
+}
+
+func (s *Store) saveAnalytics(albumID string, computed analytics.Album) error {
+	metrics, err := json.Marshal(computed)
+	if err != nil {
+		return fmt.Errorf("error encoding album analytics: %w", err)
+	}
+
+	_, err = s.db.Exec(`INSERT INTO album_analytics (album_id, version, metrics, computed_at)
+		VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(album_id) DO UPDATE SET
+			version = excluded.version, metrics = excluded.metrics, computed_at = excluded.computed_at`,
+		albumID, computed.Version, string(metrics))
+	if err != nil {
+		return fmt.Errorf("error saving album analytics: %w", err)
+	}
+	return nil
+}
+
+// applyAnalytics puts the stored numbers on the album. Albums without any, or
+// whose lyrics changed since they were computed, are computed and stored
+// first. Numbers from an older analytics.Version are still shown until
+// RecomputeAnalytics catches up.
+func (s *Store) applyAnalytics(album *models.BandcampAlbumData, stored analytics.Album, ok bool) {
+	if !ok || !stored.Matches(*album) {
+		stored = analytics.Compute(*album)
+		if err := s.saveAnalytics(album.ID, stored); err != nil {
+			log.Printf("Error storing analytics of album %s: %v", album.ID, err)
+		}
+	}
+	stored.Apply(album)
+}
+
+// refreshAnalytics recomputes the analytics of an album after a write, unless
+// the write left the lyrics alone and the stored numbers are still fresh. The
+// write already succeeded, so failures are only logged; the album is
+// computed again on its next load.
+func (s *Store) refreshAnalytics(albumID string) {
+	album, err := s.albumWithTracks("id", albumID)
+	if err != nil {
+		log.Printf("Error reloading album %s for analytics: %v", albumID, err)
+		return
+	}
+	if stored, ok, err := s.getAnalytics(albumID); err == nil && ok && stored.Fresh(album) {
+		return
+	}
+	if err := s.saveAnalytics(albumID, analytics.Compute(album)); err != nil {
+		log.Printf("Error storing analytics of album %s: %v", albumID, err)
+	}
+}
+
+// RecomputeAnalytics recomputes the analytics of every album that has none,
+// or has them from an older analytics.Version, and returns how many it
+// stored.
+func (s *Store) RecomputeAnalytics() (int, error) {
+	rows, err := s.db.Query(`SELECT albums.id FROM albums
+		LEFT JOIN album_analytics ON album_analytics.album_id = albums.id
+		WHERE album_analytics.version IS NULL OR album_analytics.version <> ?`, analytics.Version)
+	if err != nil {
+		return 0, fmt.Errorf("error querying stale album analytics: %w", err)
+	}
+	var stale []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("error scanning album id: %w", err)
+		}
+		stale = append(stale, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("error iterating stale album analytics: %w", err)
+	}
+
+	updated := 0
+	for _, id := range stale {
+		album, err := s.albumWithTracks("id", id)
+		if err != nil {
+			log.Printf("Error loading album %s for analytics: %v", id, err)
+			continue
+		}
+		if err := s.saveAnalytics(id, analytics.Compute(album)); err != nil {
+			log.Printf("Error storing analytics of album %s: %v", id, err)
+			continue
+		}
+		updated++
+	}
+	return updated, nil
+}
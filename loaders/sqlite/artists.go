@@ -0,0 +1,61 @@
+package loader
+
+import (
+	"fmt"
+
+	"millions-of-words/models"
+)
+
+const artistColumns = `slug, name, country, genre, bandcamp_url, metal_archives_url, website`
+
+func scanArtist(row rowScanner) (models.Artist, error) {
+	var artist models.Artist
+	err := row.Scan(&artist.Slug, &artist.Name, &artist.Country, &artist.Genre,
+		&artist.BandcampUrl, &artist.MetalArchivesURL, &artist.Website)
+	return artist, err
+}
+
+func (s *Store) LoadArtists() ([]models.Artist, error) {
+	rows, err := s.db.Query(`SELECT ` + artistColumns + ` FROM artists ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("error querying artists: %w", err)
+	}
+	defer rows.Close()
+
+	var artists []models.Artist
+	for rows.Next() {
+		artist, err := scanArtist(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning artist: %w", err)
+		}
+		artists = append(artists, artist)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating artists: %w", err)
+	}
+
+	return artists, nil
+}
+
+func (s *Store) GetArtistBySlug(slug string) (models.Artist, error) {
+	artist, err := scanArtist(s.db.QueryRow(`SELECT `+artistColumns+` FROM artists WHERE slug = ?`, slug))
+	if err != nil {
+		return models.Artist{}, fmt.Errorf("error fetching artist: %w", err)
+	}
+	return artist, nil
+}
+
+func (s *Store) SaveArtist(artist models.Artist) error {
+	_, err := s.db.Exec(`INSERT INTO artists (`+artistColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(slug) DO UPDATE SET
+			name = excluded.name, country = excluded.country, genre = excluded.genre,
+			bandcamp_url = excluded.bandcamp_url, metal_archives_url = excluded.metal_archives_url,
+			website = excluded.website`,
+		artist.Slug, artist.Name, artist.Country, artist.Genre,
+		artist.BandcampUrl, artist.MetalArchivesURL, artist.Website)
+	if err != nil {
+		return fmt.Errorf("error saving artist: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package loader
+
+import (
+	"testing"
+
+	"millions-of-words/models"
+)
+
+func TestArtists(t *testing.T) {
+	store := newTestStore(t)
+
+	artist := models.Artist{Slug: "cirith-ungol", Name: "Cirith Ungol", Country: "United States", Genre: "Heavy Metal"}
+	if err := store.SaveArtist(artist); err != nil {
+		t.Fatalf("SaveArtist() error: %v", err)
+	}
+
+	artist.Website = "https://example.com"
+	if err := store.SaveArtist(artist); err != nil {
+		t.Fatalf("SaveArtist() update error: %v", err)
+	}
+	if err := store.SaveArtist(models.Artist{Slug: "atlantean-kodex", Name: "Atlantean Kodex"}); err != nil {
+		t.Fatalf("SaveArtist() error: %v", err)
+	}
+
+	artists, err := store.LoadArtists()
+	if err != nil {
+		t.Fatalf("LoadArtists() error: %v", err)
+	}
+	if len(artists) != 2 || artists[0].Slug != "atlantean-kodex" || artists[1] != artist {
+		t.Fatalf("LoadArtists() = %+v", artists)
+	}
+
+	got, err := store.GetArtistBySlug("cirith-ungol")
+	if err != nil {
+		t.Fatalf("GetArtistBySlug() error: %v", err)
+	}
+	if got != artist {
+		t.Errorf("GetArtistBySlug() = %+v, want %+v", got, artist)
+	}
+
+	if _, err := store.GetArtistBySlug("missing"); err == nil {
+		t.Error("GetArtistBySlug() for a missing slug returned no error")
+	}
+}
+
+func TestAlbumArtistSlugFallsBackToName(t *testing.T) {
+	store := newTestStore(t)
+
+	album := models.BandcampAlbumData{ID: "a", Slug: "a", ArtistName: "Blue Öyster Cult", Enabled: true}
+	if err := store.SaveAlbum(album); err != nil {
+		t.Fatalf("SaveAlbum() error: %v", err)
+	}
+
+	got, err := store.GetAlbumBySlug("a")
+	if err != nil {
+		t.Fatalf("GetAlbumBySlug() error: %v", err)
+	}
+	if got.ArtistSlug != "blue-öyster-cult" {
+		t.Errorf("ArtistSlug = %q, want %q", got.ArtistSlug, "blue-öyster-cult")
+	}
+}
@@ -0,0 +1,49 @@
+package loader
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+
+	"millions-of-words/models"
+)
+
+// Local admin auth checks credentials against the configured admin email and
+// password. Sessions are kept in memory, so a restart logs everyone out.
+
+func (s *Store) SignInWithEmail(email, password string) (*models.User, error) {
+	if s.adminEmail == "" || s.adminPassword == "" {
+		return nil, fmt.Errorf("admin credentials not configured")
+	}
+
+	emailMatch := subtle.ConstantTimeCompare([]byte(email), []byte(s.adminEmail)) == 1
+	passwordMatch := subtle.ConstantTimeCompare([]byte(password), []byte(s.adminPassword)) == 1
+	if !emailMatch || !passwordMatch {
+		return nil, fmt.Errorf("authentication failed")
+	}
+
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return nil, fmt.Errorf("failed to generate session token: %w", err)
+	}
+
+	user := &models.User{
+		AccessToken: hex.EncodeToString(token),
+		Email:       email,
+	}
+	s.sessions.Store(user.AccessToken, user.Email)
+	return user, nil
+}
+
+func (s *Store) ValidateSession(sessionToken string) (*models.User, error) {
+	email, ok := s.sessions.Load(sessionToken)
+	if !ok {
+		return nil, fmt.Errorf("invalid session")
+	}
+
+	return &models.User{
+		AccessToken: sessionToken,
+		Email:       email.(string),
+	}, nil
+}
@@ -5,50 +5,108 @@ import (
 	"encoding/base64"
 	"fmt"
 	"log"
+	"math"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
+	"millions-of-words/fetch"
 	"millions-of-words/models"
 	"millions-of-words/words"
 
 	_ "modernc.org/sqlite"
 )
 
-const dbPath = "data/db/albums.db"
+const DefaultPath = "data/db/albums.db"
 
-func LoadAlbumsData(limit ...int) ([]models.BandcampAlbumData, error) {
-	db, err := sql.Open("sqlite", dbPath)
+// Store reads and writes albums in a local SQLite file.
+type Store struct {
+	db            *sql.DB
+	adminEmail    string
+	adminPassword string
+	sessions      sync.Map
+}
+
+func NewStore(path, adminEmail, adminPassword string) (*Store, error) {
+	if path == "" {
+		path = DefaultPath
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory for SQLite database: %w", err)
+	}
+
+	db, err := sql.Open("sqlite", path)
 	if err != nil {
-		return nil, fmt.Errorf("error opening database at %s: %w", dbPath, err)
+		return nil, fmt.Errorf("error opening database at %s: %w", path, err)
+	}
+
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, err
 	}
-	defer db.Close()
 
-	albums, err := fetchAlbums(db, limit...)
+	return &Store{
+		db:            db,
+		adminEmail:    adminEmail,
+		adminPassword: adminPassword,
+	}, nil
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+func (s *Store) LoadAlbumsData(limit ...int) ([]models.BandcampAlbumData, error) {
+	albums, err := s.LoadAllAlbumsData(limit...)
+	if err != nil {
+		return nil, err
+	}
+
+	var enabledAlbums []models.BandcampAlbumData
+	for _, album := range albums {
+		if album.Enabled {
+			enabledAlbums = append(enabledAlbums, album)
+		}
+	}
+	return enabledAlbums, nil
+}
+
+func (s *Store) LoadAllAlbumsData(limit ...int) ([]models.BandcampAlbumData, error) {
+	albums, err := s.fetchAlbums(limit...)
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := s.loadAnalytics()
 	if err != nil {
 		return nil, err
 	}
 
 	for i := range albums {
-		if err := fetchTracks(db, &albums[i]); err != nil {
+		if err := s.fetchTracks(&albums[i]); err != nil {
 			log.Printf("Error fetching tracks for album %s: %v", albums[i].ID, err)
 			continue
 		}
-		calculateAlbumMetrics(&albums[i])
+		metrics, ok := stored[albums[i].ID]
+		s.applyAnalytics(&albums[i], metrics, ok)
+		fillDerivedFields(&albums[i])
 	}
 	return albums, nil
 }
 
-func fetchAlbums(db *sql.DB, limit ...int) ([]models.BandcampAlbumData, error) {
-	query := `SELECT id, slug, artist_name, album_name, image_url, image_data, 
-        album_color_average, bandcamp_url, ampwall_url, metal_archives_url, 
-        total_length, formatted_length, date_added 
-        FROM albums 
+func (s *Store) fetchAlbums(limit ...int) ([]models.BandcampAlbumData, error) {
+	query := `SELECT ` + albumColumns + `
+        FROM albums
         ORDER BY date_added DESC`
 
 	if len(limit) > 0 && limit[0] > 0 {
 		query += fmt.Sprintf(" LIMIT %d", limit[0])
 	}
 
-	rows, err := db.Query(query)
+	rows, err := s.db.Query(query)
 	if err != nil {
 		return nil, fmt.Errorf("error querying albums: %w", err)
 	}
@@ -56,41 +114,78 @@ func fetchAlbums(db *sql.DB, limit ...int) ([]models.BandcampAlbumData, error) {
 
 	var albums []models.BandcampAlbumData
 	for rows.Next() {
-		var album models.BandcampAlbumData
-		var ampwallURL sql.NullString
-		var metalArchivesURL sql.NullString
-
-		err := rows.Scan(
-			&album.ID,
-			&album.Slug,
-			&album.ArtistName,
-			&album.AlbumName,
-			&album.ImageUrl,
-			&album.ImageData,
-			&album.AlbumColorAverage,
-			&album.BandcampUrl,
-			&ampwallURL,
-			&metalArchivesURL,
-			&album.TotalLength,
-			&album.FormattedLength,
-			&album.DateAdded,
-		)
+		album, err := scanAlbum(rows)
 		if err != nil {
 			return nil, fmt.Errorf("error scanning album row: %w", err)
 		}
-
-		album.AmpwallUrl = ampwallURL.String
-		album.MetalArchivesURL = metalArchivesURL.String
-		album.ImageDataBase64 = base64.StdEncoding.EncodeToString(album.ImageData)
 		albums = append(albums, album)
 	}
 
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating album rows: %w", err)
+	}
+
 	return albums, nil
 }
 
-func fetchTracks(db *sql.DB, album *models.BandcampAlbumData) error {
-	query := `SELECT name, total_length, formatted_length, lyrics FROM tracks WHERE album_id = ?`
-	rows, err := db.Query(query, album.ID)
+const albumColumns = `id, slug, artist_name, album_name, image_url, image_storage_path, image_data,
+	album_color_average, bandcamp_url, ampwall_url, metal_archives_url,
+	total_length, formatted_length, date_added, release_date, genre, country,
+	label, ignored_words, notes, enabled, artist_slug`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanAlbum(row rowScanner) (models.BandcampAlbumData, error) {
+	var album models.BandcampAlbumData
+	var imageURL, albumColor, bandcampURL, ampwallURL, metalArchivesURL, formattedLength, dateAdded sql.NullString
+	var totalLength sql.NullInt64
+
+	err := row.Scan(
+		&album.ID,
+		&album.Slug,
+		&album.ArtistName,
+		&album.AlbumName,
+		&imageURL,
+		&album.ImageStoragePath,
+		&album.ImageData,
+		&albumColor,
+		&bandcampURL,
+		&ampwallURL,
+		&metalArchivesURL,
+		&totalLength,
+		&formattedLength,
+		&dateAdded,
+		&album.ReleaseDate,
+		&album.Genre,
+		&album.Country,
+		&album.Label,
+		&album.IgnoredWords,
+		&album.Notes,
+		&album.Enabled,
+		&album.ArtistSlug,
+	)
+	if err != nil {
+		return models.BandcampAlbumData{}, err
+	}
+
+	album.ImageUrl = imageURL.String
+	album.AlbumColorAverage = albumColor.String
+	album.BandcampUrl = bandcampURL.String
+	album.AmpwallUrl = ampwallURL.String
+	album.MetalArchivesURL = metalArchivesURL.String
+	album.TotalLength = int(totalLength.Int64)
+	album.FormattedLength = formattedLength.String
+	album.DateAdded = dateAdded.String
+	album.ImageDataBase64 = base64.StdEncoding.EncodeToString(album.ImageData)
+	return album, nil
+}
+
+func (s *Store) fetchTracks(album *models.BandcampAlbumData) error {
+	query := `SELECT name, track_number, total_length, formatted_length, lyrics, ignored_words, language
+		FROM tracks WHERE album_id = ? ORDER BY track_number, id`
+	rows, err := s.db.Query(query, album.ID)
 	if err != nil {
 		return fmt.Errorf("error querying tracks: %w", err)
 	}
@@ -98,10 +193,12 @@ func fetchTracks(db *sql.DB, album *models.BandcampAlbumData) error {
 
 	for rows.Next() {
 		var track models.BandcampTrackData
-		err := rows.Scan(&track.Name, &track.TotalLength, &track.FormattedLength, &track.Lyrics)
+		var lyrics sql.NullString
+		err := rows.Scan(&track.Name, &track.TrackNumber, &track.TotalLength, &track.FormattedLength, &lyrics, &track.IgnoredWords, &track.Language)
 		if err != nil {
 			return fmt.Errorf("error scanning track row: %w", err)
 		}
+		track.Lyrics = lyrics.String
 		album.Tracks = append(album.Tracks, track)
 	}
 
@@ -112,75 +209,47 @@ func fetchTracks(db *sql.DB, album *models.BandcampAlbumData) error {
 	return nil
 }
 
-func calculateAlbumMetrics(album *models.BandcampAlbumData) {
-	totalWords := 0
-	totalVowels := 0
-	totalConsonants := 0
-	totalChars := 0
-	totalLines := 0
-	uniqueWords := make(map[string]struct{})
-	wordLengths := make(map[int]int)
-
-	for i, track := range album.Tracks {
-		wordCounts, vowels, consonants, lengths := words.CalculateAndSortWordFrequencies(track.Lyrics, track.IgnoredWords)
-		words := len(strings.Fields(track.Lyrics))
-
-		totalWords += words
-		totalVowels += vowels
-		totalConsonants += consonants
-		totalChars += len(track.Lyrics)
-		totalLines += len(strings.Split(track.Lyrics, "\n"))
-
-		for l, c := range lengths {
-			wordLengths[l] += c
-		}
-		for _, wc := range wordCounts {
-			uniqueWords[wc.Word] = struct{}{}
-		}
-
-		track.TotalWords = words
-		track.TotalCharacters = len(track.Lyrics)
-		track.TotalCharactersNoSpaces = len(strings.ReplaceAll(strings.ReplaceAll(track.Lyrics, " ", ""), "\n", ""))
-		track.TotalLines = len(strings.Split(track.Lyrics, "\n"))
-		album.Tracks[i] = track
-	}
-
-	album.TotalWords = totalWords
-	album.TotalCharacters = totalChars
-	album.TotalLines = totalLines
-	album.TotalVowelCount = totalVowels
-	album.TotalConsonantCount = totalConsonants
-	album.TotalUniqueWords = len(uniqueWords)
-	album.WordLengthDistribution = wordLengths
-	album.AverageWordsPerTrack = calculateAverage(totalWords, len(album.Tracks))
+// fillDerivedFields sets the album fields that do not come from its lyrics
+// and so are not stored with its analytics.
+func fillDerivedFields(album *models.BandcampAlbumData) {
+	if album.ArtistSlug == "" {
+		album.ArtistSlug = fetch.GenerateSlug(album.ArtistName)
+	}
+	album.ReleaseDateDaysAgo = calculateReleaseDateDaysAgo(album.ReleaseDate)
 }
 
-func calculateAverage(total, count int) int {
-	if count > 0 {
-		return total / count
+func calculateReleaseDateDaysAgo(releaseDate string) string {
+	if releaseDate == "" {
+		return ""
 	}
-	return 0
+
+	releaseDateTime, err := time.Parse("2006-01-02", releaseDate)
+	if err != nil {
+		return ""
+	}
+
+	days := math.Floor(time.Since(releaseDateTime).Hours() / 24)
+	if days < 0 {
+		return "future release"
+	}
+
+	return fmt.Sprintf("%d days ago", int(days))
 }
 
-func UpdateTrackLyrics(req models.UpdateTrackRequest) error {
-	cleanLyrics := strings.TrimSpace(req.Lyrics)
+func (s *Store) UpdateTrack(req models.UpdateTrackRequest) error {
+	cleanLyrics := strings.TrimSpace(words.NormalizeText(req.Lyrics))
 	if strings.HasPrefix(strings.ToLower(cleanLyrics), "lyrics") {
 		cleanLyrics = ""
 	}
 
-	log.Printf("Updating lyrics for album: %s, track: %s", req.AlbumID, req.TrackName)
-
-	db, err := sql.Open("sqlite", dbPath)
-	if err != nil {
-		return fmt.Errorf("error opening database: %w", err)
-	}
-	defer db.Close()
+	log.Printf("Updating track for album: %s, track: %s", req.AlbumID, req.TrackName)
 
-	result, err := db.Exec("UPDATE tracks SET lyrics = ? WHERE album_id = ? AND name = ?",
-		cleanLyrics, req.AlbumID, req.TrackName)
+	result, err := s.db.Exec(`UPDATE tracks SET lyrics = ?, track_number = ?, ignored_words = ?, language = ?
+		WHERE album_id = ? AND name = ?`,
+		cleanLyrics, req.TrackNumber, req.IgnoredWords, words.DetectLanguage(cleanLyrics), req.AlbumID, req.TrackName)
 	if err != nil {
 		log.Printf("Error executing update: %v", err)
-		return fmt.Errorf("error updating lyrics: %w", err)
+		return fmt.Errorf("error updating track: %w", err)
 	}
 
 	rows, err := result.RowsAffected()
@@ -193,122 +262,92 @@ func UpdateTrackLyrics(req models.UpdateTrackRequest) error {
 		return fmt.Errorf("no matching track found")
 	}
 
-	log.Printf("Successfully updated lyrics for album: %s, track: %s", req.AlbumID, req.TrackName)
+	log.Printf("Successfully updated track for album: %s, track: %s", req.AlbumID, req.TrackName)
+	s.refreshAnalytics(req.AlbumID)
 	return nil
 }
 
-func GetAlbumBySlug(slug string) (models.BandcampAlbumData, error) {
-	db, err := sql.Open("sqlite", dbPath)
+// UpdateTrackLyrics is kept for callers of the old SQLite loader. It now
+// updates the same fields as UpdateTrack.
+func (s *Store) UpdateTrackLyrics(req models.UpdateTrackRequest) error {
+	return s.UpdateTrack(req)
+}
+
+func (s *Store) UpdateAlbum(req models.UpdateAlbumRequest) error {
+	log.Printf("Updating album: %s", req.AlbumID)
+
+	enabled := req.Enabled == "true"
+
+	result, err := s.db.Exec(`UPDATE albums SET
+			metal_archives_url = ?, release_date = ?, genre = ?, country = ?,
+			label = ?, ignored_words = ?, notes = ?, enabled = ?
+		WHERE id = ?`,
+		req.MetalArchivesURL, req.ReleaseDate, req.Genre, req.Country,
+		req.Label, req.IgnoredWords, req.Notes, enabled, req.AlbumID)
 	if err != nil {
-		return models.BandcampAlbumData{}, fmt.Errorf("error opening database: %w", err)
+		return fmt.Errorf("error updating album: %w", err)
 	}
-	defer db.Close()
-
-	var album models.BandcampAlbumData
-	var ampwallURL, metalArchivesURL sql.NullString
 
-	err = db.QueryRow(`
-			SELECT id, slug, artist_name, album_name, image_url, image_data, 
-						 album_color_average, bandcamp_url, ampwall_url, metal_archives_url,
-						 total_length, formatted_length, date_added 
-			FROM albums WHERE slug = ?`, slug).Scan(
-		&album.ID,
-		&album.Slug,
-		&album.ArtistName,
-		&album.AlbumName,
-		&album.ImageUrl,
-		&album.ImageData,
-		&album.AlbumColorAverage,
-		&album.BandcampUrl,
-		&ampwallURL,
-		&metalArchivesURL,
-		&album.TotalLength,
-		&album.FormattedLength,
-		&album.DateAdded)
+	rows, err := result.RowsAffected()
 	if err != nil {
-		return models.BandcampAlbumData{}, fmt.Errorf("error fetching album: %w", err)
+		return fmt.Errorf("error checking update result: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("no matching album found")
 	}
 
-	album.AmpwallUrl = ampwallURL.String
-	album.MetalArchivesURL = metalArchivesURL.String
-	album.ImageDataBase64 = base64.StdEncoding.EncodeToString(album.ImageData)
+	return nil
+}
 
-	if err := fetchTracks(db, &album); err != nil {
-		return models.BandcampAlbumData{}, fmt.Errorf("error fetching tracks: %w", err)
-	}
+func (s *Store) GetAlbumBySlug(slug string) (models.BandcampAlbumData, error) {
+	return s.getAlbum("slug", slug)
+}
 
-	calculateAlbumMetrics(&album)
-	return album, nil
+func (s *Store) GetAlbumByID(id string) (models.BandcampAlbumData, error) {
+	return s.getAlbum("id", id)
 }
 
-func GetAlbumByID(id string) (models.BandcampAlbumData, error) {
-	db, err := sql.Open("sqlite", dbPath)
+func (s *Store) getAlbum(column, value string) (models.BandcampAlbumData, error) {
+	album, err := s.albumWithTracks(column, value)
 	if err != nil {
-		return models.BandcampAlbumData{}, fmt.Errorf("error opening database: %w", err)
+		return models.BandcampAlbumData{}, err
 	}
-	defer db.Close()
 
-	var album models.BandcampAlbumData
-	var ampwallURL, metalArchivesURL sql.NullString
+	stored, ok, err := s.getAnalytics(album.ID)
+	if err != nil {
+		return models.BandcampAlbumData{}, err
+	}
+	s.applyAnalytics(&album, stored, ok)
+	fillDerivedFields(&album)
+	return album, nil
+}
 
-	err = db.QueryRow(`
-		SELECT id, slug, artist_name, album_name, image_url, image_data, 
-				 album_color_average, bandcamp_url, ampwall_url, metal_archives_url,
-				 total_length, formatted_length, date_added 
-		FROM albums WHERE id = ?`, id).Scan(
-		&album.ID,
-		&album.Slug,
-		&album.ArtistName,
-		&album.AlbumName,
-		&album.ImageUrl,
-		&album.ImageData,
-		&album.AlbumColorAverage,
-		&album.BandcampUrl,
-		&ampwallURL,
-		&metalArchivesURL,
-		&album.TotalLength,
-		&album.FormattedLength,
-		&album.DateAdded)
+// albumWithTracks reads an album and its tracks as stored, without analytics.
+func (s *Store) albumWithTracks(column, value string) (models.BandcampAlbumData, error) {
+	row := s.db.QueryRow(`SELECT `+albumColumns+` FROM albums WHERE `+column+` = ?`, value)
+	album, err := scanAlbum(row)
 	if err != nil {
 		return models.BandcampAlbumData{}, fmt.Errorf("error fetching album: %w", err)
 	}
 
-	album.AmpwallUrl = ampwallURL.String
-	album.MetalArchivesURL = metalArchivesURL.String
-	album.ImageDataBase64 = base64.StdEncoding.EncodeToString(album.ImageData)
-
-	if err := fetchTracks(db, &album); err != nil {
+	if err := s.fetchTracks(&album); err != nil {
 		return models.BandcampAlbumData{}, fmt.Errorf("error fetching tracks: %w", err)
 	}
-
-	calculateAlbumMetrics(&album)
 	return album, nil
 }
 
-func AlbumUrlExists(url string) (bool, error) {
-	db, err := sql.Open("sqlite", dbPath)
-	if err != nil {
-		return false, fmt.Errorf("error opening database: %w", err)
-	}
-	defer db.Close()
-
+func (s *Store) AlbumUrlExists(url string) (bool, error) {
 	var exists bool
 	query := "SELECT EXISTS(SELECT 1 FROM albums WHERE bandcamp_url=? LIMIT 1)"
-	err = db.QueryRow(query, url).Scan(&exists)
+	err := s.db.QueryRow(query, url).Scan(&exists)
 	if err != nil {
 		return false, fmt.Errorf("error checking if URL exists: %w", err)
 	}
 	return exists, nil
 }
 
-func SaveAlbum(album models.BandcampAlbumData) error {
-	db, err := sql.Open("sqlite", dbPath)
-	if err != nil {
-		return fmt.Errorf("error opening database: %w", err)
-	}
-	defer db.Close()
-
-	tx, err := db.Begin()
+func (s *Store) SaveAlbum(album models.BandcampAlbumData) error {
+	tx, err := s.db.Begin()
 	if err != nil {
 		return fmt.Errorf("error starting transaction: %w", err)
 	}
@@ -316,14 +355,16 @@ func SaveAlbum(album models.BandcampAlbumData) error {
 
 	_, err = tx.Exec(`
 			INSERT INTO albums (
-					id, slug, artist_name, album_name, image_url, image_data,
-					bandcamp_url, album_color_average, total_length, formatted_length,
-					date_added
-			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+					id, slug, artist_name, album_name, image_url, image_storage_path, image_data,
+					bandcamp_url, ampwall_url, metal_archives_url, album_color_average,
+					total_length, formatted_length, date_added, release_date, genre,
+					country, label, ignored_words, notes, enabled, artist_slug
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		album.ID, album.Slug, album.ArtistName, album.AlbumName,
-		album.ImageUrl, album.ImageData, album.BandcampUrl,
-		album.AlbumColorAverage, album.TotalLength, album.FormattedLength,
-		album.DateAdded,
+		album.ImageUrl, album.ImageStoragePath, album.ImageData,
+		album.BandcampUrl, album.AmpwallUrl, album.MetalArchivesURL, album.AlbumColorAverage,
+		album.TotalLength, album.FormattedLength, album.DateAdded, album.ReleaseDate, album.Genre,
+		album.Country, album.Label, album.IgnoredWords, album.Notes, album.Enabled, album.ArtistSlug,
 	)
 	if err != nil {
 		return fmt.Errorf("error inserting album: %w", err)
@@ -331,27 +372,26 @@ func SaveAlbum(album models.BandcampAlbumData) error {
 
 	for _, track := range album.Tracks {
 		_, err = tx.Exec(`
-					INSERT INTO tracks (album_id, name, total_length, formatted_length, lyrics)
-					VALUES (?, ?, ?, ?, ?)`,
-			album.ID, track.Name, track.TotalLength, track.FormattedLength, track.Lyrics,
+					INSERT INTO tracks (album_id, name, track_number, total_length, formatted_length, lyrics, ignored_words, language)
+					VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			album.ID, track.Name, track.TrackNumber, track.TotalLength, track.FormattedLength, track.Lyrics, track.IgnoredWords,
+			words.TrackLanguage(track),
 		)
 		if err != nil {
 			return fmt.Errorf("error inserting track: %w", err)
 		}
 	}
 
-	return tx.Commit()
-}
-
-func FetchAlbumNamesOnly() ([]models.BandcampAlbumData, error) {
-	db, err := sql.Open("sqlite", dbPath)
-	if err != nil {
-		return nil, fmt.Errorf("error opening database at %s: %w", dbPath, err)
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing album: %w", err)
 	}
-	defer db.Close()
+	s.refreshAnalytics(album.ID)
+	return nil
+}
 
+func (s *Store) FetchAlbumNamesOnly() ([]models.BandcampAlbumData, error) {
 	query := `SELECT id, artist_name, album_name FROM albums ORDER BY date_added DESC`
-	rows, err := db.Query(query)
+	rows, err := s.db.Query(query)
 	if err != nil {
 		return nil, fmt.Errorf("error querying albums: %w", err)
 	}
@@ -0,0 +1,350 @@
+package loader
+
+import (
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"millions-of-words/analytics"
+	"millions-of-words/models"
+)
+
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	store, err := NewStore(filepath.Join(t.TempDir(), "albums.db"), "", "")
+	if err != nil {
+		t.Fatalf("NewStore() error: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestSaveAlbumRoundTrip(t *testing.T) {
+	store := newTestStore(t)
+
+	album := models.BandcampAlbumData{
+		ID:                "Artist - Album",
+		Slug:              "artist-album",
+		ArtistName:        "Artist",
+		ArtistSlug:        "the-artist",
+		AlbumName:         "Album",
+		ImageUrl:          "https://example.com/cover.jpg",
+		ImageStoragePath:  "Artist - Album.jpg",
+		ImageData:         []byte{1, 2, 3},
+		BandcampUrl:       "https://artist.bandcamp.com/album/album",
+		AmpwallUrl:        "https://ampwall.com/a/artist",
+		MetalArchivesURL:  "https://www.metal-archives.com/albums/artist/album/1",
+		AlbumColorAverage: "#112233",
+		DateAdded:         "2024-01-02 03:04:05",
+		ReleaseDate:       "2023-12-21",
+		Genre:             "Doom",
+		Country:           "Finland",
+		Label:             "Label",
+		IgnoredWords:      "la",
+		Notes:             "Notes",
+		TotalLength:       300,
+		FormattedLength:   "5m 0s",
+		Enabled:           true,
+		Tracks: []models.BandcampTrackData{
+			{Name: "Second", TrackNumber: 2, TotalLength: 200, FormattedLength: "3m 20s", Lyrics: "two words", IgnoredWords: "two"},
+			{Name: "First", TrackNumber: 1, TotalLength: 100, FormattedLength: "1m 40s", Lyrics: "one", IgnoredWords: ""},
+		},
+	}
+
+	if err := store.SaveAlbum(album); err != nil {
+		t.Fatalf("SaveAlbum() error: %v", err)
+	}
+
+	got, err := store.GetAlbumBySlug("artist-album")
+	if err != nil {
+		t.Fatalf("GetAlbumBySlug() error: %v", err)
+	}
+
+	stored := []struct {
+		name      string
+		got, want interface{}
+	}{
+		{"ID", got.ID, album.ID},
+		{"ArtistSlug", got.ArtistSlug, album.ArtistSlug},
+		{"ImageUrl", got.ImageUrl, album.ImageUrl},
+		{"ImageStoragePath", got.ImageStoragePath, album.ImageStoragePath},
+		{"ImageData", got.ImageData, album.ImageData},
+		{"AmpwallUrl", got.AmpwallUrl, album.AmpwallUrl},
+		{"MetalArchivesURL", got.MetalArchivesURL, album.MetalArchivesURL},
+		{"AlbumColorAverage", got.AlbumColorAverage, album.AlbumColorAverage},
+		{"ReleaseDate", got.ReleaseDate, album.ReleaseDate},
+		{"Genre", got.Genre, album.Genre},
+		{"Country", got.Country, album.Country},
+		{"Label", got.Label, album.Label},
+		{"IgnoredWords", got.IgnoredWords, album.IgnoredWords},
+		{"Notes", got.Notes, album.Notes},
+		{"TotalLength", got.TotalLength, album.TotalLength},
+		{"FormattedLength", got.FormattedLength, album.FormattedLength},
+		{"Enabled", got.Enabled, album.Enabled},
+	}
+	for _, f := range stored {
+		if !reflect.DeepEqual(f.got, f.want) {
+			t.Errorf("%s = %v, want %v", f.name, f.got, f.want)
+		}
+	}
+
+	if len(got.Tracks) != 2 {
+		t.Fatalf("got %d tracks, want 2", len(got.Tracks))
+	}
+	first, second := got.Tracks[0], got.Tracks[1]
+	if first.Name != "First" || first.TrackNumber != 1 || first.Lyrics != "one" {
+		t.Errorf("first track = %+v", first)
+	}
+	if second.Name != "Second" || second.TrackNumber != 2 || second.IgnoredWords != "two" || second.FormattedLength != "3m 20s" {
+		t.Errorf("second track = %+v", second)
+	}
+}
+
+func TestUpdateAlbumAndTrack(t *testing.T) {
+	store := newTestStore(t)
+
+	album := models.BandcampAlbumData{
+		ID:      "a",
+		Slug:    "a",
+		Enabled: true,
+		Tracks:  []models.BandcampTrackData{{Name: "Song", TrackNumber: 1, Lyrics: "old"}},
+	}
+	if err := store.SaveAlbum(album); err != nil {
+		t.Fatalf("SaveAlbum() error: %v", err)
+	}
+
+	err := store.UpdateAlbum(models.UpdateAlbumRequest{
+		AlbumID:     "a",
+		ReleaseDate: "2020-01-01",
+		Genre:       "Black",
+		Enabled:     "false",
+	})
+	if err != nil {
+		t.Fatalf("UpdateAlbum() error: %v", err)
+	}
+
+	err = store.UpdateTrack(models.UpdateTrackRequest{
+		AlbumID:      "a",
+		TrackName:    "Song",
+		TrackNumber:  3,
+		Lyrics:       "new lyrics",
+		IgnoredWords: "new",
+	})
+	if err != nil {
+		t.Fatalf("UpdateTrack() error: %v", err)
+	}
+
+	got, err := store.GetAlbumByID("a")
+	if err != nil {
+		t.Fatalf("GetAlbumByID() error: %v", err)
+	}
+	if got.Enabled || got.Genre != "Black" || got.ReleaseDate != "2020-01-01" {
+		t.Errorf("album = %+v", got)
+	}
+	track := got.Tracks[0]
+	if track.TrackNumber != 3 || track.Lyrics != "new lyrics" || track.IgnoredWords != "new" {
+		t.Errorf("track = %+v", track)
+	}
+
+	enabled, err := store.LoadAlbumsData()
+	if err != nil {
+		t.Fatalf("LoadAlbumsData() error: %v", err)
+	}
+	if len(enabled) != 0 {
+		t.Errorf("LoadAlbumsData() returned %d albums, want disabled album hidden", len(enabled))
+	}
+
+	if err := store.UpdateAlbum(models.UpdateAlbumRequest{AlbumID: "missing"}); err == nil {
+		t.Error("UpdateAlbum() on a missing album should fail")
+	}
+}
+
+func TestMigrateIsIdempotent(t *testing.T) {
+	store := newTestStore(t)
+
+	if err := migrate(store.db); err != nil {
+		t.Fatalf("second migrate() error: %v", err)
+	}
+
+	var version int
+	if err := store.db.QueryRow(`SELECT MAX(version) FROM schema_migrations`).Scan(&version); err != nil {
+		t.Fatalf("reading schema version: %v", err)
+	}
+	if version != len(migrations) {
+		t.Errorf("schema version = %d, want %d", version, len(migrations))
+	}
+}
+
+func TestTrackLanguage(t *testing.T) {
+	store := newTestStore(t)
+
+	german := "Die Nacht ist kalt und das Feuer brennt, Blut an meinen Händen und der Tod in meinen Augen"
+	english := "The night is cold and the fire burns, blood on my hands and death in my eyes"
+	album := models.BandcampAlbumData{
+		ID:   "a",
+		Slug: "a",
+		Tracks: []models.BandcampTrackData{
+			{Name: "Nacht", TrackNumber: 1, Lyrics: german},
+			{Name: "Kept", TrackNumber: 2, Lyrics: german, Language: "la"},
+		},
+	}
+	if err := store.SaveAlbum(album); err != nil {
+		t.Fatalf("SaveAlbum() error: %v", err)
+	}
+
+	stored := func(name string) string {
+		var language string
+		if err := store.db.QueryRow(`SELECT language FROM tracks WHERE name = ?`, name).Scan(&language); err != nil {
+			t.Fatalf("reading language of %s: %v", name, err)
+		}
+		return language
+	}
+	if got := stored("Nacht"); got != "de" {
+		t.Errorf("detected language = %q, want de", got)
+	}
+	if got := stored("Kept"); got != "la" {
+		t.Errorf("given language = %q, want la", got)
+	}
+
+	if err := store.UpdateTrack(models.UpdateTrackRequest{AlbumID: "a", TrackName: "Nacht", TrackNumber: 1, Lyrics: english}); err != nil {
+		t.Fatalf("UpdateTrack() error: %v", err)
+	}
+	if got := stored("Nacht"); got != "en" {
+		t.Errorf("language after new lyrics = %q, want en", got)
+	}
+
+	// Tracks saved before languages were stored are detected on load.
+	if _, err := store.db.Exec(`UPDATE tracks SET language = '' WHERE name = 'Kept'`); err != nil {
+		t.Fatalf("clearing language: %v", err)
+	}
+	got, err := store.GetAlbumByID("a")
+	if err != nil {
+		t.Fatalf("GetAlbumByID() error: %v", err)
+	}
+	if got.Tracks[1].Language != "de" {
+		t.Errorf("loaded language = %q, want de", got.Tracks[1].Language)
+	}
+}
+
+func TestAlbumAnalytics(t *testing.T) {
+	store := newTestStore(t)
+
+	album := models.BandcampAlbumData{
+		ID:      "a",
+		Slug:    "a",
+		Enabled: true,
+		Tracks:  []models.BandcampTrackData{{Name: "Song", TrackNumber: 1, Lyrics: "fire and ice"}},
+	}
+	if err := store.SaveAlbum(album); err != nil {
+		t.Fatalf("SaveAlbum() error: %v", err)
+	}
+
+	var version int
+	if err := store.db.QueryRow(`SELECT version FROM album_analytics WHERE album_id = 'a'`).Scan(&version); err != nil {
+		t.Fatalf("reading stored analytics: %v", err)
+	}
+	if version != analytics.Version {
+		t.Errorf("stored version = %d, want %d", version, analytics.Version)
+	}
+
+	totalWords := func() int {
+		t.Helper()
+		loaded, err := store.LoadAlbumsData()
+		if err != nil || len(loaded) != 1 {
+			t.Fatalf("LoadAlbumsData() = %d albums, %v", len(loaded), err)
+		}
+		return loaded[0].TotalWords
+	}
+
+	// Loads read the stored numbers rather than counting again.
+	stored, _, err := store.getAnalytics("a")
+	if err != nil {
+		t.Fatalf("getAnalytics() error: %v", err)
+	}
+	stored.TotalWords = 99
+	stored.Version = analytics.Version - 1
+	if err := store.saveAnalytics("a", stored); err != nil {
+		t.Fatalf("saveAnalytics() error: %v", err)
+	}
+	if got := totalWords(); got != 99 {
+		t.Errorf("TotalWords = %d, want the stored 99", got)
+	}
+
+	// Numbers from an older version are recomputed in the background.
+	updated, err := store.RecomputeAnalytics()
+	if err != nil || updated != 1 {
+		t.Fatalf("RecomputeAnalytics() = %d, %v; want 1 album", updated, err)
+	}
+	if got := totalWords(); got != 3 {
+		t.Errorf("TotalWords after recompute = %d, want 3", got)
+	}
+	if updated, _ := store.RecomputeAnalytics(); updated != 0 {
+		t.Errorf("second RecomputeAnalytics() updated %d albums, want 0", updated)
+	}
+
+	// Edits through the store, and lyrics changed behind its back, are
+	// counted again.
+	if err := store.UpdateTrack(models.UpdateTrackRequest{AlbumID: "a", TrackName: "Song", TrackNumber: 1, Lyrics: "fire"}); err != nil {
+		t.Fatalf("UpdateTrack() error: %v", err)
+	}
+	if got := totalWords(); got != 1 {
+		t.Errorf("TotalWords after UpdateTrack = %d, want 1", got)
+	}
+
+	// Writes that leave the lyrics alone keep the fresh numbers.
+	if _, err := store.db.Exec(`UPDATE album_analytics SET computed_at = '2000-01-01' WHERE album_id = 'a'`); err != nil {
+		t.Fatalf("backdating analytics: %v", err)
+	}
+	if err := store.UpdateTrack(models.UpdateTrackRequest{AlbumID: "a", TrackName: "Song", TrackNumber: 1, Lyrics: "fire"}); err != nil {
+		t.Fatalf("UpdateTrack() error: %v", err)
+	}
+	var backdated int
+	if err := store.db.QueryRow(`SELECT COUNT(*) FROM album_analytics WHERE album_id = 'a' AND computed_at = '2000-01-01'`).Scan(&backdated); err != nil {
+		t.Fatalf("reading computed_at: %v", err)
+	}
+	if backdated != 1 {
+		t.Error("UpdateTrack recomputed analytics that were still fresh")
+	}
+	if _, err := store.db.Exec(`UPDATE tracks SET lyrics = 'fire fire' WHERE album_id = 'a'`); err != nil {
+		t.Fatalf("editing lyrics: %v", err)
+	}
+	if got := totalWords(); got != 2 {
+		t.Errorf("TotalWords after editing the database = %d, want 2", got)
+	}
+}
+
+func TestMigrateRenamesDuplicateSlugs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "albums.db")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("opening database: %v", err)
+	}
+	// A prototype database: only the first migration, with a slug used twice.
+	_, err = db.Exec(migrations[0] + `
+		CREATE TABLE schema_migrations (version INTEGER PRIMARY KEY);
+		INSERT INTO schema_migrations (version) VALUES (1);
+		INSERT INTO albums (id, slug, date_added) VALUES ('a', 'same', '2024-01-01');
+		INSERT INTO albums (id, slug, date_added) VALUES ('b', 'same', '2024-01-02');
+		INSERT INTO albums (id, slug, date_added) VALUES ('c', 'other', '2024-01-03');`)
+	db.Close()
+	if err != nil {
+		t.Fatalf("creating prototype database: %v", err)
+	}
+
+	store, err := NewStore(path, "", "")
+	if err != nil {
+		t.Fatalf("NewStore() on duplicate slugs error: %v", err)
+	}
+	defer store.Close()
+
+	for id, want := range map[string]string{"a": "same", "b": "same-2", "c": "other"} {
+		var slug string
+		if err := store.db.QueryRow(`SELECT slug FROM albums WHERE id = ?`, id).Scan(&slug); err != nil {
+			t.Fatalf("reading slug of %s: %v", id, err)
+		}
+		if slug != want {
+			t.Errorf("slug of %s = %q, want %q", id, slug, want)
+		}
+	}
+}
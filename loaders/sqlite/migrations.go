@@ -0,0 +1,144 @@
+package loader
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+)
+
+// migrations are applied in order and each one runs exactly once per database.
+// Never edit a migration that has shipped; append a new one instead.
+var migrations = []string{
+	// 1: the original prototype schema, as created by albumfetcher.
+	`
+	CREATE TABLE IF NOT EXISTS albums (
+		id TEXT PRIMARY KEY,
+		slug TEXT,
+		artist_name TEXT,
+		album_name TEXT,
+		image_url TEXT,
+		image_data BLOB,
+		bandcamp_url TEXT,
+		ampwall_url TEXT,
+		metal_archives_url TEXT,
+		album_color_average TEXT,
+		total_length INTEGER,
+		formatted_length TEXT,
+		date_added DATETIME
+	);
+	CREATE TABLE IF NOT EXISTS tracks (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		album_id TEXT,
+		name TEXT,
+		total_length INTEGER,
+		formatted_length TEXT,
+		lyrics TEXT,
+		FOREIGN KEY(album_id) REFERENCES albums(id)
+	);
+	`,
+	// 2: album metadata and per-track fields that the Supabase schema has.
+	// Existing albums stay visible; track numbers follow insertion order.
+	// Prototype databases could hold the same slug twice, which the unique
+	// index refuses, so every copy after the first gets its row id appended.
+	`
+	ALTER TABLE albums ADD COLUMN image_storage_path TEXT NOT NULL DEFAULT '';
+	ALTER TABLE albums ADD COLUMN release_date TEXT NOT NULL DEFAULT '';
+	ALTER TABLE albums ADD COLUMN genre TEXT NOT NULL DEFAULT '';
+	ALTER TABLE albums ADD COLUMN country TEXT NOT NULL DEFAULT '';
+	ALTER TABLE albums ADD COLUMN label TEXT NOT NULL DEFAULT '';
+	ALTER TABLE albums ADD COLUMN ignored_words TEXT NOT NULL DEFAULT '';
+	ALTER TABLE albums ADD COLUMN notes TEXT NOT NULL DEFAULT '';
+	ALTER TABLE albums ADD COLUMN enabled INTEGER NOT NULL DEFAULT 1;
+	ALTER TABLE tracks ADD COLUMN track_number INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE tracks ADD COLUMN ignored_words TEXT NOT NULL DEFAULT '';
+	UPDATE tracks SET track_number = (
+		SELECT COUNT(*) FROM tracks t WHERE t.album_id = tracks.album_id AND t.id <= tracks.id
+	);
+	UPDATE albums SET slug = slug || '-' || rowid
+	WHERE EXISTS (SELECT 1 FROM albums a WHERE a.slug = albums.slug AND a.rowid < albums.rowid);
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_albums_slug ON albums(slug);
+	CREATE INDEX IF NOT EXISTS idx_tracks_album_id ON tracks(album_id);
+	`,
+	// 3: the editable profanity lexicon. It starts empty and is seeded with
+	// the built-in terms on first start.
+	`
+	CREATE TABLE IF NOT EXISTS profanity_terms (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		word TEXT NOT NULL UNIQUE,
+		category TEXT NOT NULL
+	);
+	`,
+	// 4: stopwords added by the admin. The built-in list is not stored.
+	`
+	CREATE TABLE IF NOT EXISTS stopwords (
+		word TEXT PRIMARY KEY
+	);
+	`,
+	// 5: artists, and the slug albums use to point at them. Existing albums
+	// keep an empty artist_slug and fall back to one made from artist_name.
+	`
+	CREATE TABLE IF NOT EXISTS artists (
+		slug TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		country TEXT NOT NULL DEFAULT '',
+		genre TEXT NOT NULL DEFAULT '',
+		bandcamp_url TEXT NOT NULL DEFAULT '',
+		metal_archives_url TEXT NOT NULL DEFAULT '',
+		website TEXT NOT NULL DEFAULT ''
+	);
+	ALTER TABLE albums ADD COLUMN artist_slug TEXT NOT NULL DEFAULT '';
+	CREATE INDEX IF NOT EXISTS idx_albums_artist_slug ON albums(artist_slug);
+	`,
+	// 6: the language each track's lyrics are written in. Existing tracks
+	// keep an empty language and have it detected when they are loaded.
+	`
+	ALTER TABLE tracks ADD COLUMN language TEXT NOT NULL DEFAULT '';
+	`,
+	// 7: the numbers derived from each album's lyrics, as JSON from the
+	// analytics package. Albums without a row get one on their next load.
+	`
+	CREATE TABLE IF NOT EXISTS album_analytics (
+		album_id TEXT PRIMARY KEY REFERENCES albums(id),
+		version INTEGER NOT NULL,
+		metrics TEXT NOT NULL,
+		computed_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+	`,
+}
+
+func migrate(db *sql.DB) error {
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY)`); err != nil {
+		return fmt.Errorf("error creating schema_migrations table: %w", err)
+	}
+
+	var current int
+	if err := db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return fmt.Errorf("error reading schema version: %w", err)
+	}
+
+	for i := current; i < len(migrations); i++ {
+		version := i + 1
+		log.Printf("Applying SQLite migration %d", version)
+
+		tx, err := db.Begin()
+		if err != nil {
+			return fmt.Errorf("error starting migration %d: %w", version, err)
+		}
+
+		if _, err := tx.Exec(migrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("error applying migration %d: %w", version, err)
+		}
+
+		if _, err := tx.Exec(`INSERT INTO schema_migrations (version) VALUES (?)`, version); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("error recording migration %d: %w", version, err)
+		}
+
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("error committing migration %d: %w", version, err)
+		}
+	}
+
+	return nil
+}
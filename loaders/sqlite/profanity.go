@@ -0,0 +1,64 @@
+package loader
+
+import (
+	"fmt"
+
+	"millions-of-words/models"
+)
+
+func (s *Store) LoadProfanityTerms() ([]models.ProfanityTerm, error) {
+	rows, err := s.db.Query(`SELECT id, word, category FROM profanity_terms ORDER BY category, word`)
+	if err != nil {
+		return nil, fmt.Errorf("error querying profanity terms: %w", err)
+	}
+	defer rows.Close()
+
+	var terms []models.ProfanityTerm
+	for rows.Next() {
+		var term models.ProfanityTerm
+		if err := rows.Scan(&term.ID, &term.Word, &term.Category); err != nil {
+			return nil, fmt.Errorf("error scanning profanity term: %w", err)
+		}
+		terms = append(terms, term)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating profanity terms: %w", err)
+	}
+
+	return terms, nil
+}
+
+func (s *Store) SaveProfanityTerm(term models.ProfanityTerm) error {
+	if term.ID == 0 {
+		_, err := s.db.Exec(`INSERT INTO profanity_terms (word, category) VALUES (?, ?)
+			ON CONFLICT(word) DO UPDATE SET category = excluded.category`,
+			term.Word, term.Category)
+		if err != nil {
+			return fmt.Errorf("error inserting profanity term: %w", err)
+		}
+		return nil
+	}
+
+	result, err := s.db.Exec(`UPDATE profanity_terms SET word = ?, category = ? WHERE id = ?`,
+		term.Word, term.Category, term.ID)
+	if err != nil {
+		return fmt.Errorf("error updating profanity term: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error checking update result: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("no matching profanity term found")
+	}
+	return nil
+}
+
+func (s *Store) DeleteProfanityTerm(id int64) error {
+	if _, err := s.db.Exec(`DELETE FROM profanity_terms WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("error deleting profanity term: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package loader
+
+import (
+	"reflect"
+	"testing"
+
+	"millions-of-words/models"
+)
+
+func TestProfanityTerms(t *testing.T) {
+	store := newTestStore(t)
+
+	for _, term := range []models.ProfanityTerm{
+		{Word: "shit*", Category: "scatological"},
+		{Word: "fuck*", Category: "sexual"},
+		{Word: "shit*", Category: "mild"},
+	} {
+		if err := store.SaveProfanityTerm(term); err != nil {
+			t.Fatalf("SaveProfanityTerm(%+v) error: %v", term, err)
+		}
+	}
+
+	terms, err := store.LoadProfanityTerms()
+	if err != nil {
+		t.Fatalf("LoadProfanityTerms() error: %v", err)
+	}
+	want := []models.ProfanityTerm{
+		{ID: 1, Word: "shit*", Category: "mild"},
+		{ID: 2, Word: "fuck*", Category: "sexual"},
+	}
+	if !reflect.DeepEqual(terms, want) {
+		t.Fatalf("LoadProfanityTerms() = %+v, want %+v", terms, want)
+	}
+
+	if err := store.SaveProfanityTerm(models.ProfanityTerm{ID: 2, Word: "fuck*", Category: "strong"}); err != nil {
+		t.Fatalf("SaveProfanityTerm() update error: %v", err)
+	}
+	if err := store.SaveProfanityTerm(models.ProfanityTerm{ID: 99, Word: "damn", Category: "mild"}); err == nil {
+		t.Error("SaveProfanityTerm() on a missing ID should fail")
+	}
+	if err := store.DeleteProfanityTerm(1); err != nil {
+		t.Fatalf("DeleteProfanityTerm() error: %v", err)
+	}
+
+	terms, err = store.LoadProfanityTerms()
+	if err != nil {
+		t.Fatalf("LoadProfanityTerms() error: %v", err)
+	}
+	want = []models.ProfanityTerm{{ID: 2, Word: "fuck*", Category: "strong"}}
+	if !reflect.DeepEqual(terms, want) {
+		t.Errorf("LoadProfanityTerms() after edits = %+v, want %+v", terms, want)
+	}
+}
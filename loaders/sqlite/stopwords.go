@@ -0,0 +1,42 @@
+package loader
+
+import (
+	"fmt"
+)
+
+func (s *Store) LoadStopwords() ([]string, error) {
+	rows, err := s.db.Query(`SELECT word FROM stopwords ORDER BY word`)
+	if err != nil {
+		return nil, fmt.Errorf("error querying stopwords: %w", err)
+	}
+	defer rows.Close()
+
+	var stopwords []string
+	for rows.Next() {
+		var word string
+		if err := rows.Scan(&word); err != nil {
+			return nil, fmt.Errorf("error scanning stopword: %w", err)
+		}
+		stopwords = append(stopwords, word)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating stopwords: %w", err)
+	}
+
+	return stopwords, nil
+}
+
+func (s *Store) SaveStopword(word string) error {
+	if _, err := s.db.Exec(`INSERT INTO stopwords (word) VALUES (?) ON CONFLICT(word) DO NOTHING`, word); err != nil {
+		return fmt.Errorf("error inserting stopword: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) DeleteStopword(word string) error {
+	if _, err := s.db.Exec(`DELETE FROM stopwords WHERE word = ?`, word); err != nil {
+		return fmt.Errorf("error deleting stopword: %w", err)
+	}
+	return nil
+}
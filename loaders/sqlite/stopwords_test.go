@@ -0,0 +1,35 @@
+package loader
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStopwords(t *testing.T) {
+	store := newTestStore(t)
+
+	for _, word := range []string{"oh", "yeah", "oh"} {
+		if err := store.SaveStopword(word); err != nil {
+			t.Fatalf("SaveStopword(%q) error: %v", word, err)
+		}
+	}
+
+	stopwords, err := store.LoadStopwords()
+	if err != nil {
+		t.Fatalf("LoadStopwords() error: %v", err)
+	}
+	if want := []string{"oh", "yeah"}; !reflect.DeepEqual(stopwords, want) {
+		t.Fatalf("LoadStopwords() = %v, want %v", stopwords, want)
+	}
+
+	if err := store.DeleteStopword("oh"); err != nil {
+		t.Fatalf("DeleteStopword() error: %v", err)
+	}
+	stopwords, err = store.LoadStopwords()
+	if err != nil {
+		t.Fatalf("LoadStopwords() error: %v", err)
+	}
+	if want := []string{"yeah"}; !reflect.DeepEqual(stopwords, want) {
+		t.Errorf("LoadStopwords() after delete = %v, want %v", stopwords, want)
+	}
+}
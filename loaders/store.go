@@ -0,0 +1,107 @@
+package loaders
+
+import (
+	"fmt"
+
+	sqliteLoader "millions-of-words/loaders/sqlite"
+	supabaseLoader "millions-of-words/loaders/supabase"
+	"millions-of-words/models"
+)
+
+const (
+	BackendSupabase = "supabase"
+	BackendSQLite   = "sqlite"
+)
+
+// AlbumStore is everything the site and the admin need to read and write albums.
+type AlbumStore interface {
+	LoadAlbumsData(limit ...int) ([]models.BandcampAlbumData, error)
+	LoadAllAlbumsData(limit ...int) ([]models.BandcampAlbumData, error)
+	GetAlbumBySlug(slug string) (models.BandcampAlbumData, error)
+	GetAlbumByID(id string) (models.BandcampAlbumData, error)
+	AlbumUrlExists(url string) (bool, error)
+	SaveAlbum(album models.BandcampAlbumData) error
+	UpdateTrack(req models.UpdateTrackRequest) error
+	UpdateAlbum(req models.UpdateAlbumRequest) error
+	FetchAlbumNamesOnly() ([]models.BandcampAlbumData, error)
+}
+
+// ProfanityStore keeps the profanity lexicon that the admin edits.
+type ProfanityStore interface {
+	LoadProfanityTerms() ([]models.ProfanityTerm, error)
+	// SaveProfanityTerm adds the term when its ID is zero and updates it otherwise.
+	SaveProfanityTerm(term models.ProfanityTerm) error
+	DeleteProfanityTerm(id int64) error
+}
+
+// StopwordStore keeps the stopwords the admin adds on top of the built-in list.
+type StopwordStore interface {
+	LoadStopwords() ([]string, error)
+	SaveStopword(word string) error
+	DeleteStopword(word string) error
+}
+
+// ArtistStore keeps the artists that albums point at through their ArtistSlug.
+type ArtistStore interface {
+	LoadArtists() ([]models.Artist, error)
+	GetArtistBySlug(slug string) (models.Artist, error)
+	// SaveArtist adds the artist, or replaces the one with the same slug.
+	SaveArtist(artist models.Artist) error
+}
+
+// AnalyticsStore keeps the numbers derived from each album's lyrics, so that
+// loading albums does not re-tokenise them. Writes through AlbumStore keep
+// them up to date.
+type AnalyticsStore interface {
+	// RecomputeAnalytics recomputes the analytics stored by an older
+	// analytics.Version and returns how many albums it updated.
+	RecomputeAnalytics() (int, error)
+}
+
+// Authenticator signs admin users in and validates their session tokens.
+type Authenticator interface {
+	SignInWithEmail(email, password string) (*models.User, error)
+	ValidateSession(sessionToken string) (*models.User, error)
+}
+
+// Store is a storage backend. Each backend handles its own admin auth.
+type Store interface {
+	AlbumStore
+	ProfanityStore
+	StopwordStore
+	ArtistStore
+	AnalyticsStore
+	Authenticator
+}
+
+type Config struct {
+	Backend string
+	// SupabaseConfigPath is read when the SUPABASE_* environment variables are unset.
+	SupabaseConfigPath string
+	SQLitePath         string
+	AdminEmail         string
+	AdminPassword      string
+}
+
+func New(cfg Config) (Store, error) {
+	switch cfg.Backend {
+	case "", BackendSupabase:
+		supabaseConfig, err := supabaseLoader.LoadConfig(cfg.SupabaseConfigPath)
+		if err != nil {
+			return nil, fmt.Errorf("error loading supabase config (set SUPABASE_URL or provide %s): %w", cfg.SupabaseConfigPath, err)
+		}
+		store, err := supabaseLoader.NewStore(supabaseConfig)
+		if err != nil {
+			return nil, fmt.Errorf("error creating supabase store: %w", err)
+		}
+		return store, nil
+	case BackendSQLite:
+		store, err := sqliteLoader.NewStore(cfg.SQLitePath, cfg.AdminEmail, cfg.AdminPassword)
+		if err != nil {
+			return nil, fmt.Errorf("error opening sqlite store: %w", err)
+		}
+		return store, nil
+	default:
+		return nil, fmt.Errorf("unknown store backend %q", cfg.Backend)
+	}
+}
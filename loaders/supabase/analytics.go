@@ -0,0 +1,132 @@
+package loader
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"millions-of-words/analytics"
+	"millions-of-words/models"
+)
+
+// albumAnalyticsRow is a row of the album_analytics table, created by the
+// migrations in supabase/migrations.
+type albumAnalyticsRow struct {
+	AlbumID string          `json:"album_id"`
+	Version int             `json:"version"`
+	Metrics analytics.Album `json:"metrics"`
+}
+
+// loadAnalytics reads the stored analytics of every album, by album ID.
+func (s *Store) loadAnalytics() (map[string]analytics.Album, error) {
+	data, _, err := s.publicClient.From("album_analytics").
+		Select("album_id, version, metrics", "exact", false).
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("error querying album analytics: %w", err)
+	}
+
+	var rows []albumAnalyticsRow
+	if err := json.Unmarshal(data, &rows); err != nil {
+		return nil, fmt.Errorf("error scanning album analytics rows: %w", err)
+	}
+
+	stored := make(map[string]analytics.Album, len(rows))
+	for _, row := range rows {
+		stored[row.AlbumID] = row.Metrics
+	}
+	return stored, nil
+}
+
+func (s *Store) getAnalytics(albumID string) (analytics.Album, bool, error) {
+	data, _, err := s.publicClient.From("album_analytics").
+		Select("album_id, version, metrics", "exact", false).
+		Eq("album_id", albumID).
+		Execute()
+	if err != nil {
+		return analytics.Album{}, false, fmt.Errorf("error fetching album analytics: %w", err)
+	}
+
+	var rows []albumAnalyticsRow
+	if err := json.Unmarshal(data, &rows); err != nil {
+		return analytics.Album{}, false, fmt.Errorf("error scanning album analytics: %w", err)
+	}
+	if len(rows) == 0 {
+		return analytics.Album{}, false, nil
+	}
+	return rows[0].Metrics, true, nil
+}
+
+func (s *Store) saveAnalytics(albumID string, computed analytics.Album) error {
+	row := albumAnalyticsRow{AlbumID: albumID, Version: computed.Version, Metrics: computed}
+	_, _, err := s.adminClient.From("album_analytics").
+		Upsert(row, "album_id", "", "").
+		Execute()
+	if err != nil {
+		return fmt.Errorf("error saving album analytics: %w", err)
+	}
+	return nil
+}
+
+// applyAnalytics puts the stored numbers on the album. Albums without any, or
+// whose lyrics changed since they were computed, are computed and stored
+// first. Numbers from an older analytics.Version are still shown until
+// RecomputeAnalytics catches up.
+func (s *Store) applyAnalytics(album *models.BandcampAlbumData, stored analytics.Album, ok bool) {
+	if !ok || !stored.Matches(*album) {
+		stored = analytics.Compute(*album)
+		if err := s.saveAnalytics(album.ID, stored); err != nil {
+			log.Printf("Error storing analytics of album %s: %v", album.ID, err)
+		}
+	}
+	stored.Apply(album)
+}
+
+// refreshAnalytics recomputes the analytics of an album after a write, unless
+// the write left the lyrics alone and the stored numbers are still fresh. The
+// write already succeeded, so failures are only logged; the album is
+// computed again on its next load.
+func (s *Store) refreshAnalytics(albumID string) {
+	album := models.BandcampAlbumData{ID: albumID}
+	if err := s.fetchTracks(&album); err != nil {
+		log.Printf("Error reloading tracks of album %s for analytics: %v", albumID, err)
+		return
+	}
+	if stored, ok, err := s.getAnalytics(albumID); err == nil && ok && stored.Fresh(album) {
+		return
+	}
+	if err := s.saveAnalytics(albumID, analytics.Compute(album)); err != nil {
+		log.Printf("Error storing analytics of album %s: %v", albumID, err)
+	}
+}
+
+// RecomputeAnalytics recomputes the analytics of every album that has none,
+// or has them from an older analytics.Version, and returns how many it
+// stored.
+func (s *Store) RecomputeAnalytics() (int, error) {
+	albums, err := s.fetchAlbums()
+	if err != nil {
+		return 0, err
+	}
+	stored, err := s.loadAnalytics()
+	if err != nil {
+		return 0, err
+	}
+
+	updated := 0
+	for _, album := range albums {
+		if metrics, ok := stored[album.ID]; ok && metrics.Version == analytics.Version {
+			continue
+		}
+		if err := s.fetchTracks(&album); err != nil {
+			log.Printf("Error fetching tracks of album %s for analytics: %v", album.ID, err)
+			continue
+		}
+		if err := s.saveAnalytics(album.ID, analytics.Compute(album)); err != nil {
+			log.Printf("Error storing analytics of album %s: %v", album.ID, err)
+			continue
+		}
+		updated++
+	}
+	return updated, nil
+}
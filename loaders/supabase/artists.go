@@ -0,0 +1,56 @@
+package loader
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"millions-of-words/models"
+
+	"github.com/supabase-community/postgrest-go"
+)
+
+// Artists live in an artists table whose primary key is the slug. Albums
+// point at them through their artist_slug column.
+
+func (s *Store) LoadArtists() ([]models.Artist, error) {
+	data, _, err := s.publicClient.From("artists").
+		Select("*", "exact", false).
+		Order("name", &postgrest.OrderOpts{Ascending: true}).
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("error querying artists: %w", err)
+	}
+
+	var artists []models.Artist
+	if err := json.Unmarshal(data, &artists); err != nil {
+		return nil, fmt.Errorf("error scanning artists: %w", err)
+	}
+	return artists, nil
+}
+
+func (s *Store) GetArtistBySlug(slug string) (models.Artist, error) {
+	data, _, err := s.publicClient.From("artists").
+		Select("*", "exact", false).
+		Eq("slug", slug).
+		Single().
+		Execute()
+	if err != nil {
+		return models.Artist{}, fmt.Errorf("error fetching artist: %w", err)
+	}
+
+	var artist models.Artist
+	if err := json.Unmarshal(data, &artist); err != nil {
+		return models.Artist{}, fmt.Errorf("error scanning artist: %w", err)
+	}
+	return artist, nil
+}
+
+func (s *Store) SaveArtist(artist models.Artist) error {
+	_, _, err := s.adminClient.From("artists").
+		Upsert(artist, "slug", "", "").
+		Execute()
+	if err != nil {
+		return fmt.Errorf("error saving artist: %w", err)
+	}
+	return nil
+}
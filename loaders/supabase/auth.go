@@ -7,20 +7,16 @@ import (
 	"io"
 	"net/http"
 	"strings"
-)
 
-// User represents an authenticated user
-type User struct {
-	AccessToken string
-	Email       string
-}
+	"millions-of-words/models"
+)
 
-func SignInWithEmail(email, password string) (*User, error) {
-	if publicClient == nil {
+func (s *Store) SignInWithEmail(email, password string) (*models.User, error) {
+	if s.publicClient == nil {
 		return nil, fmt.Errorf("supabase client not initialized")
 	}
 
-	url := fmt.Sprintf("%s/auth/v1/token?grant_type=password", strings.TrimRight(config.URL, "/"))
+	url := fmt.Sprintf("%s/auth/v1/token?grant_type=password", strings.TrimRight(s.config.URL, "/"))
 
 	body := map[string]interface{}{
 		"email":    email,
@@ -36,7 +32,7 @@ func SignInWithEmail(email, password string) (*User, error) {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("apikey", config.AnonKey)
+	req.Header.Set("apikey", s.config.AnonKey)
 	req.Header.Set("Content-Type", "application/json")
 
 	client := &http.Client{}
@@ -62,25 +58,25 @@ func SignInWithEmail(email, password string) (*User, error) {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	return &User{
+	return &models.User{
 		AccessToken: result.AccessToken,
 		Email:       result.User.Email,
 	}, nil
 }
 
-func ValidateSession(sessionToken string) (*User, error) {
-	if publicClient == nil {
+func (s *Store) ValidateSession(sessionToken string) (*models.User, error) {
+	if s.publicClient == nil {
 		return nil, fmt.Errorf("supabase client not initialized")
 	}
 
-	url := fmt.Sprintf("%s/auth/v1/user", strings.TrimRight(config.URL, "/"))
+	url := fmt.Sprintf("%s/auth/v1/user", strings.TrimRight(s.config.URL, "/"))
 
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("apikey", config.AnonKey)
+	req.Header.Set("apikey", s.config.AnonKey)
 	req.Header.Set("Authorization", "Bearer "+sessionToken)
 
 	client := &http.Client{}
@@ -102,7 +98,7 @@ func ValidateSession(sessionToken string) (*User, error) {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	return &User{
+	return &models.User{
 		AccessToken: sessionToken,
 		Email:       result.Email,
 	}, nil
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log"
 	"math"
+	"millions-of-words/fetch"
 	"millions-of-words/models"
 	"millions-of-words/words"
 	"os"
@@ -23,31 +24,38 @@ type SupabaseConfig struct {
 	AnonKey    string `json:"supabase_key"`
 }
 
-var (
+// Store reads and writes albums in Supabase. Build one with NewStore.
+type Store struct {
 	config       SupabaseConfig
 	publicClient *supa.Client
 	adminClient  *supa.Client
-)
+}
 
-func init() {
-	var err error
-	config, err = loadConfig("auth.json")
-	if err != nil {
-		log.Fatalf("Failed to load Supabase config: %v", err)
+func NewStore(config SupabaseConfig) (*Store, error) {
+	if config.URL == "" {
+		return nil, fmt.Errorf("supabase URL is not configured")
 	}
 
-	publicClient, err = supa.NewClient(config.URL, config.AnonKey, nil)
+	publicClient, err := supa.NewClient(config.URL, config.AnonKey, nil)
 	if err != nil {
-		log.Fatalf("Failed to create public Supabase client: %v", err)
+		return nil, fmt.Errorf("failed to create public Supabase client: %w", err)
 	}
 
-	adminClient, err = supa.NewClient(config.URL, config.ServiceKey, nil)
+	adminClient, err := supa.NewClient(config.URL, config.ServiceKey, nil)
 	if err != nil {
-		log.Fatalf("Failed to create admin Supabase client: %v", err)
+		return nil, fmt.Errorf("failed to create admin Supabase client: %w", err)
 	}
+
+	return &Store{
+		config:       config,
+		publicClient: publicClient,
+		adminClient:  adminClient,
+	}, nil
 }
 
-func loadConfig(path string) (SupabaseConfig, error) {
+// LoadConfig reads the Supabase settings from the SUPABASE_* environment
+// variables, or from the JSON file at path when SUPABASE_URL is not set.
+func LoadConfig(path string) (SupabaseConfig, error) {
 	if url := os.Getenv("SUPABASE_URL"); url != "" {
 		return SupabaseConfig{
 			URL:        url,
@@ -69,9 +77,13 @@ func loadConfig(path string) (SupabaseConfig, error) {
 	return config, nil
 }
 
-func LoadAlbumsData(limit ...int) ([]models.BandcampAlbumData, error) {
+func (s *Store) LoadAlbumsData(limit ...int) ([]models.BandcampAlbumData, error) {
 	log.Printf("Loading albums data...")
-	albums, err := fetchAlbums(limit...)
+	albums, err := s.fetchAlbums(limit...)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := s.loadAnalytics()
 	if err != nil {
 		return nil, err
 	}
@@ -79,11 +91,13 @@ func LoadAlbumsData(limit ...int) ([]models.BandcampAlbumData, error) {
 	var enabledAlbums []models.BandcampAlbumData
 	for _, album := range albums {
 		if album.Enabled {
-			if err := fetchTracks(&album); err != nil {
+			if err := s.fetchTracks(&album); err != nil {
 				log.Printf("Error fetching tracks for album %s: %v", album.ID, err)
 				continue
 			}
-			calculateAlbumMetrics(&album)
+			metrics, ok := stored[album.ID]
+			s.applyAnalytics(&album, metrics, ok)
+			fillDerivedFields(&album)
 			enabledAlbums = append(enabledAlbums, album)
 		}
 	}
@@ -91,25 +105,31 @@ func LoadAlbumsData(limit ...int) ([]models.BandcampAlbumData, error) {
 	return enabledAlbums, nil
 }
 
-func LoadAllAlbumsData(limit ...int) ([]models.BandcampAlbumData, error) {
+func (s *Store) LoadAllAlbumsData(limit ...int) ([]models.BandcampAlbumData, error) {
 	log.Printf("Loading all albums data...")
-	albums, err := fetchAlbums(limit...)
+	albums, err := s.fetchAlbums(limit...)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := s.loadAnalytics()
 	if err != nil {
 		return nil, err
 	}
 
 	for i := range albums {
-		if err := fetchTracks(&albums[i]); err != nil {
+		if err := s.fetchTracks(&albums[i]); err != nil {
 			log.Printf("Error fetching tracks for album %s: %v", albums[i].ID, err)
 			continue
 		}
-		calculateAlbumMetrics(&albums[i])
+		metrics, ok := stored[albums[i].ID]
+		s.applyAnalytics(&albums[i], metrics, ok)
+		fillDerivedFields(&albums[i])
 	}
 	return albums, nil
 }
 
-func fetchAlbums(limit ...int) ([]models.BandcampAlbumData, error) {
-	query := publicClient.From("albums").
+func (s *Store) fetchAlbums(limit ...int) ([]models.BandcampAlbumData, error) {
+	query := s.publicClient.From("albums").
 		Select("*", "exact", false).
 		Order("date_added", &postgrest.OrderOpts{
 			Ascending:  false,
@@ -132,7 +152,7 @@ func fetchAlbums(limit ...int) ([]models.BandcampAlbumData, error) {
 
 	for i := range albums {
 		if albums[i].ImageStoragePath != "" {
-			publicURL := adminClient.Storage.GetPublicUrl("album-covers", albums[i].ImageStoragePath)
+			publicURL := s.adminClient.Storage.GetPublicUrl("album-covers", albums[i].ImageStoragePath)
 			albums[i].ImageUrl = publicURL.SignedURL
 		}
 	}
@@ -140,9 +160,9 @@ func fetchAlbums(limit ...int) ([]models.BandcampAlbumData, error) {
 	return albums, nil
 }
 
-func fetchTracks(album *models.BandcampAlbumData) error {
-	data, _, err := publicClient.From("tracks").
-		Select("name, total_length, formatted_length, lyrics, track_number, ignored_words", "exact", false).
+func (s *Store) fetchTracks(album *models.BandcampAlbumData) error {
+	data, _, err := s.publicClient.From("tracks").
+		Select("name, total_length, formatted_length, lyrics, track_number, ignored_words, language", "exact", false).
 		Eq("album_id", album.ID).
 		Order("track_number", &postgrest.OrderOpts{Ascending: true}).
 		Execute()
@@ -160,8 +180,8 @@ func fetchTracks(album *models.BandcampAlbumData) error {
 	return nil
 }
 
-func GetAlbumBySlug(slug string) (models.BandcampAlbumData, error) {
-	data, _, err := publicClient.From("albums").
+func (s *Store) GetAlbumBySlug(slug string) (models.BandcampAlbumData, error) {
+	data, _, err := s.publicClient.From("albums").
 		Select("*", "exact", false).
 		Eq("slug", slug).
 		Single().
@@ -176,20 +196,25 @@ func GetAlbumBySlug(slug string) (models.BandcampAlbumData, error) {
 	}
 
 	if album.ImageStoragePath != "" {
-		publicURL := adminClient.Storage.GetPublicUrl("album-covers", album.ImageStoragePath)
+		publicURL := s.adminClient.Storage.GetPublicUrl("album-covers", album.ImageStoragePath)
 		album.ImageUrl = publicURL.SignedURL
 	}
 
-	if err := fetchTracks(&album); err != nil {
+	if err := s.fetchTracks(&album); err != nil {
 		return models.BandcampAlbumData{}, fmt.Errorf("error fetching tracks: %w", err)
 	}
 
-	calculateAlbumMetrics(&album)
+	stored, ok, err := s.getAnalytics(album.ID)
+	if err != nil {
+		return models.BandcampAlbumData{}, err
+	}
+	s.applyAnalytics(&album, stored, ok)
+	fillDerivedFields(&album)
 	return album, nil
 }
 
-func GetAlbumByID(id string) (models.BandcampAlbumData, error) {
-	data, _, err := publicClient.From("albums").
+func (s *Store) GetAlbumByID(id string) (models.BandcampAlbumData, error) {
+	data, _, err := s.publicClient.From("albums").
 		Select("*", "exact", false).
 		Eq("id", id).
 		Single().
@@ -204,20 +229,25 @@ func GetAlbumByID(id string) (models.BandcampAlbumData, error) {
 	}
 
 	if album.ImageStoragePath != "" {
-		publicURL := adminClient.Storage.GetPublicUrl("album-covers", album.ImageStoragePath)
+		publicURL := s.adminClient.Storage.GetPublicUrl("album-covers", album.ImageStoragePath)
 		album.ImageUrl = publicURL.SignedURL
 	}
 
-	if err := fetchTracks(&album); err != nil {
+	if err := s.fetchTracks(&album); err != nil {
 		return models.BandcampAlbumData{}, fmt.Errorf("error fetching tracks: %w", err)
 	}
 
-	calculateAlbumMetrics(&album)
+	stored, ok, err := s.getAnalytics(album.ID)
+	if err != nil {
+		return models.BandcampAlbumData{}, err
+	}
+	s.applyAnalytics(&album, stored, ok)
+	fillDerivedFields(&album)
 	return album, nil
 }
 
-func AlbumUrlExists(url string) (bool, error) {
-	data, _, err := publicClient.From("albums").
+func (s *Store) AlbumUrlExists(url string) (bool, error) {
+	data, _, err := s.publicClient.From("albums").
 		Select("id", "exact", false).
 		Eq("bandcamp_url", url).
 		Execute()
@@ -233,7 +263,7 @@ func AlbumUrlExists(url string) (bool, error) {
 	return len(results) > 0, nil
 }
 
-func SaveAlbum(album models.BandcampAlbumData) error {
+func (s *Store) SaveAlbum(album models.BandcampAlbumData) error {
 	var storagePath string
 	if len(album.ImageData) > 0 {
 		filename := fmt.Sprintf("%s.jpg", album.ID)
@@ -244,7 +274,7 @@ func SaveAlbum(album models.BandcampAlbumData) error {
 			ContentType: &contentType,
 		}
 
-		_, err := adminClient.Storage.UploadFile("album-covers", filename, imageReader, fileOpts)
+		_, err := s.adminClient.Storage.UploadFile("album-covers", filename, imageReader, fileOpts)
 		if err != nil {
 			return fmt.Errorf("error uploading image to storage: %w", err)
 		}
@@ -255,6 +285,7 @@ func SaveAlbum(album models.BandcampAlbumData) error {
 		"id":                  album.ID,
 		"slug":                album.Slug,
 		"artist_name":         album.ArtistName,
+		"artist_slug":         album.ArtistSlug,
 		"album_name":          album.AlbumName,
 		"image_url":           album.ImageUrl,
 		"image_storage_path":  storagePath,
@@ -272,7 +303,7 @@ func SaveAlbum(album models.BandcampAlbumData) error {
 		"notes":               album.Notes,
 	}
 
-	_, _, err := adminClient.From("albums").
+	_, _, err := s.adminClient.From("albums").
 		Insert(albumData, false, "albums", "id", "").
 		Execute()
 	if err != nil {
@@ -287,9 +318,10 @@ func SaveAlbum(album models.BandcampAlbumData) error {
 			"total_length":     track.TotalLength,
 			"formatted_length": track.FormattedLength,
 			"lyrics":           track.Lyrics,
+			"language":         words.TrackLanguage(track),
 		}
 
-		_, _, err = adminClient.From("tracks").
+		_, _, err = s.adminClient.From("tracks").
 			Insert(trackData, false, "tracks", "id", "").
 			Execute()
 		if err != nil {
@@ -297,54 +329,17 @@ func SaveAlbum(album models.BandcampAlbumData) error {
 		}
 	}
 
+	s.refreshAnalytics(album.ID)
 	return nil
 }
 
-func calculateAlbumMetrics(album *models.BandcampAlbumData) {
-	totalWords := 0
-	totalVowels := 0
-	totalConsonants := 0
-	totalChars := 0
-	totalCharsNoSpaces := 0
-	totalLines := 0
-	uniqueWords := make(map[string]struct{})
-	wordLengths := make(map[int]int)
-
-	for i, track := range album.Tracks {
-		wordCounts, vowels, consonants, lengths := words.CalculateAndSortWordFrequencies(track.Lyrics, track.IgnoredWords)
-		words := len(strings.Fields(track.Lyrics))
-
-		totalWords += words
-		totalVowels += vowels
-		totalConsonants += consonants
-		totalChars += len(track.Lyrics)
-		totalCharsNoSpaces += len(track.Lyrics) - strings.Count(track.Lyrics, " ")
-		totalLines += len(strings.Split(track.Lyrics, "\n"))
-
-		for l, c := range lengths {
-			wordLengths[l] += c
-		}
-		for _, wc := range wordCounts {
-			uniqueWords[wc.Word] = struct{}{}
-		}
-
-		track.TotalWords = words
-		track.TotalCharacters = len(track.Lyrics)
-		track.TotalCharactersNoSpaces = len(strings.ReplaceAll(strings.ReplaceAll(track.Lyrics, " ", ""), "\n", ""))
-		track.TotalLines = len(strings.Split(track.Lyrics, "\n"))
-		album.Tracks[i] = track
+// fillDerivedFields sets the album fields that do not come from its lyrics
+// and so are not stored with its analytics.
+func fillDerivedFields(album *models.BandcampAlbumData) {
+	if album.ArtistSlug == "" {
+		album.ArtistSlug = fetch.GenerateSlug(album.ArtistName)
 	}
-
 	album.ReleaseDateDaysAgo = calculateReleaseDateDaysAgo(album.ReleaseDate)
-	album.TotalWords = totalWords
-	album.TotalCharacters = totalChars
-	album.TotalCharactersNoSpaces = totalCharsNoSpaces
-	album.TotalLines = totalLines
-	album.TotalVowelCount = totalVowels
-	album.TotalConsonantCount = totalConsonants
-	album.TotalUniqueWords = len(uniqueWords)
-	album.WordLengthDistribution = wordLengths
-	album.AverageWordsPerTrack = calculateAverage(totalWords, len(album.Tracks))
 }
 
 func calculateReleaseDateDaysAgo(releaseDate string) string {
@@ -365,22 +360,15 @@ func calculateReleaseDateDaysAgo(releaseDate string) string {
 	return fmt.Sprintf("%d days ago", int(days))
 }
 
-func calculateAverage(total, count int) int {
-	if count > 0 {
-		return total / count
-	}
-	return 0
-}
-
-func UpdateTrack(req models.UpdateTrackRequest) error {
-	cleanLyrics := strings.TrimSpace(req.Lyrics)
+func (s *Store) UpdateTrack(req models.UpdateTrackRequest) error {
+	cleanLyrics := strings.TrimSpace(words.NormalizeText(req.Lyrics))
 	if strings.HasPrefix(strings.ToLower(cleanLyrics), "lyrics") {
 		cleanLyrics = ""
 	}
 
 	log.Printf("Attempting to update track. Album ID: '%s', Track Name: '%s'", req.AlbumID, req.TrackName)
 
-	data, _, err := adminClient.From("tracks").
+	data, _, err := s.adminClient.From("tracks").
 		Select("*", "exact", false).
 		Eq("album_id", req.AlbumID).
 		Eq("name", req.TrackName).
@@ -405,9 +393,10 @@ func UpdateTrack(req models.UpdateTrackRequest) error {
 		"lyrics":        cleanLyrics,
 		"track_number":  req.TrackNumber,
 		"ignored_words": req.IgnoredWords,
+		"language":      words.DetectLanguage(cleanLyrics),
 	}
 
-	_, _, err = adminClient.From("tracks").
+	_, _, err = s.adminClient.From("tracks").
 		Update(updates, "tracks", "id").
 		Eq("album_id", req.AlbumID).
 		Eq("name", req.TrackName).
@@ -417,10 +406,11 @@ func UpdateTrack(req models.UpdateTrackRequest) error {
 		return fmt.Errorf("error updating track: %w", err)
 	}
 
+	s.refreshAnalytics(req.AlbumID)
 	return nil
 }
 
-func UpdateAlbum(req models.UpdateAlbumRequest) error {
+func (s *Store) UpdateAlbum(req models.UpdateAlbumRequest) error {
 	log.Printf("Updating album: %s", req.AlbumID)
 	log.Printf("Update data: %+v", req)
 
@@ -437,7 +427,7 @@ func UpdateAlbum(req models.UpdateAlbumRequest) error {
 		"enabled":            enabled,
 	}
 
-	_, _, err := adminClient.From("albums").
+	_, _, err := s.adminClient.From("albums").
 		Update(updates, "albums", "id").
 		Eq("id", req.AlbumID).
 		Execute()
@@ -446,7 +436,7 @@ func UpdateAlbum(req models.UpdateAlbumRequest) error {
 		return fmt.Errorf("error updating album: %w", err)
 	}
 
-	data, _, err := adminClient.From("albums").
+	data, _, err := s.adminClient.From("albums").
 		Select("*", "exact", false).
 		Eq("id", req.AlbumID).
 		Single().
@@ -465,8 +455,8 @@ func UpdateAlbum(req models.UpdateAlbumRequest) error {
 	return nil
 }
 
-func FetchAlbumNamesOnly() ([]models.BandcampAlbumData, error) {
-	query := publicClient.From("albums").
+func (s *Store) FetchAlbumNamesOnly() ([]models.BandcampAlbumData, error) {
+	query := s.publicClient.From("albums").
 		Select("id, artist_name, album_name", "exact", false).
 		Order("date_added", &postgrest.OrderOpts{
 			Ascending:  false,
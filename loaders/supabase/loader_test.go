@@ -0,0 +1,53 @@
+package loader
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadConfigFromFile(t *testing.T) {
+	t.Setenv("SUPABASE_URL", "")
+
+	path := filepath.Join(t.TempDir(), "auth.json")
+	content := `{"supabase_url": "https://example.supabase.co", "service_role_key": "service", "supabase_key": "anon"}`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("writing config: %v", err)
+	}
+
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error: %v", err)
+	}
+	if config.URL != "https://example.supabase.co" || config.ServiceKey != "service" || config.AnonKey != "anon" {
+		t.Errorf("LoadConfig() = %+v", config)
+	}
+}
+
+func TestLoadConfigMissingFile(t *testing.T) {
+	t.Setenv("SUPABASE_URL", "")
+
+	if _, err := LoadConfig(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("LoadConfig() with a missing file should return an error")
+	}
+}
+
+func TestLoadConfigFromEnv(t *testing.T) {
+	t.Setenv("SUPABASE_URL", "https://env.supabase.co")
+	t.Setenv("SUPABASE_SERVICE_KEY", "service")
+	t.Setenv("SUPABASE_ANON_KEY", "anon")
+
+	config, err := LoadConfig("does-not-matter.json")
+	if err != nil {
+		t.Fatalf("LoadConfig() error: %v", err)
+	}
+	if config.URL != "https://env.supabase.co" {
+		t.Errorf("config.URL = %q", config.URL)
+	}
+}
+
+func TestNewStoreRequiresURL(t *testing.T) {
+	if _, err := NewStore(SupabaseConfig{}); err == nil {
+		t.Error("NewStore() without a URL should return an error")
+	}
+}
@@ -0,0 +1,69 @@
+package loader
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"millions-of-words/models"
+
+	"github.com/supabase-community/postgrest-go"
+)
+
+// The profanity lexicon lives in a profanity_terms table with an identity id,
+// a unique word and a category.
+
+func (s *Store) LoadProfanityTerms() ([]models.ProfanityTerm, error) {
+	data, _, err := s.adminClient.From("profanity_terms").
+		Select("id, word, category", "exact", false).
+		Order("category", &postgrest.OrderOpts{Ascending: true}).
+		Order("word", &postgrest.OrderOpts{Ascending: true}).
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("error querying profanity terms: %w", err)
+	}
+
+	var terms []models.ProfanityTerm
+	if err := json.Unmarshal(data, &terms); err != nil {
+		return nil, fmt.Errorf("error scanning profanity terms: %w", err)
+	}
+
+	return terms, nil
+}
+
+func (s *Store) SaveProfanityTerm(term models.ProfanityTerm) error {
+	termData := map[string]interface{}{
+		"word":     term.Word,
+		"category": term.Category,
+	}
+
+	if term.ID == 0 {
+		_, _, err := s.adminClient.From("profanity_terms").
+			Upsert(termData, "word", "", "").
+			Execute()
+		if err != nil {
+			return fmt.Errorf("error inserting profanity term: %w", err)
+		}
+		return nil
+	}
+
+	_, _, err := s.adminClient.From("profanity_terms").
+		Update(termData, "", "").
+		Eq("id", strconv.FormatInt(term.ID, 10)).
+		Execute()
+	if err != nil {
+		return fmt.Errorf("error updating profanity term: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) DeleteProfanityTerm(id int64) error {
+	_, _, err := s.adminClient.From("profanity_terms").
+		Delete("", "").
+		Eq("id", strconv.FormatInt(id, 10)).
+		Execute()
+	if err != nil {
+		return fmt.Errorf("error deleting profanity term: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package loader
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/supabase-community/postgrest-go"
+)
+
+// Stopwords added by the admin live in a stopwords table whose primary key is
+// the word.
+
+func (s *Store) LoadStopwords() ([]string, error) {
+	data, _, err := s.adminClient.From("stopwords").
+		Select("word", "exact", false).
+		Order("word", &postgrest.OrderOpts{Ascending: true}).
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("error querying stopwords: %w", err)
+	}
+
+	var rows []struct {
+		Word string `json:"word"`
+	}
+	if err := json.Unmarshal(data, &rows); err != nil {
+		return nil, fmt.Errorf("error scanning stopwords: %w", err)
+	}
+
+	stopwords := make([]string, 0, len(rows))
+	for _, row := range rows {
+		stopwords = append(stopwords, row.Word)
+	}
+	return stopwords, nil
+}
+
+func (s *Store) SaveStopword(word string) error {
+	_, _, err := s.adminClient.From("stopwords").
+		Upsert(map[string]interface{}{"word": word}, "word", "", "").
+		Execute()
+	if err != nil {
+		return fmt.Errorf("error inserting stopword: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) DeleteStopword(word string) error {
+	_, _, err := s.adminClient.From("stopwords").
+		Delete("", "").
+		Eq("word", word).
+		Execute()
+	if err != nil {
+		return fmt.Errorf("error deleting stopword: %w", err)
+	}
+	return nil
+}
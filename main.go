@@ -8,18 +8,20 @@ import (
 	"log"
 	"math"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
-	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"millions-of-words/fetch"
 	"millions-of-words/internal/admin"
-	loader "millions-of-words/loaders/supabase"
+	"millions-of-words/loaders"
 	"millions-of-words/models"
 	"millions-of-words/words"
+	"millions-of-words/words/pos"
+	"millions-of-words/words/profanity"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
@@ -28,32 +30,35 @@ import (
 const (
 	defaultPort         = "8080"
 	defaultTemplatesDir = "./templates"
+	maxSearchResults    = 100
+	// maxPOSTextBytes caps the body of /pos/analyze. The longest album in
+	// the corpus is well under this.
+	maxPOSTextBytes = 256 << 10
 )
 
-var (
-	albums []models.BandcampAlbumData
-	// Add a cache for home page stats
-	homePageCache struct {
-		Albums             []models.BandcampAlbumData
-		TotalAlbums        int
-		TotalSongs         int
-		TotalWords         int
-		TotalChars         int
-		TotalCharsNoSpaces int
-		TotalVowels        int
-		TotalConsonants    int
-		TotalLines         int
-		TotalDuration      int
-		AvgWordsPerAlbum   int
-		AvgCharsPerAlbum   int
-		AvgWordLength      float64
-		AvgSongsPerAlbum   float64
-		WPM                int
-		ProjectedAlbums    float64
-		FuckCount          int
-		DisplayAlbums      []models.BandcampAlbumData
-	}
-)
+var store loaders.Store
+
+// homePageStats are the corpus-wide numbers on the home page.
+type homePageStats struct {
+	TotalAlbums        int
+	TotalSongs         int
+	TotalWords         int
+	TotalChars         int
+	TotalCharsNoSpaces int
+	TotalVowels        int
+	TotalConsonants    int
+	TotalLines         int
+	TotalDuration      int
+	AvgWordsPerAlbum   int
+	AvgCharsPerAlbum   int
+	AvgWordLength      float64
+	AvgSongsPerAlbum   float64
+	WPM                int
+	ProjectedAlbums    float64
+	Profanity          models.ProfanityCounts
+	Moods              moodRankings
+	DisplayAlbums      []models.BandcampAlbumData
+}
 
 type TemplateRenderer struct {
 	templates *template.Template
@@ -75,14 +80,28 @@ func main() {
 	renderer := &TemplateRenderer{templates: templates}
 	e.Renderer = renderer
 
-	if err := loadAlbums(); err != nil {
-		e.Logger.Fatal(err)
+	baseStore, err := loaders.New(loaders.Config{
+		Backend:            getEnv("STORE_BACKEND", loaders.BackendSupabase),
+		SupabaseConfigPath: getEnv("SUPABASE_CONFIG", "auth.json"),
+		SQLitePath:         getEnv("SQLITE_PATH", ""),
+		AdminEmail:         getEnv("ADMIN_EMAIL", ""),
+		AdminPassword:      getEnv("ADMIN_PASSWORD", ""),
+	})
+	if err != nil {
+		log.Fatalf("Error creating album store: %v", err)
 	}
-	if err := refreshHomePageCache(); err != nil {
+	store = indexedStore{Store: baseStore}
+
+	loadProfanityLexicon()
+	loadStopwords()
+	if err := loadAlbums(); err != nil {
 		e.Logger.Fatal(err)
 	}
+	loadArtists()
+	go recomputeAnalytics()
 
 	setupRoutes(e)
+	setupAPIRoutes(e)
 	setupAdminRoutes(e, renderer)
 
 	port := getEnv("PORT", defaultPort)
@@ -134,31 +153,89 @@ func getEnv(key, fallback string) string {
 	return fallback
 }
 
+// loadAlbums does a full reload from the store and rebuilds the lyrics index.
+// Later writes through indexedStore update both one album at a time. It holds
+// stateMu while reading the store, so a write that lands during the reload is
+// applied on top of it rather than lost.
 func loadAlbums() error {
-	var err error
-	albums, err = loader.LoadAlbumsData()
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	loaded, err := store.LoadAlbumsData()
 	if err != nil {
 		return fmt.Errorf("failed to load album data: %w", err)
 	}
+	replaceAlbums(loaded)
 	return nil
 }
 
+// recomputeAnalytics brings the stored analytics up to the current
+// analytics.Version, in the background so the site starts serving the older
+// numbers straight away. Once any album is recomputed the albums are reloaded
+// to pick the new numbers up.
+func recomputeAnalytics() {
+	updated, err := store.RecomputeAnalytics()
+	if err != nil {
+		log.Printf("Error recomputing analytics: %v", err)
+		return
+	}
+	if updated == 0 {
+		return
+	}
+	log.Printf("Recomputed analytics of %d albums", updated)
+
+	if err := loadAlbums(); err != nil {
+		log.Printf("Error reloading albums after recomputing analytics: %v", err)
+	}
+}
+
+// loadProfanityLexicon reads the profanity lexicon from the store, seeding it
+// with the built-in terms when it is empty. If the store cannot be read the
+// built-in terms are used, so the site still starts.
+func loadProfanityLexicon() {
+	terms, err := store.LoadProfanityTerms()
+	if err != nil {
+		log.Printf("Error loading profanity terms, using built-in list: %v", err)
+		setProfanityLexicon(profanity.NewLexicon(profanity.DefaultTerms))
+		return
+	}
+
+	if len(terms) == 0 {
+		log.Printf("Profanity lexicon is empty, seeding %d built-in terms", len(profanity.DefaultTerms))
+		for _, term := range profanity.DefaultTerms {
+			if err := store.SaveProfanityTerm(term); err != nil {
+				log.Printf("Error seeding profanity term %q: %v", term.Word, err)
+			}
+		}
+		terms = profanity.DefaultTerms
+	}
+
+	setProfanityLexicon(profanity.NewLexicon(terms))
+}
+
 func setupRoutes(e *echo.Echo) {
 	e.GET("/", indexHandler)
 
 	e.GET("/pos", partsOfSpeechHandler)
+	e.POST("/pos/analyze", analyzePartsOfSpeechHandler)
 
 	e.GET("/about", aboutHandler)
 	e.GET("/all-words", allWordsHandler)
+	e.GET("/phrases", phrasesHandler)
+	e.GET("/compare", compareHandler)
+	e.GET("/timeline", timelineHandler)
+	e.GET("/word/:word", wordHandler)
+	e.GET("/search", searchHandler)
 	e.GET("/all-albums", allAlbumsHandler)
 	e.GET("/album/:slug", albumDetailsHandler)
+	e.GET("/artist/:slug", artistHandler)
 	e.GET("/search-albums", searchAlbumsHandler)
 	e.GET("/all-albums/sort", sortAlbumsHandler)
 	e.GET("/all-albums/filter", filterAlbumsHandler)
 }
 
 func setupAdminRoutes(e *echo.Echo, renderer *TemplateRenderer) {
-	adminHandler := admin.NewHandler(renderer)
+	adminHandler := admin.NewHandler(renderer, store)
 	admin.SetupRoutes(e, adminHandler)
 }
 
@@ -170,23 +247,11 @@ func aboutHandler(c echo.Context) error {
 	return c.Render(http.StatusOK, "about.html", nil)
 }
 
-func countWordOccurrences(albums []models.BandcampAlbumData, word string) int {
-	count := 0
-	word = strings.ToLower(word)
-	for _, album := range albums {
-		for _, track := range album.Tracks {
-			lyrics := strings.ToLower(track.Lyrics)
-			count += strings.Count(lyrics, word)
-		}
-	}
-	return count
-}
-
-func refreshHomePageCache() error {
-	allAlbums, err := loader.LoadAlbumsData()
-	if err != nil {
-		return err
-	}
+// homePageStats works out the home page numbers for the state. Album metrics
+// are already calculated by the store, so this never touches the lyrics
+// themselves.
+func (s *siteState) homePageStats() homePageStats {
+	allAlbums := s.albums
 
 	displayAlbums := allAlbums
 	if len(allAlbums) > 18 {
@@ -237,29 +302,9 @@ func refreshHomePageCache() error {
 		}
 	}
 
-	fuckCount := countWordOccurrences(allAlbums, "fuck")
-
-	homePageCache = struct {
-		Albums             []models.BandcampAlbumData
-		TotalAlbums        int
-		TotalSongs         int
-		TotalWords         int
-		TotalChars         int
-		TotalCharsNoSpaces int
-		TotalVowels        int
-		TotalConsonants    int
-		TotalLines         int
-		TotalDuration      int
-		AvgWordsPerAlbum   int
-		AvgCharsPerAlbum   int
-		AvgWordLength      float64
-		AvgSongsPerAlbum   float64
-		WPM                int
-		ProjectedAlbums    float64
-		FuckCount          int
-		DisplayAlbums      []models.BandcampAlbumData
-	}{
-		Albums:             allAlbums,
+	profanityCounts := s.lexicon.CountFrequencies(s.index.WordFrequencies())
+
+	return homePageStats{
 		TotalAlbums:        albumCount,
 		TotalSongs:         totalSongs,
 		TotalWords:         totalWords,
@@ -275,81 +320,277 @@ func refreshHomePageCache() error {
 		AvgSongsPerAlbum:   avgSongsPerAlbum,
 		WPM:                wpm,
 		ProjectedAlbums:    projectedAlbums,
-		FuckCount:          fuckCount,
+		Profanity:          profanityCounts,
+		Moods:              rankAlbumsByMood(allAlbums),
 		DisplayAlbums:      displayAlbums,
 	}
-	return nil
 }
 
 func indexHandler(c echo.Context) error {
+	homePage := currentState().homePage
 	return renderTemplate(c, "index.html", map[string]interface{}{
-		"albums":             homePageCache.DisplayAlbums,
-		"TotalAlbums":        homePageCache.TotalAlbums,
-		"TotalSongs":         homePageCache.TotalSongs,
-		"TotalWords":         homePageCache.TotalWords,
-		"TotalChars":         homePageCache.TotalChars,
-		"TotalCharsNoSpaces": homePageCache.TotalCharsNoSpaces,
-		"TotalVowels":        homePageCache.TotalVowels,
-		"TotalConsonants":    homePageCache.TotalConsonants,
-		"TotalLines":         homePageCache.TotalLines,
-		"TotalDuration":      homePageCache.TotalDuration,
-		"AvgWordsPerAlbum":   homePageCache.AvgWordsPerAlbum,
-		"AvgCharsPerAlbum":   homePageCache.AvgCharsPerAlbum,
-		"AvgWordLength":      homePageCache.AvgWordLength,
-		"AvgSongsPerAlbum":   homePageCache.AvgSongsPerAlbum,
-		"WPM":                homePageCache.WPM,
-		"ProjectedAlbums":    homePageCache.ProjectedAlbums,
-		"FuckCount":          homePageCache.FuckCount,
+		"albums":             homePage.DisplayAlbums,
+		"TotalAlbums":        homePage.TotalAlbums,
+		"TotalSongs":         homePage.TotalSongs,
+		"TotalWords":         homePage.TotalWords,
+		"TotalChars":         homePage.TotalChars,
+		"TotalCharsNoSpaces": homePage.TotalCharsNoSpaces,
+		"TotalVowels":        homePage.TotalVowels,
+		"TotalConsonants":    homePage.TotalConsonants,
+		"TotalLines":         homePage.TotalLines,
+		"TotalDuration":      homePage.TotalDuration,
+		"AvgWordsPerAlbum":   homePage.AvgWordsPerAlbum,
+		"AvgCharsPerAlbum":   homePage.AvgCharsPerAlbum,
+		"AvgWordLength":      homePage.AvgWordLength,
+		"AvgSongsPerAlbum":   homePage.AvgSongsPerAlbum,
+		"WPM":                homePage.WPM,
+		"ProjectedAlbums":    homePage.ProjectedAlbums,
+		"Profanity":          homePage.Profanity,
+		"Moods":              homePage.Moods,
 	})
 }
 
 func albumDetailsHandler(c echo.Context) error {
 	slug := c.Param("slug")
 
-	album, err := loader.GetAlbumBySlug(slug)
+	album, err := store.GetAlbumBySlug(slug)
 	if err != nil {
 		log.Printf("Error loading album with slug %s: %v", slug, err)
 		return echo.NewHTTPError(http.StatusNotFound, "Album not found")
 	}
 
-	data := prepareAlbumDetails(album)
+	data := currentState().prepareAlbumDetails(album, parseFrequencyView(c))
 	return renderTemplate(c, "album-details.html", data)
 }
 
+func artistHandler(c echo.Context) error {
+	slug := c.Param("slug")
+	view := parseFrequencyView(c)
+
+	s := currentState()
+	profile, ok := s.prepareArtistProfile(slug, view)
+	if !ok {
+		return echo.NewHTTPError(http.StatusNotFound, "Artist not found")
+	}
+	artist := s.findArtist(slug)
+
+	return renderTemplate(c, "artist.html", map[string]interface{}{
+		"Title":          artist.Name + " - Millions of Words",
+		"Artist":         artist,
+		"Profile":        profile,
+		"FrequencyView":  view,
+		"FrequencyViews": frequencyViews,
+	})
+}
+
 func searchAlbumsHandler(c echo.Context) error {
 	searchQuery := c.QueryParam("search")
-	filteredAlbums := filterAlbumsByQuery(searchQuery)
+	filteredAlbums := currentState().filterAlbumsByQuery(searchQuery)
 	return c.Render(http.StatusOK, "album-grid.html", map[string]interface{}{
 		"albums": filteredAlbums,
 	})
 }
 
 func allWordsHandler(c echo.Context) error {
-	wordFrequencyMap := make(map[string]int)
-
-	for _, album := range albums {
-		for _, track := range album.Tracks {
-			wordCounts, _, _, _ := words.CalculateAndSortWordFrequencies(track.Lyrics, track.IgnoredWords)
-			for _, wc := range wordCounts {
-				wordFrequencyMap[wc.Word] += wc.Count
-			}
-		}
-	}
-
-	wordFrequencies := words.MapToSortedList(wordFrequencyMap)
+	view := parseFrequencyView(c)
+	language := parseLanguage(c)
+	s := currentState()
+	wordFrequencies := s.applyFrequencyView(s.languageWordFrequencies(language), view)
 	wordFrequenciesJSON, err := json.Marshal(wordFrequencies)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to marshal word frequencies")
 	}
 
-	return renderTemplate(c, "all-words.html", map[string]interface{}{
+	data := map[string]interface{}{
 		"wordFrequencies":     wordFrequencies,
 		"wordFrequenciesJSON": template.JS(wordFrequenciesJSON),
 		"Title":               "Word Frequencies - Millions of Words",
 		"IsAllWords":          true,
+		"FrequencyView":       view,
+		"FrequencyViews":      frequencyViews,
+		"Languages":           s.corpusLanguages(),
+		"Language":            language,
+	}
+	if language != "" {
+		data["LanguageName"] = words.LanguageName(language)
+		data["FrequencyViewQuery"] = template.URL("lang=" + url.QueryEscape(language) + "&")
+	}
+	return renderTemplate(c, "all-words.html", data)
+}
+
+const maxCorpusPhrases = 200
+
+func (s *siteState) corpusPhrases(n int) []models.NGram {
+	if cached, ok := s.phrases.Load(n); ok {
+		return cached.([]models.NGram)
+	}
+	phrases := words.CorpusNGrams(s.albums, n)
+	s.phrases.Store(n, phrases)
+	return phrases
+}
+
+// parsePhraseQuery reads the phrase length and ranking from the query string,
+// defaulting to two-word phrases ranked by log-likelihood.
+func parsePhraseQuery(c echo.Context) (int, string) {
+	n := 2
+	if c.QueryParam("n") == "3" {
+		n = 3
+	}
+
+	rank := c.QueryParam("rank")
+	switch rank {
+	case words.RankByCount, words.RankByPMI, words.RankByLogLikelihood:
+	default:
+		rank = words.RankByLogLikelihood
+	}
+	return n, rank
+}
+
+func phrasesHandler(c echo.Context) error {
+	n, rank := parsePhraseQuery(c)
+	phrases := words.RankNGrams(currentState().corpusPhrases(n), rank)
+	total := len(phrases)
+	if len(phrases) > maxCorpusPhrases {
+		phrases = phrases[:maxCorpusPhrases]
+	}
+
+	return renderTemplate(c, "phrases.html", map[string]interface{}{
+		"Title":   "Phrases - Millions of Words",
+		"N":       n,
+		"Rank":    rank,
+		"Phrases": phrases,
+		"Total":   total,
+	})
+}
+
+func compareHandler(c echo.Context) error {
+	slugs := parseCompareSlugs(c.QueryParam("albums"))
+	view := parseFrequencyView(c)
+	joined := strings.Join(slugs, ",")
+
+	data := map[string]interface{}{
+		"Title":              "Compare Albums - Millions of Words",
+		"Slugs":              joined,
+		"FrequencyView":      view,
+		"FrequencyViews":     frequencyViews,
+		"FrequencyViewQuery": template.URL("albums=" + url.QueryEscape(joined) + "&"),
+	}
+	if len(slugs) == 0 {
+		return renderTemplate(c, "compare.html", data)
+	}
+
+	comparison, err := currentState().compareAlbums(slugs, view)
+	if err != nil {
+		data["Error"] = err.Error()
+		return renderTemplate(c, "compare.html", data)
+	}
+
+	type dataset struct {
+		Label string    `json:"label"`
+		Data  []float64 `json:"data"`
+	}
+	overlay := struct {
+		Labels   []int     `json:"labels"`
+		Datasets []dataset `json:"datasets"`
+	}{Labels: comparison.WordLengths}
+	for _, compared := range comparison.Albums {
+		overlay.Datasets = append(overlay.Datasets, dataset{
+			Label: compared.Album.ArtistName + " - " + compared.Album.AlbumName,
+			Data:  compared.WordLengthShares,
+		})
+	}
+	overlayJSON, err := json.Marshal(overlay)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to marshal word length distributions")
+	}
+
+	data["Comparison"] = comparison
+	data["WordLengthJSON"] = template.JS(overlayJSON)
+	return renderTemplate(c, "compare.html", data)
+}
+
+const maxConcordanceContextLines = 3
+
+func enabledAlbums(albums []models.BandcampAlbumData) []models.BandcampAlbumData {
+	var enabled []models.BandcampAlbumData
+	for _, album := range albums {
+		if album.Enabled {
+			enabled = append(enabled, album)
+		}
+	}
+	return enabled
+}
+
+// albumsContaining uses the lyrics index to narrow the album list down to the
+// albums a word actually appears in.
+func (s *siteState) albumsContaining(word string) []models.BandcampAlbumData {
+	ids := make(map[string]bool)
+	for _, posting := range s.index.Postings(word) {
+		ids[posting.AlbumID] = true
+	}
+
+	var matching []models.BandcampAlbumData
+	for _, album := range enabledAlbums(s.albums) {
+		if ids[album.ID] {
+			matching = append(matching, album)
+		}
+	}
+	return matching
+}
+
+func concordanceContextLines(c echo.Context) int {
+	contextLines, err := strconv.Atoi(c.QueryParam("context"))
+	if err != nil || contextLines < 0 {
+		return 0
+	}
+	if contextLines > maxConcordanceContextLines {
+		return maxConcordanceContextLines
+	}
+	return contextLines
+}
+
+func wordHandler(c echo.Context) error {
+	contextLines := concordanceContextLines(c)
+	concordance := words.BuildConcordance(c.Param("word"), currentState().albumsContaining(c.Param("word")), contextLines)
+
+	contextOptions := make([]int, 0, maxConcordanceContextLines+1)
+	for n := 0; n <= maxConcordanceContextLines; n++ {
+		contextOptions = append(contextOptions, n)
+	}
+
+	return renderTemplate(c, "word.html", map[string]interface{}{
+		"Title":          "\"" + concordance.Word + "\" - Millions of Words",
+		"Concordance":    concordance,
+		"ContextLines":   contextLines,
+		"ContextOptions": contextOptions,
 	})
 }
 
+func searchHandler(c echo.Context) error {
+	query := strings.TrimSpace(c.QueryParam("q"))
+	data := map[string]interface{}{
+		"Title": "Search - Millions of Words",
+		"Query": query,
+	}
+	if query == "" {
+		return renderTemplate(c, "search.html", data)
+	}
+
+	results, err := currentState().index.Search(query)
+	if err != nil {
+		data["Error"] = err.Error()
+		return renderTemplate(c, "search.html", data)
+	}
+
+	data["Title"] = "\"" + query + "\" - Millions of Words"
+	data["Total"] = len(results)
+	if len(results) > maxSearchResults {
+		results = results[:maxSearchResults]
+	}
+	data["Results"] = results
+	return renderTemplate(c, "search.html", data)
+}
+
 func updateTrackHandler(c echo.Context) error {
 	var req models.UpdateTrackRequest
 	if err := c.Bind(&req); err != nil {
@@ -357,31 +598,19 @@ func updateTrackHandler(c echo.Context) error {
 		return c.HTML(http.StatusOK, `<div class="text-red-500">Error: Failed to process request</div>`)
 	}
 
-	if err := loader.UpdateTrack(req); err != nil {
+	if err := store.UpdateTrack(req); err != nil {
 		log.Printf("Error updating track: %v", err)
 		return c.HTML(http.StatusOK, `<div class="text-red-500">Error: Failed to update track</div>`)
 	}
 
-	if err := loadAlbums(); err != nil {
-		log.Printf("Error reloading albums: %v", err)
-		return c.HTML(http.StatusOK, `<div class="text-yellow-500">Saved but failed to refresh</div>`)
-	}
-
-	albumDetailsCache.Delete(req.AlbumID)
-
 	return c.HTML(http.StatusOK, `<div class="text-green-500">Updated successfully</div>`)
 }
 
 func allAlbumsHandler(c echo.Context) error {
-	albums, err := loader.LoadAlbumsData()
-	if err != nil {
-		return err
-	}
-
 	return c.Render(http.StatusOK, "all-albums.html", map[string]interface{}{
 		"Title":       "All Albums - Millions of Words",
 		"IsAllAlbums": true,
-		"Albums":      albums,
+		"Albums":      currentState().table.Sorted("date_added", "desc"),
 		"Sort":        "date_added",
 		"SortDir":     "desc",
 		"NextSortDir": "asc",
@@ -389,26 +618,16 @@ func allAlbumsHandler(c echo.Context) error {
 }
 
 func sortAlbumsHandler(c echo.Context) error {
-	log.Printf("Sorting albums...")
-	allAlbums, err := loader.LoadAlbumsData()
-	if err != nil {
-		log.Printf("Error loading albums: %v", err)
-		return err
-	}
-
 	sort := c.QueryParam("sort")
 	dir := c.QueryParam("dir")
-	log.Printf("Sort: %s, Dir: %s", sort, dir)
 
 	nextSortDir := "asc"
 	if dir == "asc" {
 		nextSortDir = "desc"
 	}
 
-	sortAlbums(allAlbums, sort, dir)
-
 	return c.Render(http.StatusOK, "album-table.html", map[string]interface{}{
-		"Albums":      allAlbums,
+		"Albums":      currentState().table.Sorted(sort, dir),
 		"Sort":        sort,
 		"SortDir":     dir,
 		"NextSortDir": nextSortDir,
@@ -416,110 +635,16 @@ func sortAlbumsHandler(c echo.Context) error {
 }
 
 func filterAlbumsHandler(c echo.Context) error {
-	allAlbums, err := loader.LoadAlbumsData()
-	if err != nil {
-		return err
-	}
-
-	search := c.QueryParam("search")
-	filtered := filterAlbums(allAlbums, search)
-
 	return c.Render(http.StatusOK, "album-table.html", map[string]interface{}{
-		"Albums":      filtered,
+		"Albums":      currentState().table.Filter(c.QueryParam("search")),
 		"Sort":        "date_added",
 		"SortDir":     "desc",
 		"NextSortDir": "asc",
 	})
 }
 
-type AlbumTableData struct {
-	Albums      []models.BandcampAlbumData
-	Sort        string
-	SortDir     string
-	NextSortDir string
-}
-
-func renderAlbumsTable(c echo.Context, sortField, sortDir, search string) error {
-	log.Printf("renderAlbumsTable: sortField=%s, sortDir=%s, search=%s", sortField, sortDir, search)
-
-	allAlbums, err := loader.LoadAllAlbumsData()
-	if err != nil {
-		log.Printf("Error loading albums: %v", err)
-		return err
-	}
-	log.Printf("Loaded %d albums total", len(allAlbums))
-
-	nextSortDir := "asc"
-	if sortDir == "asc" {
-		nextSortDir = "desc"
-	}
-
-	filtered := filterAlbums(allAlbums, search)
-	log.Printf("After filtering: %d albums", len(filtered))
-
-	sortAlbums(filtered, sortField, sortDir)
-	log.Printf("After sorting by %s %s", sortField, sortDir)
-
-	data := AlbumTableData{
-		Albums:      filtered,
-		Sort:        sortField,
-		SortDir:     sortDir,
-		NextSortDir: nextSortDir,
-	}
-
-	return c.Render(http.StatusOK, "album-table.html", data)
-}
-
-func filterAlbums(albums []models.BandcampAlbumData, search string) []models.BandcampAlbumData {
-	if search == "" {
-		log.Printf("No search term, returning all %d albums", len(albums))
-		return albums
-	}
-
-	search = strings.ToLower(search)
-	var filtered []models.BandcampAlbumData
-
-	for _, album := range albums {
-		if strings.Contains(strings.ToLower(album.ArtistName), search) ||
-			strings.Contains(strings.ToLower(album.AlbumName), search) {
-			filtered = append(filtered, album)
-		}
-	}
-
-	log.Printf("Filtered albums by '%s': %d results", search, len(filtered))
-	return filtered
-}
-
-func sortAlbums(albums []models.BandcampAlbumData, sortField, sortDir string) {
-	sort.Slice(albums, func(i, j int) bool {
-		var result bool
-
-		switch sortField {
-		case "name":
-			nameI := strings.ToLower(albums[i].ArtistName + " " + albums[i].AlbumName)
-			nameJ := strings.ToLower(albums[j].ArtistName + " " + albums[j].AlbumName)
-			result = nameI < nameJ
-		case "words":
-			result = albums[i].TotalWords < albums[j].TotalWords
-		case "unique":
-			result = albums[i].TotalUniqueWords < albums[j].TotalUniqueWords
-		case "length":
-			result = albums[i].TotalLength < albums[j].TotalLength
-		case "wpt":
-			result = albums[i].AverageWordsPerTrack < albums[j].AverageWordsPerTrack
-		default:
-			result = albums[i].DateAdded < albums[j].DateAdded
-		}
-
-		if sortDir == "desc" {
-			result = !result
-		}
-		return result
-	})
-}
-
 func importAlbumHandler(c echo.Context) error {
-	user := c.Get("user").(*loader.User)
+	user := c.Get("user").(*models.User)
 	if user == nil {
 		return c.HTML(http.StatusUnauthorized, `
 			<div class="bg-red-500/10 border border-red-500 text-red-500 p-4 rounded">
@@ -550,7 +675,7 @@ func importAlbumHandler(c echo.Context) error {
 			continue
 		}
 
-		exists, err := loader.AlbumUrlExists(url)
+		exists, err := store.AlbumUrlExists(url)
 		if err != nil {
 			results = append(results, fmt.Sprintf(`
 				<div class="bg-red-500/10 border border-red-500 text-red-500 p-2 rounded text-sm">
@@ -576,7 +701,7 @@ func importAlbumHandler(c echo.Context) error {
 			continue
 		}
 
-		if err := loader.SaveAlbum(albumData); err != nil {
+		if err := store.SaveAlbum(albumData); err != nil {
 			results = append(results, fmt.Sprintf(`
 				<div class="bg-red-500/10 border border-red-500 text-red-500 p-2 rounded text-sm">
 					Error saving %s: %v
@@ -605,13 +730,6 @@ func importAlbumHandler(c echo.Context) error {
 		c.Response().Flush()
 	}
 
-	if err := loadAlbums(); err != nil {
-		log.Printf("Error reloading albums after import: %v", err)
-	}
-	if err := refreshHomePageCache(); err != nil {
-		log.Printf("Error refreshing home page cache after import: %v", err)
-	}
-
 	log.Printf("Import complete")
 	return c.HTML(http.StatusOK, strings.Join(results, "\n"))
 }
@@ -630,16 +748,11 @@ func updateAlbumHandler(c echo.Context) error {
 		return c.HTML(http.StatusOK, `<div class="text-red-500">Error: Failed to process request</div>`)
 	}
 
-	if err := loader.UpdateAlbum(req); err != nil {
+	if err := store.UpdateAlbum(req); err != nil {
 		log.Printf("Error updating album: %v", err)
 		return c.HTML(http.StatusOK, `<div class="text-red-500">Error: Failed to update album</div>`)
 	}
 
-	if err := loadAlbums(); err != nil {
-		log.Printf("Error reloading albums: %v", err)
-		return c.HTML(http.StatusOK, `<div class="text-yellow-500">Saved but failed to refresh</div>`)
-	}
-
 	return c.HTML(http.StatusOK, `<div class="text-green-500">Album updated successfully</div>`)
 }
 
@@ -650,6 +763,16 @@ func partsOfSpeechHandler(c echo.Context) error {
 	})
 }
 
+func analyzePartsOfSpeechHandler(c echo.Context) error {
+	req := c.Request()
+	req.Body = http.MaxBytesReader(c.Response(), req.Body, maxPOSTextBytes)
+	if err := req.ParseForm(); err != nil {
+		return echo.NewHTTPError(http.StatusRequestEntityTooLarge, "Text is too long to analyze")
+	}
+	text := c.FormValue("text")
+	return c.JSON(http.StatusOK, pos.Analyze(text, c.FormValue("ignoredWords")))
+}
+
 func adminAuthMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		session, err := c.Cookie("session")
@@ -657,8 +780,8 @@ func adminAuthMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 			return c.Redirect(http.StatusSeeOther, "/admin/login")
 		}
 
-		// Validate session with Supabase
-		user, err := loader.ValidateSession(session.Value)
+		// Validate session with the store backend
+		user, err := store.ValidateSession(session.Value)
 		if err != nil {
 			c.SetCookie(&http.Cookie{
 				Name:     "session",
@@ -681,7 +804,7 @@ func handleAdminAuth(c echo.Context) error {
 	email := c.FormValue("email")
 	password := c.FormValue("password")
 
-	user, err := loader.SignInWithEmail(email, password)
+	user, err := store.SignInWithEmail(email, password)
 	if err != nil {
 		return c.HTML(http.StatusUnauthorized, `<div class="text-red-500">Invalid credentials</div>`)
 	}
@@ -2,10 +2,15 @@ package main
 
 import (
 	"io"
+	"log"
+	"millions-of-words/loaders"
 	"millions-of-words/models"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/labstack/echo/v4"
@@ -19,6 +24,39 @@ func (m *MockRenderer) Render(w io.Writer, name string, data interface{}, c echo
 	return err
 }
 
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "millions-of-words-test")
+	if err != nil {
+		log.Fatalf("Error creating temp dir: %v", err)
+	}
+
+	store, err = loaders.New(loaders.Config{
+		Backend:    loaders.BackendSQLite,
+		SQLitePath: filepath.Join(dir, "albums.db"),
+	})
+	if err != nil {
+		log.Fatalf("Error creating test store: %v", err)
+	}
+
+	err = store.SaveAlbum(models.BandcampAlbumData{
+		ID:         "1",
+		Slug:       "artist1-album1",
+		ArtistName: "Artist1",
+		AlbumName:  "Album1",
+		Enabled:    true,
+		Tracks: []models.BandcampTrackData{
+			{Name: "Track1", TrackNumber: 1, Lyrics: "test word test"},
+		},
+	})
+	if err != nil {
+		log.Fatalf("Error seeding test store: %v", err)
+	}
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
 func TestGetEnv(t *testing.T) {
 	os.Setenv("TEST_VAR", "test_value")
 	assert.Equal(t, "test_value", getEnv("TEST_VAR", "default_value"))
@@ -31,10 +69,10 @@ func TestIndexHandler(t *testing.T) {
 	rec := httptest.NewRecorder()
 	c := e.NewContext(req, rec)
 
-	albums = []models.BandcampAlbumData{
+	replaceAlbums([]models.BandcampAlbumData{
 		{ID: "1", ArtistName: "Artist1", AlbumName: "Album1"},
 		{ID: "2", ArtistName: "Artist2", AlbumName: "Album2"},
-	}
+	})
 
 	e.Renderer = &MockRenderer{}
 
@@ -50,10 +88,10 @@ func TestSearchAlbumsHandler(t *testing.T) {
 	rec := httptest.NewRecorder()
 	c := e.NewContext(req, rec)
 
-	albums = []models.BandcampAlbumData{
+	replaceAlbums([]models.BandcampAlbumData{
 		{ID: "1", ArtistName: "Artist1", AlbumName: "Album1"},
 		{ID: "2", ArtistName: "Artist2", AlbumName: "Album2"},
-	}
+	})
 
 	e.Renderer = &MockRenderer{}
 
@@ -68,14 +106,14 @@ func TestAlbumDetailsHandler(t *testing.T) {
 	req := httptest.NewRequest(http.MethodGet, "/", nil)
 	rec := httptest.NewRecorder()
 	c := e.NewContext(req, rec)
-	c.SetPath("/album/:id")
-	c.SetParamNames("id")
-	c.SetParamValues("1")
+	c.SetPath("/album/:slug")
+	c.SetParamNames("slug")
+	c.SetParamValues("artist1-album1")
 
-	albums = []models.BandcampAlbumData{
+	replaceAlbums([]models.BandcampAlbumData{
 		{ID: "1", ArtistName: "Artist1", AlbumName: "Album1"},
 		{ID: "2", ArtistName: "Artist2", AlbumName: "Album2"},
-	}
+	})
 
 	e.Renderer = &MockRenderer{}
 
@@ -91,14 +129,14 @@ func TestAllWordsHandler(t *testing.T) {
 	rec := httptest.NewRecorder()
 	c := e.NewContext(req, rec)
 
-	albums = []models.BandcampAlbumData{
+	replaceAlbums([]models.BandcampAlbumData{
 		{
 			ID: "1",
 			Tracks: []models.BandcampTrackData{
 				{Lyrics: "test word test"},
 			},
 		},
-	}
+	})
 
 	e.Renderer = &MockRenderer{}
 
@@ -109,11 +147,11 @@ func TestAllWordsHandler(t *testing.T) {
 }
 
 func TestFilterAlbumsByQuery(t *testing.T) {
-	albums = []models.BandcampAlbumData{
-		{ID: "1", ArtistName: "Artist1", AlbumName: "Album1"},
-		{ID: "2", ArtistName: "Artist2", AlbumName: "Album2"},
-		{ID: "3", ArtistName: "Artist3", AlbumName: "TestAlbum"},
-	}
+	replaceAlbums([]models.BandcampAlbumData{
+		{ID: "1", ArtistName: "Artist1", AlbumName: "Album1", Enabled: true},
+		{ID: "2", ArtistName: "Artist2", AlbumName: "Album2", Enabled: true},
+		{ID: "3", ArtistName: "Artist3", AlbumName: "TestAlbum", Enabled: true},
+	})
 
 	testCases := []struct {
 		query    string
@@ -127,8 +165,297 @@ func TestFilterAlbumsByQuery(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.query, func(t *testing.T) {
-			filtered := filterAlbumsByQuery(tc.query)
+			filtered := currentState().filterAlbumsByQuery(tc.query)
 			assert.Equal(t, tc.expected, len(filtered))
 		})
 	}
 }
+
+func TestWordHandler(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/word/test?context=1", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("/word/:word")
+	c.SetParamNames("word")
+	c.SetParamValues("test")
+
+	replaceAlbums([]models.BandcampAlbumData{
+		{ID: "1", Slug: "a", Enabled: true, Tracks: []models.BandcampTrackData{{Lyrics: "test word test"}}},
+		{ID: "2", Slug: "b", Enabled: false, Tracks: []models.BandcampTrackData{{Lyrics: "test"}}},
+	})
+
+	e.Renderer = &MockRenderer{}
+
+	if assert.NoError(t, wordHandler(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "word.html", rec.Body.String())
+	}
+}
+
+func TestSearchHandler(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/search?q=test", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	replaceAlbums([]models.BandcampAlbumData{
+		{ID: "1", Slug: "a", Enabled: true, Tracks: []models.BandcampTrackData{{Name: "Song", Lyrics: "test word test"}}},
+	})
+
+	e.Renderer = &MockRenderer{}
+
+	if assert.NoError(t, searchHandler(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "search.html", rec.Body.String())
+	}
+}
+
+func TestPhrasesHandler(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/phrases?n=3&rank=pmi", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	replaceAlbums([]models.BandcampAlbumData{
+		{ID: "1", Enabled: true, Tracks: []models.BandcampTrackData{{Lyrics: "into the dark\ninto the dark"}}},
+	})
+
+	e.Renderer = &MockRenderer{}
+
+	if assert.NoError(t, phrasesHandler(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "phrases.html", rec.Body.String())
+	}
+	if cached, ok := currentState().phrases.Load(3); assert.True(t, ok) {
+		assert.Equal(t, "into the dark", cached.([]models.NGram)[0].Phrase)
+	}
+}
+
+func TestCompareHandler(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/compare?albums=a,b", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	replaceAlbums([]models.BandcampAlbumData{
+		{ID: "1", Slug: "a", Enabled: true, Tracks: []models.BandcampTrackData{{Lyrics: "fire"}}},
+		{ID: "2", Slug: "b", Enabled: true, Tracks: []models.BandcampTrackData{{Lyrics: "ice"}}},
+	})
+
+	e.Renderer = &MockRenderer{}
+
+	if assert.NoError(t, compareHandler(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "compare.html", rec.Body.String())
+	}
+}
+
+func TestCompareAlbums(t *testing.T) {
+	replaceAlbums([]models.BandcampAlbumData{
+		{ID: "1", Slug: "fire", Enabled: true, TotalWords: 6, TotalLength: 120, WordLengthDistribution: map[int]int{3: 2, 4: 2},
+			Tracks: []models.BandcampTrackData{{Lyrics: "the fire and the ice"}}},
+		{ID: "2", Slug: "stone", Enabled: true, WordLengthDistribution: map[int]int{5: 1, 3: 1},
+			Tracks: []models.BandcampTrackData{{Lyrics: "the stone"}}},
+	})
+
+	comparison, err := currentState().compareAlbums(parseCompareSlugs(" fire, stone,fire,"), viewRaw)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Len(t, comparison.Albums, 2)
+	assert.Equal(t, 3.0, comparison.Albums[0].WPM)
+	assert.Equal(t, []models.WordCount{{Word: "the", Count: 3}}, comparison.Shared)
+	assert.Equal(t, []models.WordCount{{Word: "and", Count: 1}, {Word: "fire", Count: 1}, {Word: "ice", Count: 1}}, comparison.Albums[0].Exclusive)
+	assert.Equal(t, []models.WordCount{{Word: "stone", Count: 1}}, comparison.Albums[1].Exclusive)
+	assert.Equal(t, []int{3, 4, 5}, comparison.WordLengths)
+	assert.Equal(t, []float64{50, 50, 0}, comparison.Albums[0].WordLengthShares)
+	assert.Equal(t, []float64{50, 0, 50}, comparison.Albums[1].WordLengthShares)
+
+	filtered, err := currentState().compareAlbums([]string{"fire", "stone"}, viewFiltered)
+	if assert.NoError(t, err) {
+		assert.Empty(t, filtered.Shared)
+	}
+
+	_, err = currentState().compareAlbums([]string{"fire"}, viewRaw)
+	assert.Error(t, err)
+	_, err = currentState().compareAlbums([]string{"fire", "missing"}, viewRaw)
+	assert.EqualError(t, err, "album not found: missing")
+}
+
+func TestArtistHandler(t *testing.T) {
+	e := echo.New()
+	e.Renderer = &MockRenderer{}
+	replaceAlbums([]models.BandcampAlbumData{
+		{ID: "1", Slug: "a", ArtistSlug: "artist", ArtistName: "Artist", Enabled: true},
+	})
+
+	rec := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/artist/artist", nil), rec)
+	c.SetParamNames("slug")
+	c.SetParamValues("artist")
+	if assert.NoError(t, artistHandler(c)) {
+		assert.Equal(t, "artist.html", rec.Body.String())
+	}
+
+	c = e.NewContext(httptest.NewRequest(http.MethodGet, "/artist/nobody", nil), httptest.NewRecorder())
+	c.SetParamNames("slug")
+	c.SetParamValues("nobody")
+	err := artistHandler(c)
+	if assert.Error(t, err) {
+		assert.Equal(t, http.StatusNotFound, err.(*echo.HTTPError).Code)
+	}
+}
+
+func TestPrepareArtistProfile(t *testing.T) {
+	replaceAlbums([]models.BandcampAlbumData{
+		{ID: "3", Slug: "undated", ArtistSlug: "artist", Enabled: true, TotalWords: 1, TotalLength: 60,
+			Tracks: []models.BandcampTrackData{{Lyrics: "fire"}}},
+		{ID: "2", Slug: "second", ArtistSlug: "artist", ReleaseDate: "2001-05-01", Enabled: true, TotalWords: 2, TotalLength: 60,
+			Tracks: []models.BandcampTrackData{{Lyrics: "fire ice"}}},
+		{ID: "1", Slug: "first", ArtistSlug: "artist", ReleaseDate: "1999-01-01", Enabled: true, TotalWords: 3, TotalLength: 60,
+			Tracks: []models.BandcampTrackData{{Lyrics: "fire fire stone"}}},
+		{ID: "4", Slug: "other", ArtistSlug: "someone-else", Enabled: true,
+			Tracks: []models.BandcampTrackData{{Lyrics: "water"}}},
+	})
+
+	profile, ok := currentState().prepareArtistProfile("artist", viewRaw)
+	if !assert.True(t, ok) {
+		return
+	}
+	var order []string
+	for _, a := range profile.Albums {
+		order = append(order, a.Album.Slug)
+	}
+	assert.Equal(t, []string{"first", "second", "undated"}, order)
+	assert.Equal(t, "1999", profile.Albums[0].Year())
+	assert.Equal(t, "", profile.Albums[2].Year())
+	assert.Equal(t, 6, profile.TotalWords)
+	assert.Equal(t, 3, profile.UniqueWords)
+	assert.Equal(t, 2.0, profile.WPM)
+	assert.Equal(t, models.WordCount{Word: "fire", Count: 4}, profile.TopWords[0])
+}
+
+func TestLoadArtistsSeedsFromAlbums(t *testing.T) {
+	replaceAlbums([]models.BandcampAlbumData{
+		{ID: "1", ArtistSlug: "seeded-artist", ArtistName: "Seeded Artist", Country: "Norway", Enabled: true},
+		{ID: "2", ArtistSlug: "seeded-artist", ArtistName: "Seeded Artist", Genre: "Black Metal", Enabled: true},
+	})
+
+	loadArtists()
+
+	artist, err := store.GetArtistBySlug("seeded-artist")
+	if assert.NoError(t, err) {
+		assert.Equal(t, models.Artist{Slug: "seeded-artist", Name: "Seeded Artist", Country: "Norway", Genre: "Black Metal"}, artist)
+	}
+}
+
+func TestTimelineHandler(t *testing.T) {
+	e := echo.New()
+	e.Renderer = &MockRenderer{}
+	replaceAlbums([]models.BandcampAlbumData{
+		{ID: "1", Slug: "a", ArtistSlug: "artist", ReleaseDate: "2001-01-01", Enabled: true,
+			Tracks: []models.BandcampTrackData{{Lyrics: "fire"}}},
+	})
+
+	rec := httptest.NewRecorder()
+	if assert.NoError(t, timelineHandler(e.NewContext(httptest.NewRequest(http.MethodGet, "/timeline", nil), rec))) {
+		assert.Equal(t, "timeline.html", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	if assert.NoError(t, timelineHandler(e.NewContext(httptest.NewRequest(http.MethodGet, "/timeline?artist=artist", nil), rec))) {
+		assert.Equal(t, "timeline.html", rec.Body.String())
+	}
+
+	err := timelineHandler(e.NewContext(httptest.NewRequest(http.MethodGet, "/timeline?artist=nobody", nil), httptest.NewRecorder()))
+	if assert.Error(t, err) {
+		assert.Equal(t, http.StatusNotFound, err.(*echo.HTTPError).Code)
+	}
+}
+
+func TestRankAlbumsByMood(t *testing.T) {
+	testAlbums := []models.BandcampAlbumData{
+		{ID: "bright", Sentiment: models.Sentiment{Words: 200, Score: 4, Emotions: models.Emotions{Joy: 8, Fear: 2}}},
+		{ID: "dark", Sentiment: models.Sentiment{Words: 300, Score: -6, Emotions: models.Emotions{Fear: 9, Joy: 1}}},
+		{ID: "grey", Sentiment: models.Sentiment{Words: 150, Score: -1, Emotions: models.Emotions{Anger: 1}}},
+		{ID: "short", Sentiment: models.Sentiment{Words: 20, Score: 50, Emotions: models.Emotions{Joy: 2}}},
+	}
+
+	moods := rankAlbumsByMood(testAlbums)
+
+	ids := func(albums []models.BandcampAlbumData) []string {
+		var result []string
+		for _, album := range albums {
+			result = append(result, album.ID)
+		}
+		return result
+	}
+	assert.Equal(t, []string{"bright", "grey", "dark"}, ids(moods.MostPositive))
+	assert.Equal(t, []string{"dark", "grey", "bright"}, ids(moods.MostNegative))
+
+	leaders := map[string]string{}
+	for _, leader := range moods.EmotionLeaders {
+		leaders[leader.Emotion] = leader.Album.ID
+	}
+	assert.Equal(t, map[string]string{"anger": "grey", "fear": "dark", "joy": "bright"}, leaders)
+}
+
+func TestAnalyzePartsOfSpeechHandlerLimitsBody(t *testing.T) {
+	e := echo.New()
+	analyze := func(text string) (*httptest.ResponseRecorder, error) {
+		body := url.Values{"text": {text}}.Encode()
+		req := httptest.NewRequest(http.MethodPost, "/pos/analyze", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+		rec := httptest.NewRecorder()
+		return rec, analyzePartsOfSpeechHandler(e.NewContext(req, rec))
+	}
+
+	rec, err := analyze("the wind blows")
+	if assert.NoError(t, err) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+	}
+
+	_, err = analyze(strings.Repeat("wind ", maxPOSTextBytes/4))
+	var httpErr *echo.HTTPError
+	if assert.ErrorAs(t, err, &httpErr) {
+		assert.Equal(t, http.StatusRequestEntityTooLarge, httpErr.Code)
+	}
+}
+
+func TestAlbumDetailsEscapesLyrics(t *testing.T) {
+	templates, err := parseTemplates("./templates")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	shared := models.BandcampAlbumData{
+		ID:         "xss",
+		Slug:       "artist1-xss",
+		ArtistName: "Artist1",
+		AlbumName:  "XSS",
+		Enabled:    true,
+		Tracks: []models.BandcampTrackData{
+			{Name: "Track1", TrackNumber: 1, Lyrics: "&lt;script&gt;alert(1)&lt;/script&gt;"},
+		},
+	}
+	replaceAlbums([]models.BandcampAlbumData{shared})
+	assert.NoError(t, store.SaveAlbum(shared))
+
+	e := echo.New()
+	e.Renderer = &TemplateRenderer{templates: templates}
+	rec := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+	c.SetPath("/album/:slug")
+	c.SetParamNames("slug")
+	c.SetParamValues("artist1-xss")
+
+	if assert.NoError(t, albumDetailsHandler(c)) {
+		assert.NotContains(t, rec.Body.String(), "<script>alert(1)")
+		assert.Contains(t, rec.Body.String(), "&lt;script&gt;alert(1)&lt;/script&gt;")
+	}
+
+	s := currentState()
+	s.prepareAlbumDetails(s.albums[0], viewFiltered)
+	assert.Equal(t, shared.Tracks[0].Lyrics, s.albums[0].Tracks[0].Lyrics, "the album list was changed")
+}
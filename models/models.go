@@ -11,6 +11,7 @@ type BandcampAlbumData struct {
 	ID                      string              `json:"id"`
 	Slug                    string              `json:"slug"`
 	ArtistName              string              `json:"artist_name"`
+	ArtistSlug              string              `json:"artist_slug"`
 	AlbumName               string              `json:"album_name"`
 	ImageUrl                string              `json:"image_url"`
 	ImageStoragePath        string              `json:"image_storage_path"`
@@ -41,9 +42,23 @@ type BandcampAlbumData struct {
 	TotalCharacters         int                 `json:"-"`
 	TotalCharactersNoSpaces int                 `json:"-"`
 	TotalLines              int                 `json:"-"`
+	Richness                LexicalRichness     `json:"-"`
+	Sentiment               Sentiment           `json:"-"`
 	Enabled                 bool                `json:"enabled"`
 }
 
+// Artist is the band or person behind one or more albums. Albums point at it
+// through ArtistSlug.
+type Artist struct {
+	Slug             string `json:"slug"`
+	Name             string `json:"name"`
+	Country          string `json:"country"`
+	Genre            string `json:"genre"`
+	BandcampUrl      string `json:"bandcamp_url"`
+	MetalArchivesURL string `json:"metal_archives_url"`
+	Website          string `json:"website"`
+}
+
 type BandcampTrackData struct {
 	Name                    string `json:"name"`
 	TrackNumber             int    `json:"track_number"`
@@ -51,12 +66,219 @@ type BandcampTrackData struct {
 	FormattedLength         string `json:"formatted_length"`
 	Lyrics                  string `json:"lyrics,omitempty"`
 	IgnoredWords            string `json:"ignored_words"`
+	Language                string `json:"language"`
 	TotalCharacters         int    `json:"-"`
 	TotalCharactersNoSpaces int    `json:"-"`
 	TotalLines              int    `json:"-"`
 	TotalWords              int    `json:"-"`
 }
 
+// POSCounts is how many word tokens fell into each part-of-speech category.
+// Pronouns and QuestionWords overlap: "who" and "which" count towards both.
+type POSCounts struct {
+	Nouns         int `json:"nouns"`
+	Verbs         int `json:"verbs"`
+	Adjectives    int `json:"adjectives"`
+	Pronouns      int `json:"pronouns"`
+	QuestionWords int `json:"question_words"`
+	Total         int `json:"total"`
+}
+
+// LexicalRichness describes how varied a vocabulary is. Unlike a plain unique
+// word count, MATTR, MTLD and Yule's K do not grow with the length of the
+// text, so they can be compared between short and long albums.
+type LexicalRichness struct {
+	Tokens           int     `json:"tokens"`
+	Types            int     `json:"types"`
+	TTR              float64 `json:"ttr"`
+	MATTR            float64 `json:"mattr"`
+	MTLD             float64 `json:"mtld"`
+	YulesK           float64 `json:"yules_k"`
+	HapaxLegomena    int     `json:"hapax_legomena"`
+	HapaxDislegomena int     `json:"hapax_dislegomena"`
+	Honore           float64 `json:"honore"`
+}
+
+// Readability holds syllable counts and the classic readability formulas.
+// Lyrics rarely have sentence punctuation, so each non-empty line counts as
+// a sentence.
+type Readability struct {
+	Words              int     `json:"words"`
+	Lines              int     `json:"lines"`
+	Syllables          int     `json:"syllables"`
+	ComplexWords       int     `json:"complex_words"`
+	SyllablesPerWord   float64 `json:"syllables_per_word"`
+	SyllablesPerLine   float64 `json:"syllables_per_line"`
+	FleschReadingEase  float64 `json:"flesch_reading_ease"`
+	FleschKincaidGrade float64 `json:"flesch_kincaid_grade"`
+	GunningFog         float64 `json:"gunning_fog"`
+}
+
+// RhymeAnalysis describes the verse structure of a track or album. Schemes
+// has one entry per stanza, like "AABB". Densities are per line: the share of
+// lines whose last word rhymes with a nearby line, and the average number of
+// rhymes inside a line.
+type RhymeAnalysis struct {
+	Schemes              []string      `json:"schemes,omitempty"`
+	DominantScheme       string        `json:"dominant_scheme"`
+	SchemeName           string        `json:"scheme_name"`
+	SchemeCounts         []SchemeCount `json:"scheme_counts,omitempty"`
+	Lines                int           `json:"lines"`
+	RhymedLines          int           `json:"rhymed_lines"`
+	RhymeDensity         float64       `json:"rhyme_density"`
+	InternalRhymes       int           `json:"internal_rhymes"`
+	InternalRhymeDensity float64       `json:"internal_rhyme_density"`
+}
+
+type SchemeCount struct {
+	Scheme string `json:"scheme"`
+	Count  int    `json:"count"`
+}
+
+// NGram is a phrase of N consecutive words with its count and two
+// collocation scores: PMI (in bits) and Dunning's log-likelihood ratio.
+type NGram struct {
+	Phrase        string  `json:"phrase"`
+	N             int     `json:"n"`
+	Count         int     `json:"count"`
+	PMI           float64 `json:"pmi"`
+	LogLikelihood float64 `json:"log_likelihood"`
+}
+
+// DistinctiveWord is a word that an album or artist uses more than the rest
+// of the corpus. LogOdds is the z-score of the weighted log-odds ratio.
+type DistinctiveWord struct {
+	Word    string  `json:"word"`
+	Count   int     `json:"count"`
+	TFIDF   float64 `json:"tf_idf"`
+	LogOdds float64 `json:"log_odds"`
+}
+
+// AlbumSimilarity is the cosine similarity of another album's vocabulary
+// vector, from 0 (no shared words) to 1 (the same words in the same mix).
+type AlbumSimilarity struct {
+	AlbumID    string  `json:"album_id"`
+	Similarity float64 `json:"similarity"`
+}
+
+// TimelinePoint is one step of a vocabulary timeline: a release year across
+// the corpus, or one album in an artist's career. NewWords are words that no
+// earlier point used, and Vocabulary is the number of distinct words up to and
+// including this point.
+type TimelinePoint struct {
+	Label         string          `json:"label"`
+	Year          int             `json:"year"`
+	Albums        int             `json:"albums"`
+	TotalWords    int             `json:"total_words"`
+	WordsPerAlbum float64         `json:"words_per_album"`
+	UniqueWords   int             `json:"unique_words"`
+	NewWords      int             `json:"new_words"`
+	Vocabulary    int             `json:"vocabulary"`
+	Richness      LexicalRichness `json:"lexical_richness"`
+}
+
+// LanguageShare is how much of a set of albums is written in one language.
+// Language is an ISO 639-1 code, or "und" for lyrics too short to tell.
+type LanguageShare struct {
+	Language    string `json:"language"`
+	Name        string `json:"name"`
+	Albums      int    `json:"albums"`
+	Tracks      int    `json:"tracks"`
+	TotalWords  int    `json:"total_words"`
+	UniqueWords int    `json:"unique_words"`
+}
+
+// Emotions counts lexicon words per emotion category.
+type Emotions struct {
+	Anger   int `json:"anger"`
+	Fear    int `json:"fear"`
+	Sadness int `json:"sadness"`
+	Joy     int `json:"joy"`
+	Disgust int `json:"disgust"`
+	Trust   int `json:"trust"`
+}
+
+// Sentiment is the lexicon-based mood of a track or album. Valence adds up
+// the word scores (-3 to 3 each) and Score is that per 100 words, so long
+// and short tracks compare. Negated words count in Negated, with their
+// valence reversed and damped and no emotion.
+type Sentiment struct {
+	Words           int      `json:"words"`
+	Matched         int      `json:"matched"`
+	Positive        int      `json:"positive"`
+	Negative        int      `json:"negative"`
+	Negated         int      `json:"negated"`
+	Valence         float64  `json:"valence"`
+	Score           float64  `json:"score"`
+	Emotions        Emotions `json:"emotions"`
+	DominantEmotion string   `json:"dominant_emotion"`
+}
+
+// SentimentPoint is one track on an album's emotional arc.
+type SentimentPoint struct {
+	TrackNumber     int     `json:"track_number"`
+	TrackName       string  `json:"track_name"`
+	Score           float64 `json:"score"`
+	DominantEmotion string  `json:"dominant_emotion"`
+}
+
+// ProfanityTerm is one entry in the editable profanity lexicon. A Word ending
+// in * matches every word that starts with the rest, so "fuck*" also covers
+// "fucking" and "fucked".
+type ProfanityTerm struct {
+	ID       int64  `json:"id"`
+	Word     string `json:"word"`
+	Category string `json:"category"`
+}
+
+// ProfanityCounts is how often lexicon words occur in a track, album or the
+// whole corpus. Categories and Words are sorted most frequent first.
+type ProfanityCounts struct {
+	Total      int             `json:"total"`
+	Categories []CategoryCount `json:"categories,omitempty"`
+	Words      []WordCount     `json:"words,omitempty"`
+}
+
+type CategoryCount struct {
+	Category string `json:"category"`
+	Count    int    `json:"count"`
+}
+
+// LyricsSection is a block of lyrics: a verse, a chorus or anything else set
+// apart by a blank line or a marker such as "[Chorus]". Repeats is how often
+// it is sung in a row, from "(x2)" style notation. RepeatOf is the index of an
+// earlier section with the same words, or -1 when it is new.
+type LyricsSection struct {
+	Label    string       `json:"label,omitempty"`
+	Lines    []LyricsLine `json:"lines"`
+	Repeats  int          `json:"repeats"`
+	RepeatOf int          `json:"repeat_of"`
+}
+
+// LyricsLine is one line of a section. Repeats comes from notation at the end
+// of the line, as in "Burn it down (x4)".
+type LyricsLine struct {
+	Text    string `json:"text"`
+	Repeats int    `json:"repeats"`
+}
+
+// Repetition compares lyrics as sung, with every repeat written out, against
+// the same lyrics with each distinct section sung once. Ratio is the share of
+// sung words that repeat earlier material: 0 when nothing repeats.
+type Repetition struct {
+	WordsWithRepeats    int     `json:"words_with_repeats"`
+	WordsWithoutRepeats int     `json:"words_without_repeats"`
+	LinesWithRepeats    int     `json:"lines_with_repeats"`
+	LinesWithoutRepeats int     `json:"lines_without_repeats"`
+	RepeatedSections    int     `json:"repeated_sections"`
+	Ratio               float64 `json:"ratio"`
+}
+
+// Percent is the ratio as a whole percentage, for display.
+func (r Repetition) Percent() int {
+	return int(r.Ratio*100 + 0.5)
+}
+
 type TrackWithDetails struct {
 	Track                   BandcampTrackData
 	TrackNumber             int
@@ -72,6 +294,21 @@ type TrackWithDetails struct {
 	TotalCharactersNoSpaces int
 	TotalLines              int
 	IgnoredWords            string
+	POS                     POSCounts
+	Richness                LexicalRichness
+	Readability             Readability
+	Rhymes                  RhymeAnalysis
+	Profanity               ProfanityCounts
+	Sentiment               Sentiment
+	Sections                []LyricsSection
+	Repetition              Repetition
+	Language                string
+}
+
+// User represents an authenticated admin user
+type User struct {
+	AccessToken string
+	Email       string
 }
 
 type UpdateTrackRequest struct {
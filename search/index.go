@@ -0,0 +1,533 @@
+package search
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"millions-of-words/models"
+	"millions-of-words/words"
+)
+
+const (
+	// Matches in a track title count for more than the same match in lyrics.
+	titleWeight = 2.0
+
+	maxSnippets = 3
+)
+
+// Segment is a piece of a snippet. Highlighted segments matched the query.
+type Segment struct {
+	Text      string `json:"text"`
+	Highlight bool   `json:"highlight"`
+}
+
+type Snippet struct {
+	LineNumber int       `json:"line_number"`
+	Segments   []Segment `json:"segments"`
+}
+
+type Result struct {
+	AlbumSlug   string    `json:"album_slug"`
+	ArtistName  string    `json:"artist_name"`
+	AlbumName   string    `json:"album_name"`
+	TrackName   string    `json:"track_name"`
+	TrackNumber int       `json:"track_number"`
+	Score       float64   `json:"score"`
+	Title       []Segment `json:"title"`
+	Snippets    []Snippet `json:"snippets"`
+}
+
+type document struct {
+	albumID     string
+	albumSlug   string
+	artistName  string
+	albumName   string
+	trackName   string
+	trackNumber int
+	lines       []string
+	tokens      []lineToken
+	titleTokens []words.Token
+}
+
+// lineToken is a lyric word together with the line it was found on.
+type lineToken struct {
+	words.Token
+	line int
+}
+
+type posting struct {
+	doc       int
+	positions []int
+}
+
+// field holds the postings for one part of a track. Posting lists are kept
+// sorted by document id, and terms is kept sorted for prefix lookups.
+type field struct {
+	postings map[string][]posting
+	terms    []string
+}
+
+// TrackPosting is where a word appears in the lyrics of one track. Positions
+// are word offsets from the start of the lyrics.
+type TrackPosting struct {
+	AlbumID     string `json:"album_id"`
+	AlbumSlug   string `json:"album_slug"`
+	TrackName   string `json:"track_name"`
+	TrackNumber int    `json:"track_number"`
+	Count       int    `json:"count"`
+	Positions   []int  `json:"positions"`
+}
+
+// Index is an inverted index over track lyrics and titles. It is built once
+// from the album list and then kept up to date one album at a time with
+// SetAlbum and RemoveAlbum. It is safe for concurrent use.
+type Index struct {
+	mu     sync.RWMutex
+	docs   map[int]*document
+	albums map[string][]int
+	nextID int
+	lyrics field
+	titles field
+
+	// Corpus-wide lyric word counts, matching the all-words page.
+	counts      map[string]int
+	totalWords  int
+	frequencies []models.WordCount
+}
+
+// Build indexes every track of the given albums. Lyrics are split and cleaned
+// the same way as the word frequency tables, and each track's ignored words
+// are left out.
+func Build(albums []models.BandcampAlbumData) *Index {
+	idx := &Index{
+		docs:   make(map[int]*document),
+		albums: make(map[string][]int),
+		lyrics: field{postings: make(map[string][]posting)},
+		titles: field{postings: make(map[string][]posting)},
+		counts: make(map[string]int),
+	}
+
+	for _, album := range albums {
+		idx.addAlbum(album)
+	}
+	return idx
+}
+
+// SetAlbum replaces everything indexed for the album with its current tracks.
+func (idx *Index) SetAlbum(album models.BandcampAlbumData) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.removeAlbum(album.ID)
+	idx.addAlbum(album)
+}
+
+func (idx *Index) RemoveAlbum(albumID string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.removeAlbum(albumID)
+}
+
+func (idx *Index) addAlbum(album models.BandcampAlbumData) {
+	for _, track := range album.Tracks {
+		idx.addTrack(album, track)
+	}
+	idx.frequencies = nil
+}
+
+func (idx *Index) removeAlbum(albumID string) {
+	for _, id := range idx.albums[albumID] {
+		doc := idx.docs[id]
+		for _, token := range doc.tokens {
+			idx.counts[token.Word]--
+			if idx.counts[token.Word] == 0 {
+				delete(idx.counts, token.Word)
+			}
+		}
+		idx.totalWords -= len(doc.tokens)
+
+		idx.lyrics.remove(id, lyricWords(doc))
+		idx.titles.remove(id, titleWords(doc))
+		delete(idx.docs, id)
+	}
+	delete(idx.albums, albumID)
+	idx.frequencies = nil
+}
+
+func (idx *Index) addTrack(album models.BandcampAlbumData, track models.BandcampTrackData) {
+	ignored := words.ParseIgnoredWords(track.IgnoredWords)
+	doc := &document{
+		albumID:     album.ID,
+		albumSlug:   album.Slug,
+		artistName:  album.ArtistName,
+		albumName:   album.AlbumName,
+		trackName:   track.Name,
+		trackNumber: track.TrackNumber,
+		lines:       words.SplitLines(ignored.StripPatterns(track.Lyrics)),
+		titleTokens: words.TokenizeLine(words.NormalizeText(track.Name)),
+	}
+
+	for lineIndex, line := range doc.lines {
+		for _, token := range words.TokenizeLine(line) {
+			if ignored.Contains(token.Word) {
+				continue
+			}
+			doc.tokens = append(doc.tokens, lineToken{Token: token, line: lineIndex})
+			idx.counts[token.Word]++
+		}
+	}
+	idx.totalWords += len(doc.tokens)
+
+	// Ids only ever grow, so appending keeps every posting list sorted.
+	id := idx.nextID
+	idx.nextID++
+	idx.docs[id] = doc
+	idx.albums[album.ID] = append(idx.albums[album.ID], id)
+
+	idx.lyrics.add(id, lyricWords(doc))
+	idx.titles.add(id, titleWords(doc))
+}
+
+func lyricWords(doc *document) []string {
+	terms := make([]string, len(doc.tokens))
+	for i, token := range doc.tokens {
+		terms[i] = token.Word
+	}
+	return terms
+}
+
+func titleWords(doc *document) []string {
+	terms := make([]string, len(doc.titleTokens))
+	for i, token := range doc.titleTokens {
+		terms[i] = token.Word
+	}
+	return terms
+}
+
+func (f *field) add(doc int, terms []string) {
+	for position, term := range terms {
+		list, known := f.postings[term]
+		if n := len(list); n > 0 && list[n-1].doc == doc {
+			list[n-1].positions = append(list[n-1].positions, position)
+			continue
+		}
+		f.postings[term] = append(list, posting{doc: doc, positions: []int{position}})
+
+		if !known {
+			i := sort.SearchStrings(f.terms, term)
+			f.terms = append(f.terms, "")
+			copy(f.terms[i+1:], f.terms[i:])
+			f.terms[i] = term
+		}
+	}
+}
+
+func (f *field) remove(doc int, terms []string) {
+	for _, term := range terms {
+		list, ok := f.postings[term]
+		if !ok {
+			continue
+		}
+
+		i := sort.Search(len(list), func(i int) bool { return list[i].doc >= doc })
+		if i == len(list) || list[i].doc != doc {
+			continue
+		}
+		list = append(list[:i:i], list[i+1:]...)
+
+		if len(list) > 0 {
+			f.postings[term] = list
+			continue
+		}
+		delete(f.postings, term)
+		if j := sort.SearchStrings(f.terms, term); j < len(f.terms) && f.terms[j] == term {
+			f.terms = append(f.terms[:j], f.terms[j+1:]...)
+		}
+	}
+}
+
+// expand returns the indexed terms a term node refers to: the word itself, or
+// every term starting with it for prefix queries.
+func (f *field) expand(term termNode) []string {
+	if !term.prefix {
+		if _, ok := f.postings[term.word]; ok {
+			return []string{term.word}
+		}
+		return nil
+	}
+
+	var matches []string
+	for i := sort.SearchStrings(f.terms, term.word); i < len(f.terms); i++ {
+		if !strings.HasPrefix(f.terms[i], term.word) {
+			break
+		}
+		matches = append(matches, f.terms[i])
+	}
+	return matches
+}
+
+// Len is the number of indexed tracks.
+func (idx *Index) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.docs)
+}
+
+// TotalWords is the number of indexed lyric words, ignored words excluded.
+func (idx *Index) TotalWords() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.totalWords
+}
+
+// Count is how many times the cleaned word appears in all indexed lyrics.
+func (idx *Index) Count(word string) int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.counts[words.CleanWord(word)]
+}
+
+// WordFrequencies is the corpus-wide word list, most frequent first. It is
+// sorted once and reused until the index changes; callers must not modify it.
+func (idx *Index) WordFrequencies() []models.WordCount {
+	idx.mu.RLock()
+	frequencies := idx.frequencies
+	idx.mu.RUnlock()
+	if frequencies != nil {
+		return frequencies
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if idx.frequencies == nil {
+		idx.frequencies = words.MapToSortedList(idx.counts)
+		if idx.frequencies == nil {
+			idx.frequencies = []models.WordCount{}
+		}
+	}
+	return idx.frequencies
+}
+
+// Postings lists every track whose lyrics contain the cleaned word.
+func (idx *Index) Postings(word string) []TrackPosting {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	list := idx.lyrics.postings[words.CleanWord(word)]
+	result := make([]TrackPosting, 0, len(list))
+	for _, p := range list {
+		doc := idx.docs[p.doc]
+		result = append(result, TrackPosting{
+			AlbumID:     doc.albumID,
+			AlbumSlug:   doc.albumSlug,
+			TrackName:   doc.trackName,
+			TrackNumber: doc.trackNumber,
+			Count:       len(p.positions),
+			Positions:   append([]int(nil), p.positions...),
+		})
+	}
+	return result
+}
+
+// Search runs the query and returns matching tracks, best first.
+func (idx *Index) Search(query string) ([]Result, error) {
+	tree, err := parse(query)
+	if err != nil {
+		return nil, err
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	matched := idx.eval(tree)
+	matcher := newHighlighter(tree)
+
+	results := make([]Result, 0, len(matched))
+	for id, score := range matched {
+		doc := idx.docs[id]
+		results = append(results, Result{
+			AlbumSlug:   doc.albumSlug,
+			ArtistName:  doc.artistName,
+			AlbumName:   doc.albumName,
+			TrackName:   doc.trackName,
+			TrackNumber: doc.trackNumber,
+			Score:       math.Round(score*1000) / 1000,
+			Title:       highlightLine(doc.trackName, doc.titleTokens, matcher),
+			Snippets:    idx.snippets(doc, matcher),
+		})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.ArtistName != b.ArtistName {
+			return a.ArtistName < b.ArtistName
+		}
+		if a.AlbumName != b.AlbumName {
+			return a.AlbumName < b.AlbumName
+		}
+		return a.TrackNumber < b.TrackNumber
+	})
+
+	return results, nil
+}
+
+type scores map[int]float64
+
+func (idx *Index) eval(n node) scores {
+	switch n := n.(type) {
+	case termNode:
+		return idx.evalTerm(n)
+	case phraseNode:
+		return idx.evalPhrase(n)
+	case orNode:
+		result := scores{}
+		for _, child := range n.children {
+			for doc, score := range idx.eval(child) {
+				result[doc] += score
+			}
+		}
+		return result
+	case andNode:
+		return idx.evalAnd(n)
+	case notNode:
+		return idx.complement(idx.eval(n.child))
+	}
+	return scores{}
+}
+
+func (idx *Index) evalAnd(n andNode) scores {
+	var result scores
+	var excluded []scores
+
+	for _, child := range n.children {
+		if not, ok := child.(notNode); ok {
+			excluded = append(excluded, idx.eval(not.child))
+			continue
+		}
+
+		childScores := idx.eval(child)
+		if result == nil {
+			result = childScores
+			continue
+		}
+		for doc, score := range result {
+			if other, ok := childScores[doc]; ok {
+				result[doc] = score + other
+			} else {
+				delete(result, doc)
+			}
+		}
+	}
+
+	if result == nil {
+		result = idx.complement(scores{})
+	}
+	for _, exclude := range excluded {
+		for doc := range exclude {
+			delete(result, doc)
+		}
+	}
+	return result
+}
+
+func (idx *Index) complement(s scores) scores {
+	result := scores{}
+	for doc := range idx.docs {
+		if _, ok := s[doc]; !ok {
+			result[doc] = 0
+		}
+	}
+	return result
+}
+
+func (idx *Index) evalTerm(term termNode) scores {
+	result := scores{}
+	idx.lyrics.score(term, len(idx.docs), 1, result)
+	idx.titles.score(term, len(idx.docs), titleWeight, result)
+	return result
+}
+
+// score adds a tf-idf score for every document containing the term.
+func (f *field) score(term termNode, totalDocs int, weight float64, result scores) {
+	for _, t := range f.expand(term) {
+		list := f.postings[t]
+		idf := inverseDocumentFrequency(totalDocs, len(list))
+		for _, p := range list {
+			result[p.doc] += weight * (1 + math.Log(float64(len(p.positions)))) * idf
+		}
+	}
+}
+
+func inverseDocumentFrequency(totalDocs, docFrequency int) float64 {
+	return math.Log(1 + float64(totalDocs)/float64(docFrequency))
+}
+
+func (idx *Index) evalPhrase(phrase phraseNode) scores {
+	result := scores{}
+	idx.lyrics.scorePhrase(phrase, len(idx.docs), 1, result)
+	idx.titles.scorePhrase(phrase, len(idx.docs), titleWeight, result)
+	return result
+}
+
+// scorePhrase finds documents where the phrase words appear at consecutive
+// positions. Each hit is scored as if the phrase were a single rare term.
+func (f *field) scorePhrase(phrase phraseNode, totalDocs int, weight float64, result scores) {
+	first, ok := f.postings[phrase.words[0]]
+	if !ok {
+		return
+	}
+
+	var idf float64
+	for _, word := range phrase.words {
+		list, ok := f.postings[word]
+		if !ok {
+			return
+		}
+		idf += inverseDocumentFrequency(totalDocs, len(list))
+	}
+
+	for _, p := range first {
+		starts := p.positions
+		for offset, word := range phrase.words[1:] {
+			next := findPosting(f.postings[word], p.doc)
+			if next == nil {
+				starts = nil
+				break
+			}
+			starts = followedBy(starts, next.positions, offset+1)
+		}
+		if len(starts) > 0 {
+			result[p.doc] += weight * (1 + math.Log(float64(len(starts)))) * idf
+		}
+	}
+}
+
+func findPosting(list []posting, doc int) *posting {
+	i := sort.Search(len(list), func(i int) bool { return list[i].doc >= doc })
+	if i < len(list) && list[i].doc == doc {
+		return &list[i]
+	}
+	return nil
+}
+
+// followedBy keeps the starts that have a position exactly offset later.
+func followedBy(starts, positions []int, offset int) []int {
+	present := make(map[int]bool, len(positions))
+	for _, position := range positions {
+		present[position] = true
+	}
+
+	var kept []int
+	for _, start := range starts {
+		if present[start+offset] {
+			kept = append(kept, start)
+		}
+	}
+	return kept
+}
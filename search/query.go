@@ -0,0 +1,275 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"millions-of-words/words"
+)
+
+// A query is parsed into a small tree of nodes.
+//
+// Supported syntax:
+//
+//	fire sky         both words (AND is implicit)
+//	fire AND sky     the same, spelled out
+//	fire OR flame    either word
+//	fire NOT sky     fire but not sky (also: fire -sky)
+//	"into the fire"  exact phrase
+//	burn*            any word starting with burn
+//	(fire OR flame) sky
+type node interface{}
+
+type termNode struct {
+	word   string
+	prefix bool
+}
+
+type phraseNode struct {
+	words []string
+}
+
+type andNode struct {
+	children []node
+}
+
+type orNode struct {
+	children []node
+}
+
+type notNode struct {
+	child node
+}
+
+type tokenKind int
+
+const (
+	tokWord tokenKind = iota
+	tokPhrase
+	tokAnd
+	tokOr
+	tokNot
+	tokLParen
+	tokRParen
+)
+
+type queryToken struct {
+	kind tokenKind
+	text string
+}
+
+func lex(query string) []queryToken {
+	var tokens []queryToken
+	runes := []rune(query)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, queryToken{kind: tokLParen})
+			i++
+		case r == ')':
+			tokens = append(tokens, queryToken{kind: tokRParen})
+			i++
+		case r == '"' || r == '“' || r == '”':
+			j := i + 1
+			for j < len(runes) && runes[j] != '"' && runes[j] != '“' && runes[j] != '”' {
+				j++
+			}
+			tokens = append(tokens, queryToken{kind: tokPhrase, text: string(runes[i+1 : j])})
+			i = j + 1
+		case r == '-' && i+1 < len(runes) && !unicode.IsSpace(runes[i+1]):
+			tokens = append(tokens, queryToken{kind: tokNot})
+			i++
+		default:
+			j := i
+			for j < len(runes) && !unicode.IsSpace(runes[j]) && runes[j] != '(' && runes[j] != ')' && runes[j] != '"' {
+				j++
+			}
+			text := string(runes[i:j])
+			switch text {
+			case "AND", "&&":
+				tokens = append(tokens, queryToken{kind: tokAnd})
+			case "OR", "||":
+				tokens = append(tokens, queryToken{kind: tokOr})
+			case "NOT":
+				tokens = append(tokens, queryToken{kind: tokNot})
+			default:
+				tokens = append(tokens, queryToken{kind: tokWord, text: text})
+			}
+			i = j
+		}
+	}
+	return tokens
+}
+
+type parser struct {
+	tokens []queryToken
+	pos    int
+}
+
+// parse turns a query string into a node tree. Empty queries and queries made
+// only of NOT terms are rejected because they would match everything.
+func parse(query string) (node, error) {
+	// Queries are normalised like the lyrics they are matched against.
+	p := &parser{tokens: lex(words.NormalizeText(query))}
+	if len(p.tokens) == 0 {
+		return nil, fmt.Errorf("empty query")
+	}
+
+	n, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected closing parenthesis")
+	}
+	if n == nil || !hasPositive(n) {
+		return nil, fmt.Errorf("query needs at least one word to look for")
+	}
+	return n, nil
+}
+
+func (p *parser) peek() (queryToken, bool) {
+	if p.pos >= len(p.tokens) {
+		return queryToken{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *parser) parseOr() (node, error) {
+	var children []node
+	for {
+		child, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		if child != nil {
+			children = append(children, child)
+		}
+
+		tok, ok := p.peek()
+		if !ok || tok.kind != tokOr {
+			break
+		}
+		p.pos++
+	}
+
+	switch len(children) {
+	case 0:
+		return nil, nil
+	case 1:
+		return children[0], nil
+	}
+	return orNode{children: children}, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	var children []node
+	for {
+		tok, ok := p.peek()
+		if !ok || tok.kind == tokOr || tok.kind == tokRParen {
+			break
+		}
+		if tok.kind == tokAnd {
+			p.pos++
+			continue
+		}
+
+		child, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if child != nil {
+			children = append(children, child)
+		}
+	}
+
+	switch len(children) {
+	case 0:
+		return nil, nil
+	case 1:
+		return children[0], nil
+	}
+	return andNode{children: children}, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	tok, _ := p.peek()
+	if tok.kind == tokNot {
+		p.pos++
+		child, err := p.parseUnary()
+		if err != nil || child == nil {
+			return nil, err
+		}
+		return notNode{child: child}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	tok, ok := p.peek()
+	if !ok {
+		return nil, nil
+	}
+	p.pos++
+
+	switch tok.kind {
+	case tokLParen:
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing, ok := p.peek(); !ok || closing.kind != tokRParen {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		p.pos++
+		return n, nil
+	case tokPhrase:
+		var phrase []string
+		for _, t := range words.TokenizeLine(tok.text) {
+			phrase = append(phrase, t.Word)
+		}
+		switch len(phrase) {
+		case 0:
+			return nil, nil
+		case 1:
+			return termNode{word: phrase[0]}, nil
+		}
+		return phraseNode{words: phrase}, nil
+	case tokWord:
+		prefix := strings.HasSuffix(tok.text, "*")
+		word := words.CleanWord(strings.TrimRight(tok.text, "*"))
+		if word == "" {
+			return nil, nil
+		}
+		return termNode{word: word, prefix: prefix}, nil
+	case tokRParen:
+		return nil, fmt.Errorf("unexpected closing parenthesis")
+	}
+	return nil, nil
+}
+
+func hasPositive(n node) bool {
+	switch n := n.(type) {
+	case termNode, phraseNode:
+		return true
+	case andNode:
+		for _, child := range n.children {
+			if hasPositive(child) {
+				return true
+			}
+		}
+	case orNode:
+		for _, child := range n.children {
+			if !hasPositive(child) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,193 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+
+	"millions-of-words/models"
+	"millions-of-words/words"
+)
+
+var testAlbums = []models.BandcampAlbumData{
+	{
+		ID:         "1",
+		Slug:       "artist1-album1",
+		ArtistName: "Artist1",
+		AlbumName:  "Album1",
+		Tracks: []models.BandcampTrackData{
+			{Name: "Into the Fire", TrackNumber: 1, Lyrics: "We walk into the fire\nThe sky is burning"},
+			{Name: "Cold Night", TrackNumber: 2, Lyrics: "The night is cold\nNo fire to warm us"},
+		},
+	},
+	{
+		ID:         "2",
+		Slug:       "artist2-album2",
+		ArtistName: "Artist2",
+		AlbumName:  "Album2",
+		Tracks: []models.BandcampTrackData{
+			{Name: "Burn", TrackNumber: 1, Lyrics: "Burned and buried\nThe fire of the sky", IgnoredWords: "buried"},
+			{Name: "Flame", TrackNumber: 2, Lyrics: "A flame in the dark"},
+		},
+	},
+}
+
+func trackNames(results []Result) []string {
+	var names []string
+	for _, result := range results {
+		names = append(names, result.TrackName)
+	}
+	return names
+}
+
+func TestSearch(t *testing.T) {
+	idx := Build(testAlbums)
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"fire", []string{"Into the Fire", "Cold Night", "Burn"}},
+		{"fire sky", []string{"Into the Fire", "Burn"}},
+		{"fire AND sky", []string{"Into the Fire", "Burn"}},
+		{"flame OR night", []string{"Cold Night", "Flame"}},
+		{"fire NOT sky", []string{"Cold Night"}},
+		{"fire -sky", []string{"Cold Night"}},
+		{`"into the fire"`, []string{"Into the Fire"}},
+		{`"the fire of"`, []string{"Burn"}},
+		{"burn*", []string{"Into the Fire", "Burn"}},
+		{"(flame OR cold) NOT night", []string{"Flame"}},
+		{"buried", nil},
+		{"nothing", nil},
+	}
+
+	for _, tt := range tests {
+		results, err := idx.Search(tt.query)
+		if err != nil {
+			t.Errorf("Search(%q) error: %v", tt.query, err)
+			continue
+		}
+
+		got := trackNames(results)
+		gotSet := map[string]bool{}
+		for _, name := range got {
+			gotSet[name] = true
+		}
+		wantSet := map[string]bool{}
+		for _, name := range tt.want {
+			wantSet[name] = true
+		}
+		if !reflect.DeepEqual(gotSet, wantSet) {
+			t.Errorf("Search(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestSearchRanksTitleMatchesFirst(t *testing.T) {
+	results, err := Build(testAlbums).Search("fire")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].TrackName != "Into the Fire" {
+		t.Errorf("top result = %q, want %q", results[0].TrackName, "Into the Fire")
+	}
+}
+
+func TestSearchSnippets(t *testing.T) {
+	results, err := Build(testAlbums).Search("burn*")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var burn Result
+	for _, result := range results {
+		if result.TrackName == "Burn" {
+			burn = result
+		}
+	}
+
+	wantTitle := []Segment{{Text: "Burn", Highlight: true}}
+	if !reflect.DeepEqual(burn.Title, wantTitle) {
+		t.Errorf("Title = %+v, want %+v", burn.Title, wantTitle)
+	}
+
+	wantSnippets := []Snippet{{
+		LineNumber: 1,
+		Segments: []Segment{
+			{Text: "Burned", Highlight: true},
+			{Text: " and buried"},
+		},
+	}}
+	if !reflect.DeepEqual(burn.Snippets, wantSnippets) {
+		t.Errorf("Snippets = %+v, want %+v", burn.Snippets, wantSnippets)
+	}
+}
+
+func TestSearchInvalidQueries(t *testing.T) {
+	idx := Build(testAlbums)
+	for _, query := range []string{"", "   ", "NOT fire", "-fire", "(fire", "fire)"} {
+		if _, err := idx.Search(query); err == nil {
+			t.Errorf("Search(%q) expected an error", query)
+		}
+	}
+}
+
+func TestWordFrequenciesMatchWordTables(t *testing.T) {
+	counts := map[string]int{}
+	for _, album := range testAlbums {
+		for _, track := range album.Tracks {
+			wordCounts, _, _, _ := words.CalculateAndSortWordFrequencies(track.Lyrics, track.IgnoredWords)
+			for _, wc := range wordCounts {
+				counts[wc.Word] += wc.Count
+			}
+		}
+	}
+
+	idx := Build(testAlbums)
+	if got, want := idx.WordFrequencies(), words.MapToSortedList(counts); !reflect.DeepEqual(got, want) {
+		t.Errorf("WordFrequencies() = %v, want %v", got, want)
+	}
+	if got := idx.Count("fire"); got != 3 {
+		t.Errorf("Count(fire) = %d, want 3", got)
+	}
+}
+
+func TestPostings(t *testing.T) {
+	want := []TrackPosting{
+		{AlbumID: "1", AlbumSlug: "artist1-album1", TrackName: "Into the Fire", TrackNumber: 1, Count: 1, Positions: []int{4}},
+		{AlbumID: "1", AlbumSlug: "artist1-album1", TrackName: "Cold Night", TrackNumber: 2, Count: 1, Positions: []int{5}},
+		{AlbumID: "2", AlbumSlug: "artist2-album2", TrackName: "Burn", TrackNumber: 1, Count: 1, Positions: []int{3}},
+	}
+	if got := Build(testAlbums).Postings("Fire"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Postings(Fire) = %+v, want %+v", got, want)
+	}
+}
+
+func TestIncrementalUpdates(t *testing.T) {
+	idx := Build(testAlbums)
+	before := idx.WordFrequencies()
+
+	edited := testAlbums[1]
+	edited.Tracks = []models.BandcampTrackData{{Name: "Burn", TrackNumber: 1, Lyrics: "ashes everywhere"}}
+	idx.SetAlbum(edited)
+
+	if got := idx.Count("fire"); got != 2 {
+		t.Errorf("Count(fire) after edit = %d, want 2", got)
+	}
+	if results, _ := idx.Search("ash*"); len(results) != 1 {
+		t.Errorf("Search(ash*) after edit returned %d results, want 1", len(results))
+	}
+	if results, _ := idx.Search("flame"); len(results) != 0 {
+		t.Errorf("Search(flame) after edit returned %d results, want 0", len(results))
+	}
+
+	idx.SetAlbum(testAlbums[1])
+	if got := idx.WordFrequencies(); !reflect.DeepEqual(got, before) {
+		t.Errorf("WordFrequencies() after restoring album = %v, want %v", got, before)
+	}
+
+	idx.RemoveAlbum("1")
+	idx.RemoveAlbum("2")
+	if idx.Len() != 0 || idx.TotalWords() != 0 || len(idx.WordFrequencies()) != 0 {
+		t.Errorf("index not empty after removing every album: %d docs, %d words", idx.Len(), idx.TotalWords())
+	}
+}
@@ -0,0 +1,98 @@
+package search
+
+import (
+	"strings"
+
+	"millions-of-words/words"
+)
+
+// highlighter decides which words in a result should be highlighted. Only
+// words from the positive part of the query are used; NOT terms never match.
+type highlighter struct {
+	exact    map[string]bool
+	prefixes []string
+}
+
+func newHighlighter(tree node) *highlighter {
+	h := &highlighter{exact: make(map[string]bool)}
+	h.collect(tree)
+	return h
+}
+
+func (h *highlighter) collect(n node) {
+	switch n := n.(type) {
+	case termNode:
+		if n.prefix {
+			h.prefixes = append(h.prefixes, n.word)
+		} else {
+			h.exact[n.word] = true
+		}
+	case phraseNode:
+		for _, word := range n.words {
+			h.exact[word] = true
+		}
+	case andNode:
+		for _, child := range n.children {
+			h.collect(child)
+		}
+	case orNode:
+		for _, child := range n.children {
+			h.collect(child)
+		}
+	}
+}
+
+func (h *highlighter) matches(word string) bool {
+	if h.exact[word] {
+		return true
+	}
+	for _, prefix := range h.prefixes {
+		if strings.HasPrefix(word, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// snippets returns the first few lines with a highlighted word in them.
+func (idx *Index) snippets(doc *document, h *highlighter) []Snippet {
+	var snippets []Snippet
+	for i := 0; i < len(doc.tokens) && len(snippets) < maxSnippets; {
+		line := doc.tokens[i].line
+
+		var lineTokens []words.Token
+		hit := false
+		for ; i < len(doc.tokens) && doc.tokens[i].line == line; i++ {
+			lineTokens = append(lineTokens, doc.tokens[i].Token)
+			hit = hit || h.matches(doc.tokens[i].Word)
+		}
+
+		if hit {
+			snippets = append(snippets, Snippet{
+				LineNumber: line + 1,
+				Segments:   highlightLine(doc.lines[line], lineTokens, h),
+			})
+		}
+	}
+	return snippets
+}
+
+// highlightLine cuts a line into plain and highlighted segments.
+func highlightLine(line string, tokens []words.Token, h *highlighter) []Segment {
+	var segments []Segment
+	last := 0
+	for _, token := range tokens {
+		if !h.matches(token.Word) {
+			continue
+		}
+		if token.Start > last {
+			segments = append(segments, Segment{Text: line[last:token.Start]})
+		}
+		segments = append(segments, Segment{Text: line[token.Start:token.End], Highlight: true})
+		last = token.End
+	}
+	if last < len(line) {
+		segments = append(segments, Segment{Text: line[last:]})
+	}
+	return segments
+}
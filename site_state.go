@@ -0,0 +1,104 @@
+package main
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"millions-of-words/models"
+	"millions-of-words/search"
+	"millions-of-words/words"
+	"millions-of-words/words/profanity"
+)
+
+// siteState is everything the pages and the API read that is built from the
+// loaded albums: the album list, the lyrics index, the all albums table, the
+// home page stats, the admin's profanity lexicon and stopwords, and the
+// corpus-wide caches.
+//
+// Apart from the lyrics index and its caches, a published siteState is never
+// changed. Writers build a new one off to the side and swap it in with
+// publishState, so a request that takes the current state once sees one
+// consistent album list, table and set of stats without locking, and
+// anything it caches goes into the state it was built from.
+type siteState struct {
+	albums []models.BandcampAlbumData
+	// index is the one live part of a state. It is shared by every state
+	// and updated in place one album at a time, as a full rebuild
+	// re-tokenises every lyric, and it does its own locking. A request that
+	// took the previous state can see search results and word counts that
+	// already include an album change its album list does not.
+	index     *search.Index
+	table     *albumRepository
+	homePage  homePageStats
+	lexicon   *profanity.Lexicon
+	stopwords words.Stopwords
+
+	// Built on first use.
+	corpus atomic.Pointer[words.Corpus]
+	lemmas atomic.Pointer[words.Lemmatizer]
+
+	// albumDetails holds album pages and apiAlbums API album details, both
+	// by album ID and frequency view, and artistPages artist profiles by
+	// slug and view.
+	albumDetails sync.Map
+	apiAlbums    sync.Map
+	artistPages  sync.Map
+	// phrases holds the corpus-wide n-gram tables by n, timelines the
+	// corpus timeline under "" and artist ones by slug, and languages the
+	// language breakdown under "" and each language's word frequencies by
+	// its code. They all read every lyric involved.
+	phrases   sync.Map
+	timelines sync.Map
+	languages sync.Map
+}
+
+var (
+	currentSite atomic.Pointer[siteState]
+	// stateMu serialises writers, so that no change is built on top of a
+	// state that is about to be replaced. Readers never take it.
+	stateMu sync.Mutex
+
+	// emptyState is served until the first albums are loaded.
+	emptyState = newSiteState(nil, search.Build(nil), profanity.NewLexicon(profanity.DefaultTerms), words.NewStopwords(nil))
+)
+
+func newSiteState(albums []models.BandcampAlbumData, index *search.Index, lexicon *profanity.Lexicon, stopwords words.Stopwords) *siteState {
+	s := &siteState{
+		albums:    albums,
+		index:     index,
+		table:     newAlbumRepository(albums),
+		lexicon:   lexicon,
+		stopwords: stopwords,
+	}
+	s.homePage = s.homePageStats()
+	return s
+}
+
+// currentState is the latest published state. A request should take it once
+// and read everything from it.
+func currentState() *siteState {
+	if s := currentSite.Load(); s != nil {
+		return s
+	}
+	return emptyState
+}
+
+func publishState(s *siteState) {
+	currentSite.Store(s)
+}
+
+// replaceAlbums publishes a new album list with a freshly built lyrics index,
+// keeping the current lexicon and stopwords. Callers hold stateMu.
+func replaceAlbums(albums []models.BandcampAlbumData) {
+	current := currentState()
+	publishState(newSiteState(albums, search.Build(albums), current.lexicon, current.stopwords))
+}
+
+// reconfigured is the state with a new lexicon and stopwords. The album
+// vectors and lemmas do not depend on either, so they are kept.
+func (s *siteState) reconfigured(lexicon *profanity.Lexicon, stopwords words.Stopwords) *siteState {
+	next := newSiteState(s.albums, s.index, lexicon, stopwords)
+	next.corpus.Store(s.corpus.Load())
+	next.lemmas.Store(s.lemmas.Load())
+	return next
+}
@@ -0,0 +1,36 @@
+-- The editable profanity lexicon, seeded with the built-in terms
+-- (profanity.DefaultTerms). The site also seeds an empty lexicon on start.
+-- Only read with the service role key.
+create table if not exists profanity_terms (
+    id bigint generated by default as identity primary key,
+    word text not null unique,
+    category text not null
+);
+alter table profanity_terms enable row level security;
+
+insert into profanity_terms (word, category) values
+    ('fuck*', 'sexual'),
+    ('motherfuck*', 'sexual'),
+    ('cunt*', 'sexual'),
+    ('cock', 'sexual'),
+    ('cocks', 'sexual'),
+    ('dick', 'sexual'),
+    ('dicks', 'sexual'),
+    ('pussy', 'sexual'),
+    ('whore*', 'sexual'),
+    ('slut*', 'sexual'),
+    ('shit*', 'scatological'),
+    ('bullshit*', 'scatological'),
+    ('piss*', 'scatological'),
+    ('crap*', 'scatological'),
+    ('turd*', 'scatological'),
+    ('goddamn*', 'blasphemous'),
+    ('damn*', 'blasphemous'),
+    ('bitch*', 'insult'),
+    ('bastard*', 'insult'),
+    ('asshole*', 'insult'),
+    ('arsehole*', 'insult'),
+    ('dickhead*', 'insult'),
+    ('wanker*', 'insult'),
+    ('twat*', 'insult')
+on conflict (word) do nothing;
@@ -0,0 +1,6 @@
+-- Stopwords added by the admin. The built-in list is not stored. Only read
+-- with the service role key.
+create table if not exists stopwords (
+    word text primary key
+);
+alter table stopwords enable row level security;
@@ -0,0 +1,17 @@
+-- Artists, and the slug albums use to point at them. Existing albums keep an
+-- empty artist_slug and fall back to one made from artist_name.
+create table if not exists artists (
+    slug text primary key,
+    name text not null,
+    country text not null default '',
+    genre text not null default '',
+    bandcamp_url text not null default '',
+    metal_archives_url text not null default '',
+    website text not null default ''
+);
+alter table artists enable row level security;
+drop policy if exists "artists are public" on artists;
+create policy "artists are public" on artists for select using (true);
+
+alter table albums add column if not exists artist_slug text not null default '';
+create index if not exists idx_albums_artist_slug on albums (artist_slug);
@@ -0,0 +1,3 @@
+-- The language each track's lyrics are written in. Existing tracks keep an
+-- empty language and have it detected when they are loaded.
+alter table tracks add column if not exists language text not null default '';
@@ -0,0 +1,10 @@
+-- Stored album numbers, recomputed when version is below analytics.Version
+-- or the lyrics no longer match the fingerprint in metrics.
+create table if not exists album_analytics (
+    album_id text primary key,
+    version integer not null,
+    metrics jsonb not null
+);
+alter table album_analytics enable row level security;
+drop policy if exists "album analytics are public" on album_analytics;
+create policy "album analytics are public" on album_analytics for select using (true);
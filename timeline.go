@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+
+	"millions-of-words/models"
+	"millions-of-words/words"
+
+	"github.com/labstack/echo/v4"
+)
+
+func (s *siteState) corpusTimeline() []models.TimelinePoint {
+	if cached, ok := s.timelines.Load(""); ok {
+		return cached.([]models.TimelinePoint)
+	}
+	points := words.YearTimeline(s.albums)
+	s.timelines.Store("", points)
+	return points
+}
+
+// artistTimeline is the career timeline of one artist, or nil when the artist
+// has no albums.
+func (s *siteState) artistTimeline(slug string) []models.TimelinePoint {
+	if cached, ok := s.timelines.Load(slug); ok {
+		return cached.([]models.TimelinePoint)
+	}
+	artistAlbums := s.albumsByArtist(slug)
+	if len(artistAlbums) == 0 {
+		return nil
+	}
+	points := words.CareerTimeline(artistAlbums)
+	s.timelines.Store(slug, points)
+	return points
+}
+
+// timelineChart is a timeline in the shape Chart.js takes: one label per
+// point and one dataset per measure. Word counts and richness have very
+// different ranges, so each dataset names the y axis it belongs on.
+type timelineChart struct {
+	Labels   []string          `json:"labels"`
+	Datasets []timelineDataset `json:"datasets"`
+}
+
+type timelineDataset struct {
+	Label   string    `json:"label"`
+	YAxisID string    `json:"yAxisID"`
+	Data    []float64 `json:"data"`
+}
+
+func chartTimeline(points []models.TimelinePoint) timelineChart {
+	chart := timelineChart{Labels: make([]string, 0, len(points))}
+	perAlbum := make([]float64, 0, len(points))
+	newWords := make([]float64, 0, len(points))
+	mattr := make([]float64, 0, len(points))
+	for _, point := range points {
+		chart.Labels = append(chart.Labels, point.Label)
+		perAlbum = append(perAlbum, point.WordsPerAlbum)
+		newWords = append(newWords, float64(point.NewWords))
+		mattr = append(mattr, point.Richness.MATTR)
+	}
+	chart.Datasets = []timelineDataset{
+		{Label: "Words per album", YAxisID: "words", Data: perAlbum},
+		{Label: "New words", YAxisID: "words", Data: newWords},
+		{Label: "MATTR", YAxisID: "richness", Data: mattr},
+	}
+	return chart
+}
+
+func timelineHandler(c echo.Context) error {
+	data := map[string]interface{}{
+		"Title": "Timeline - Millions of Words",
+	}
+
+	s := currentState()
+	points := s.corpusTimeline()
+	if slug := c.QueryParam("artist"); slug != "" {
+		points = s.artistTimeline(slug)
+		if points == nil {
+			return echo.NewHTTPError(http.StatusNotFound, "Artist not found")
+		}
+		artist := s.findArtist(slug)
+		data["Artist"] = artist
+		data["Title"] = artist.Name + " Timeline - Millions of Words"
+	}
+
+	chartJSON, err := json.Marshal(chartTimeline(points))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to marshal timeline")
+	}
+
+	data["Points"] = points
+	data["ChartJSON"] = template.JS(chartJSON)
+	return renderTemplate(c, "timeline.html", data)
+}
@@ -0,0 +1,133 @@
+package words
+
+import (
+	"sort"
+	"strings"
+
+	"millions-of-words/models"
+)
+
+// Occurrence is one appearance of a word in a track, with the text on either
+// side of it on the same line and optionally the neighbouring lines.
+type Occurrence struct {
+	AlbumSlug   string   `json:"album_slug"`
+	ArtistName  string   `json:"artist_name"`
+	AlbumName   string   `json:"album_name"`
+	TrackName   string   `json:"track_name"`
+	TrackNumber int      `json:"track_number"`
+	LineNumber  int      `json:"line_number"`
+	Left        string   `json:"left"`
+	Match       string   `json:"match"`
+	Right       string   `json:"right"`
+	Before      []string `json:"before,omitempty"`
+	After       []string `json:"after,omitempty"`
+}
+
+type AlbumOccurrenceCount struct {
+	AlbumSlug  string `json:"album_slug"`
+	ArtistName string `json:"artist_name"`
+	AlbumName  string `json:"album_name"`
+	Count      int    `json:"count"`
+}
+
+type Concordance struct {
+	Word        string                 `json:"word"`
+	Total       int                    `json:"total"`
+	PerAlbum    []AlbumOccurrenceCount `json:"per_album"`
+	Occurrences []Occurrence           `json:"occurrences"`
+}
+
+// BuildConcordance finds every occurrence of word across the albums. Words are
+// split and cleaned exactly like CalculateAndSortWordFrequencies, so the total
+// here matches the count on the all-words page. adjacentLines controls how
+// many lines before and after each match are included.
+func BuildConcordance(word string, albums []models.BandcampAlbumData, adjacentLines int) Concordance {
+	target := CleanWord(word)
+	concordance := Concordance{Word: target}
+	if target == "" {
+		return concordance
+	}
+
+	for _, album := range albums {
+		albumCount := 0
+		for _, track := range album.Tracks {
+			occurrences := findInTrack(target, track, adjacentLines)
+			for i := range occurrences {
+				occurrences[i].AlbumSlug = album.Slug
+				occurrences[i].ArtistName = album.ArtistName
+				occurrences[i].AlbumName = album.AlbumName
+			}
+			concordance.Occurrences = append(concordance.Occurrences, occurrences...)
+			albumCount += len(occurrences)
+		}
+
+		if albumCount > 0 {
+			concordance.PerAlbum = append(concordance.PerAlbum, AlbumOccurrenceCount{
+				AlbumSlug:  album.Slug,
+				ArtistName: album.ArtistName,
+				AlbumName:  album.AlbumName,
+				Count:      albumCount,
+			})
+			concordance.Total += albumCount
+		}
+	}
+
+	sort.SliceStable(concordance.PerAlbum, func(i, j int) bool {
+		return concordance.PerAlbum[i].Count > concordance.PerAlbum[j].Count
+	})
+
+	return concordance
+}
+
+func findInTrack(target string, track models.BandcampTrackData, adjacentLines int) []Occurrence {
+	if track.Lyrics == "" {
+		return nil
+	}
+
+	ignored := ParseIgnoredWords(track.IgnoredWords)
+	if ignored.Contains(target) {
+		return nil
+	}
+
+	lines := SplitLines(ignored.StripPatterns(track.Lyrics))
+
+	var occurrences []Occurrence
+	for lineIndex, line := range lines {
+		for _, token := range TokenizeLine(line) {
+			if token.Word != target {
+				continue
+			}
+			occurrences = append(occurrences, Occurrence{
+				TrackName:   track.Name,
+				TrackNumber: track.TrackNumber,
+				LineNumber:  lineIndex + 1,
+				Left:        line[:token.Start],
+				Match:       line[token.Start:token.End],
+				Right:       line[token.End:],
+				Before:      surroundingLines(lines, lineIndex-adjacentLines, lineIndex),
+				After:       surroundingLines(lines, lineIndex+1, lineIndex+1+adjacentLines),
+			})
+		}
+	}
+	return occurrences
+}
+
+func surroundingLines(lines []string, from, to int) []string {
+	if from < 0 {
+		from = 0
+	}
+	if to > len(lines) {
+		to = len(lines)
+	}
+	if from >= to {
+		return nil
+	}
+
+	var result []string
+	for _, line := range lines[from:to] {
+		if strings.TrimSpace(line) != "" {
+			result = append(result, line)
+		}
+	}
+	return result
+}
@@ -0,0 +1,84 @@
+package words
+
+import (
+	"reflect"
+	"testing"
+
+	"millions-of-words/models"
+)
+
+func TestBuildConcordance(t *testing.T) {
+	albums := []models.BandcampAlbumData{
+		{
+			Slug:       "a",
+			ArtistName: "Artist A",
+			AlbumName:  "Album A",
+			Tracks: []models.BandcampTrackData{
+				{Name: "One", TrackNumber: 1, Lyrics: "Into the Fire we go\nfire, fire!\nfirefly"},
+				{Name: "Two", TrackNumber: 2, Lyrics: "fire again", IgnoredWords: "fire"},
+			},
+		},
+		{
+			Slug:       "b",
+			ArtistName: "Artist B",
+			AlbumName:  "Album B",
+			Tracks: []models.BandcampTrackData{
+				{Name: "Three", TrackNumber: 1, Lyrics: "[Chorus: fire]\nno fire here\n\nend", IgnoredWords: "[Chorus: fire]"},
+			},
+		},
+	}
+
+	c := BuildConcordance("Fire", albums, 1)
+
+	if c.Word != "fire" {
+		t.Errorf("Word = %q, want %q", c.Word, "fire")
+	}
+	if c.Total != 4 {
+		t.Fatalf("Total = %d, want 4", c.Total)
+	}
+
+	wantPerAlbum := []AlbumOccurrenceCount{
+		{AlbumSlug: "a", ArtistName: "Artist A", AlbumName: "Album A", Count: 3},
+		{AlbumSlug: "b", ArtistName: "Artist B", AlbumName: "Album B", Count: 1},
+	}
+	if !reflect.DeepEqual(c.PerAlbum, wantPerAlbum) {
+		t.Errorf("PerAlbum = %+v, want %+v", c.PerAlbum, wantPerAlbum)
+	}
+
+	first := c.Occurrences[0]
+	if first.Left != "Into the " || first.Match != "Fire" || first.Right != " we go" {
+		t.Errorf("first occurrence = %q|%q|%q", first.Left, first.Match, first.Right)
+	}
+	if first.Before != nil || !reflect.DeepEqual(first.After, []string{"fire, fire!"}) {
+		t.Errorf("first occurrence context = %v / %v", first.Before, first.After)
+	}
+
+	second := c.Occurrences[1]
+	if second.LineNumber != 2 || second.Match != "fire" || second.Right != ", fire!" {
+		t.Errorf("second occurrence = %+v", second)
+	}
+
+	last := c.Occurrences[3]
+	if last.AlbumSlug != "b" || last.TrackName != "Three" || last.LineNumber != 2 {
+		t.Errorf("last occurrence = %+v", last)
+	}
+}
+
+func TestBuildConcordanceMatchesWordFrequencies(t *testing.T) {
+	lyrics := "I'm burning, burning-down. BURNING?\nburning's not burning"
+	track := models.BandcampTrackData{Lyrics: lyrics}
+	albums := []models.BandcampAlbumData{{Tracks: []models.BandcampTrackData{track}}}
+
+	counts, _, _, _ := CalculateAndSortWordFrequencies(lyrics, "")
+	for _, wc := range counts {
+		if got := BuildConcordance(wc.Word, albums, 0).Total; got != wc.Count {
+			t.Errorf("concordance total for %q = %d, word frequency = %d", wc.Word, got, wc.Count)
+		}
+	}
+}
+
+func TestBuildConcordanceEmptyWord(t *testing.T) {
+	if c := BuildConcordance("--", nil, 0); c.Total != 0 || c.Occurrences != nil {
+		t.Errorf("BuildConcordance(\"--\") = %+v, want empty", c)
+	}
+}
@@ -0,0 +1,59 @@
+package words
+
+import (
+	"sync"
+
+	"millions-of-words/models"
+)
+
+// Corpus holds the word counts of every album, so that one album or artist
+// can be compared with the rest without re-reading all the lyrics.
+type Corpus struct {
+	ids         []string
+	albums      map[string]map[string]int
+	albumTotals map[string]int
+	counts      map[string]int
+	docFreq     map[string]int
+	total       int
+
+	// vectors are the albums' TF-IDF vectors scaled to unit length, built
+	// with the similarity matrix on first use.
+	vectorsOnce sync.Once
+	vectors     map[string]map[string]float64
+	matrix      [][]float64
+}
+
+// NewCorpus counts the words of each album the same way as
+// CalculateAndSortWordFrequencies.
+func NewCorpus(albums []models.BandcampAlbumData) *Corpus {
+	c := &Corpus{
+		albums:      make(map[string]map[string]int, len(albums)),
+		albumTotals: make(map[string]int, len(albums)),
+		counts:      make(map[string]int),
+		docFreq:     make(map[string]int),
+	}
+	for _, album := range albums {
+		counts, total := albumWordCounts(album)
+		c.ids = append(c.ids, album.ID)
+		c.albums[album.ID] = counts
+		c.albumTotals[album.ID] = total
+		c.total += total
+		for word, count := range counts {
+			c.counts[word] += count
+			c.docFreq[word]++
+		}
+	}
+	return c
+}
+
+func albumWordCounts(album models.BandcampAlbumData) (map[string]int, int) {
+	counts := make(map[string]int)
+	total := 0
+	for _, track := range album.Tracks {
+		for _, word := range TrackWords(track.Lyrics, track.IgnoredWords) {
+			counts[word]++
+			total++
+		}
+	}
+	return counts, total
+}
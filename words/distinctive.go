@@ -0,0 +1,114 @@
+package words
+
+import (
+	"math"
+	"sort"
+
+	"millions-of-words/models"
+)
+
+const (
+	// MinDistinctiveCount is how often an album or artist has to use a word
+	// for it to count as distinctive. Words used once dominate TF-IDF
+	// otherwise.
+	MinDistinctiveCount = 2
+
+	// Distinctive word rankings.
+	RankByLogOdds = "logodds"
+	RankByTFIDF   = "tfidf"
+)
+
+// Distinctive scores the words of a group of albums (one album, or all of an
+// artist's) against the rest of the corpus, ordered by log-odds.
+//
+// TF-IDF treats each album as a document, so a word only scores well when
+// few albums use it. The log-odds ratio follows Monroe, Colaresi and Quinn
+// (2008): word counts in the group and in the rest of the corpus are
+// smoothed with an informative Dirichlet prior taken from the whole corpus,
+// and the difference is divided by its standard error so that frequent words
+// do not win just by being frequent.
+//
+// Albums missing from the corpus (disabled ones) are counted from their
+// lyrics and added to the prior. An empty list is returned when there is
+// nothing to compare against.
+func (c *Corpus) Distinctive(group []models.BandcampAlbumData) []models.DistinctiveWord {
+	target := make(map[string]int)
+	targetTotal := 0
+	restTotal := c.total
+	prior := make(map[string]int)
+	priorTotal := c.total
+	for _, album := range group {
+		counts, ok := c.albums[album.ID]
+		total := c.albumTotals[album.ID]
+		if ok {
+			restTotal -= total
+		} else {
+			counts, total = albumWordCounts(album)
+			priorTotal += total
+		}
+		for word, count := range counts {
+			target[word] += count
+			if !ok {
+				prior[word] += count
+			}
+		}
+		targetTotal += total
+	}
+
+	result := []models.DistinctiveWord{}
+	if targetTotal == 0 || restTotal <= 0 {
+		return result
+	}
+
+	documents := float64(len(c.albums))
+	for word, count := range target {
+		if count < MinDistinctiveCount {
+			continue
+		}
+
+		alpha := float64(c.counts[word] + prior[word])
+		rest := float64(c.counts[word] - (count - prior[word]))
+		targetOthers := float64(targetTotal+priorTotal) - float64(count) - alpha
+		restOthers := float64(restTotal+priorTotal) - rest - alpha
+		if targetOthers <= 0 || restOthers <= 0 {
+			continue
+		}
+
+		delta := math.Log((float64(count)+alpha)/targetOthers) - math.Log((rest+alpha)/restOthers)
+		variance := 1/(float64(count)+alpha) + 1/(rest+alpha)
+
+		tf := float64(count) / float64(targetTotal)
+		idf := math.Log((1 + documents) / (1 + float64(c.docFreq[word])))
+
+		result = append(result, models.DistinctiveWord{
+			Word:    word,
+			Count:   count,
+			TFIDF:   tf * idf,
+			LogOdds: delta / math.Sqrt(variance),
+		})
+	}
+	return RankDistinctive(result, RankByLogOdds)
+}
+
+// RankDistinctive returns a copy of the words sorted by the given score,
+// highest first. Ties go to the more frequent word.
+func RankDistinctive(distinctive []models.DistinctiveWord, by string) []models.DistinctiveWord {
+	ranked := append([]models.DistinctiveWord{}, distinctive...)
+	score := func(dw models.DistinctiveWord) float64 {
+		if by == RankByTFIDF {
+			return dw.TFIDF
+		}
+		return dw.LogOdds
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		si, sj := score(ranked[i]), score(ranked[j])
+		if si != sj {
+			return si > sj
+		}
+		if ranked[i].Count != ranked[j].Count {
+			return ranked[i].Count > ranked[j].Count
+		}
+		return ranked[i].Word < ranked[j].Word
+	})
+	return ranked
+}
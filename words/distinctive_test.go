@@ -0,0 +1,55 @@
+package words
+
+import (
+	"testing"
+
+	"millions-of-words/models"
+)
+
+func distinctiveAlbum(id, lyrics string) models.BandcampAlbumData {
+	return models.BandcampAlbumData{ID: id, Tracks: []models.BandcampTrackData{{Lyrics: lyrics}}}
+}
+
+func TestDistinctive(t *testing.T) {
+	war := distinctiveAlbum("war", "the sword the sword the blood the sword of the king")
+	love := distinctiveAlbum("love", "the heart the heart the night the love of the night")
+	sea := distinctiveAlbum("sea", "the waves the waves the night the sea of the king")
+	corpus := NewCorpus([]models.BandcampAlbumData{war, love, sea})
+
+	got := corpus.Distinctive([]models.BandcampAlbumData{war})
+	if len(got) == 0 || got[0].Word != "sword" || got[0].Count != 3 {
+		t.Fatalf("Distinctive(war) = %+v, want sword first", got)
+	}
+
+	scores := map[string]models.DistinctiveWord{}
+	for _, dw := range got {
+		scores[dw.Word] = dw
+	}
+	if the := scores["the"]; the.TFIDF != 0 || the.LogOdds >= scores["sword"].LogOdds {
+		t.Errorf("the = %+v, want no TF-IDF and a lower log-odds than sword", the)
+	}
+	if _, ok := scores["blood"]; ok {
+		t.Error("blood is used once and should not be listed")
+	}
+
+	byTFIDF := RankDistinctive(got, RankByTFIDF)
+	if byTFIDF[0].Word != "sword" || byTFIDF[len(byTFIDF)-1].Word != "the" {
+		t.Errorf("RankDistinctive(tfidf) = %+v, want sword first and the last", byTFIDF)
+	}
+}
+
+func TestDistinctiveGroupAndMissingAlbums(t *testing.T) {
+	love := distinctiveAlbum("love", "the heart the heart the night")
+	sea := distinctiveAlbum("sea", "the waves the waves the night")
+	corpus := NewCorpus([]models.BandcampAlbumData{love, sea})
+
+	if got := corpus.Distinctive([]models.BandcampAlbumData{love, sea}); len(got) != 0 {
+		t.Errorf("Distinctive(whole corpus) = %+v, want nothing to compare against", got)
+	}
+
+	disabled := distinctiveAlbum("disabled", "fire fire fire the")
+	got := corpus.Distinctive([]models.BandcampAlbumData{disabled})
+	if len(got) != 1 || got[0].Word != "fire" || got[0].LogOdds <= 0 {
+		t.Errorf("Distinctive(disabled) = %+v, want fire scored against the corpus", got)
+	}
+}
@@ -0,0 +1,341 @@
+package words
+
+import (
+	"embed"
+	"path"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"millions-of-words/models"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Undetermined is the language of lyrics that are too short or too mixed to
+// tell, using the ISO 639-2 code for exactly that.
+const Undetermined = "und"
+
+const (
+	// profileSize is how many of the most frequent trigrams make up a
+	// language profile.
+	profileSize = 300
+	// minTrigrams is the fewest distinct trigrams lyrics need before their
+	// language is guessed. A one-line chorus is not enough to go on.
+	minTrigrams = 40
+	// maxDistance is how far, as a share of the worst possible distance,
+	// lyrics may be from the closest profile and still be given its language.
+	maxDistance = 0.85
+)
+
+// Each file holds a few hundred words of ordinary prose in one language and
+// is named after its ISO 639-1 code.
+//
+//go:embed languages/*.txt
+var languageSamples embed.FS
+
+// languageProfiles maps a language code to the rank of each of its most
+// frequent trigrams, most frequent first.
+var languageProfiles, profileLanguages = loadLanguageProfiles()
+
+var languageNames = map[string]string{
+	"ar": "Arabic", "de": "German", "el": "Greek", "en": "English",
+	"es": "Spanish", "fi": "Finnish", "fr": "French", "he": "Hebrew",
+	"it": "Italian", "ja": "Japanese", "ko": "Korean", "la": "Latin",
+	"nl": "Dutch", "no": "Norwegian", "pl": "Polish", "pt": "Portuguese",
+	"ru": "Russian", "sv": "Swedish", "uk": "Ukrainian", "zh": "Chinese",
+	Undetermined: "Undetermined",
+}
+
+// LanguageName is the English name of a language code, or the code itself
+// when it is not one DetectLanguage returns.
+func LanguageName(code string) string {
+	if name, ok := languageNames[code]; ok {
+		return name
+	}
+	return code
+}
+
+func loadLanguageProfiles() (map[string]map[string]int, []string) {
+	entries, err := languageSamples.ReadDir("languages")
+	if err != nil {
+		panic(err)
+	}
+
+	profiles := make(map[string]map[string]int, len(entries))
+	var languages []string
+	for _, entry := range entries {
+		data, err := languageSamples.ReadFile(path.Join("languages", entry.Name()))
+		if err != nil {
+			panic(err)
+		}
+		profile := make(map[string]int, profileSize)
+		for rank, trigram := range rankTrigrams(string(data)) {
+			profile[trigram] = rank
+		}
+		language := strings.TrimSuffix(entry.Name(), ".txt")
+		profiles[language] = profile
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+	return profiles, languages
+}
+
+// rankTrigrams lists the most frequent letter trigrams of the text, most
+// frequent first. Every word is padded with a space on both sides so the
+// trigrams also capture how words start and end.
+func rankTrigrams(text string) []string {
+	counts := make(map[string]int)
+	for _, word := range splitLyricsIntoWords(NormalizeText(text)) {
+		word = strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) {
+				return unicode.ToLower(r)
+			}
+			return -1
+		}, word)
+		if word == "" {
+			continue
+		}
+		runes := []rune(" " + word + " ")
+		for i := 0; i+3 <= len(runes); i++ {
+			counts[string(runes[i:i+3])]++
+		}
+	}
+
+	ranked := make([]string, 0, len(counts))
+	for trigram := range counts {
+		ranked = append(ranked, trigram)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if counts[ranked[i]] != counts[ranked[j]] {
+			return counts[ranked[i]] > counts[ranked[j]]
+		}
+		return ranked[i] < ranked[j]
+	})
+	if len(ranked) > profileSize {
+		ranked = ranked[:profileSize]
+	}
+	return ranked
+}
+
+// DetectLanguage guesses the ISO 639-1 code of the language lyrics are
+// written in. Scripts used by a single language in the profiles (Greek,
+// Hangul, kana, ...) decide on their own; Latin script lyrics are compared
+// against trigram profiles of each language, as described by Cavnar and
+// Trenkle. Lyrics are normalised first, so "fancy text" counts as the plain
+// letters it is made of. It returns "" for lyrics without letters and
+// Undetermined when no language is a clear match.
+func DetectLanguage(lyrics string) string {
+	lyrics = NormalizeText(lyrics)
+	var latin, cyrillic, greek, han, kana, hangul, arabic, hebrew, total int
+	ukrainian := false
+	for _, r := range lyrics {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		total++
+		switch {
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic++
+			ukrainian = ukrainian || strings.ContainsRune("іїєґІЇЄҐ", r)
+		case unicode.Is(unicode.Greek, r):
+			greek++
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			kana++
+		case unicode.Is(unicode.Hangul, r):
+			hangul++
+		case unicode.Is(unicode.Arabic, r):
+			arabic++
+		case unicode.Is(unicode.Hebrew, r):
+			hebrew++
+		}
+	}
+
+	majority := func(n int) bool { return n*2 > total }
+	switch {
+	case total == 0:
+		return ""
+	case majority(latin):
+		return detectLatinLanguage(lyrics)
+	case kana > 0 && majority(kana+han):
+		return "ja"
+	case majority(han):
+		return "zh"
+	case majority(hangul):
+		return "ko"
+	case majority(cyrillic) && ukrainian:
+		return "uk"
+	case majority(cyrillic):
+		return "ru"
+	case majority(greek):
+		return "el"
+	case majority(arabic):
+		return "ar"
+	case majority(hebrew):
+		return "he"
+	}
+	return Undetermined
+}
+
+// detectLatinLanguage picks the language profile closest to the lyrics by
+// the out-of-place measure: how far each trigram's rank in the lyrics is from
+// its rank in the profile, with a fixed penalty for trigrams the profile does
+// not have.
+func detectLatinLanguage(lyrics string) string {
+	ranked := rankTrigrams(lyrics)
+	if len(ranked) < minTrigrams {
+		return Undetermined
+	}
+
+	best := Undetermined
+	bestDistance := int(maxDistance * float64(len(ranked)*profileSize))
+	for _, language := range profileLanguages {
+		profile := languageProfiles[language]
+		distance := 0
+		for rank, trigram := range ranked {
+			if profileRank, ok := profile[trigram]; ok {
+				distance += abs(rank - profileRank)
+			} else {
+				distance += profileSize
+			}
+		}
+		if distance < bestDistance {
+			best, bestDistance = language, distance
+		}
+	}
+	return best
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// TrackLanguage is the language stored on the track, or the one detected
+// from its lyrics for tracks saved before languages were stored.
+func TrackLanguage(track models.BandcampTrackData) string {
+	if track.Language != "" {
+		return track.Language
+	}
+	return DetectLanguage(track.Lyrics)
+}
+
+// yVowelLanguages write y as a vowel, as in Swedish "tyst" or Finnish "yö".
+// Everywhere else it is counted as a consonant, as it always was for English.
+var yVowelLanguages = map[string]bool{"fi": true, "no": true, "pl": true, "sv": true}
+
+// vowelLetters are lowercase vowels with their accents taken off: "é" and
+// "å" count as "e" and "a". Letters like "æ" and "ø" have no simpler form and
+// are listed as they are.
+var vowelLetters = map[rune]bool{
+	'a': true, 'e': true, 'i': true, 'o': true, 'u': true,
+	'æ': true, 'ø': true, 'œ': true, 'ı': true,
+	'а': true, 'е': true, 'и': true, 'о': true, 'у': true, 'ы': true,
+	'э': true, 'ю': true, 'я': true, 'і': true, 'є': true,
+	'α': true, 'ε': true, 'η': true, 'ι': true, 'ο': true, 'υ': true, 'ω': true,
+}
+
+// baseLetter lowercases a letter and takes off its accents.
+func baseLetter(r rune) rune {
+	r = unicode.ToLower(r)
+	if r < utf8.RuneSelf || r == 'й' {
+		// "й" is a consonant, but would decompose to the vowel "и".
+		return r
+	}
+	base, _ := utf8.DecodeRuneInString(norm.NFD.String(string(r)))
+	return base
+}
+
+// isAlphabetLetter reports whether a letter belongs to a script that writes
+// vowels and consonants as separate letters. Syllabaries and ideographs have
+// neither.
+func isAlphabetLetter(r rune) bool {
+	if r == 'ь' || r == 'ъ' {
+		// Cyrillic soft and hard signs are not sounds of their own.
+		return false
+	}
+	return unicode.In(r, unicode.Latin, unicode.Cyrillic, unicode.Greek)
+}
+
+// LanguageBreakdown splits the albums' tracks by language, the language with
+// the most words first. Tracks without lyrics are left out.
+func LanguageBreakdown(albums []models.BandcampAlbumData) []models.LanguageShare {
+	shares := make(map[string]*models.LanguageShare)
+	unique := make(map[string]map[string]struct{})
+	for _, album := range albums {
+		counted := make(map[string]bool)
+		for _, track := range album.Tracks {
+			language := TrackLanguage(track)
+			if language == "" {
+				continue
+			}
+			share, ok := shares[language]
+			if !ok {
+				share = &models.LanguageShare{Language: language, Name: LanguageName(language)}
+				shares[language] = share
+				unique[language] = make(map[string]struct{})
+			}
+			if !counted[language] {
+				counted[language] = true
+				share.Albums++
+			}
+			share.Tracks++
+			for _, word := range TrackWords(track.Lyrics, track.IgnoredWords) {
+				share.TotalWords++
+				unique[language][word] = struct{}{}
+			}
+		}
+	}
+
+	result := make([]models.LanguageShare, 0, len(shares))
+	for language, share := range shares {
+		share.UniqueWords = len(unique[language])
+		result = append(result, *share)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].TotalWords != result[j].TotalWords {
+			return result[i].TotalWords > result[j].TotalWords
+		}
+		return result[i].Language < result[j].Language
+	})
+	return result
+}
+
+// FilterLanguage keeps only the tracks in the language, and only the albums
+// that still have tracks left.
+func FilterLanguage(albums []models.BandcampAlbumData, language string) []models.BandcampAlbumData {
+	var filtered []models.BandcampAlbumData
+	for _, album := range albums {
+		var tracks []models.BandcampTrackData
+		for _, track := range album.Tracks {
+			if TrackLanguage(track) == language {
+				tracks = append(tracks, track)
+			}
+		}
+		if len(tracks) > 0 {
+			album.Tracks = tracks
+			filtered = append(filtered, album)
+		}
+	}
+	return filtered
+}
+
+// CorpusWordFrequencies counts every word of the albums, most frequent first.
+func CorpusWordFrequencies(albums []models.BandcampAlbumData) []models.WordCount {
+	counts := make(map[string]int)
+	for _, album := range albums {
+		for _, track := range album.Tracks {
+			for _, word := range TrackWords(track.Lyrics, track.IgnoredWords) {
+				counts[word]++
+			}
+		}
+	}
+	return MapToSortedList(counts)
+}
@@ -0,0 +1,132 @@
+package words
+
+import (
+	"reflect"
+	"testing"
+
+	"millions-of-words/models"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		lyrics string
+		want   string
+	}{
+		{"I walk alone through the fire and the flames, nothing remains of the world I knew. Blood on my hands and death in my eyes, the sky is black and the dead will rise", "en"},
+		{"Ich bin allein in der Dunkelheit, die Nacht ist kalt und das Feuer brennt. Blut an meinen Händen, Tod in meinen Augen, der Himmel ist schwarz", "de"},
+		{"Je marche seul à travers le feu et les flammes, rien ne reste du monde que je connaissais. Du sang sur mes mains et la mort dans mes yeux", "fr"},
+		{"Camino solo a través del fuego y las llamas, nada queda del mundo que conocí. Sangre en mis manos y muerte en mis ojos, el cielo es negro", "es"},
+		{"Cammino da solo attraverso il fuoco e le fiamme, niente resta del mondo che conoscevo. Sangue sulle mie mani e morte nei miei occhi", "it"},
+		{"Caminho sozinho através do fogo e das chamas, nada resta do mundo que eu conhecia. Sangue nas minhas mãos e morte nos meus olhos", "pt"},
+		{"Ik loop alleen door het vuur en de vlammen, niets blijft er over van de wereld die ik kende. Bloed aan mijn handen en de dood in mijn ogen", "nl"},
+		{"Jag vandrar ensam genom elden och lågorna, ingenting finns kvar av världen jag kände. Blod på mina händer och döden i mina ögon", "sv"},
+		{"Jeg vandrer alene gjennom ilden og flammene, ingenting er igjen av verden jeg kjente. Blod på hendene mine og døden i øynene mine", "no"},
+		{"Kuljen yksin tulen ja liekkien läpi, mitään ei ole jäljellä maailmasta jonka tunsin. Verta käsissäni ja kuolema silmissäni", "fi"},
+		{"Idę sam przez ogień i płomienie, nic nie zostało ze świata, który znałem. Krew na moich rękach i śmierć w moich oczach", "pl"},
+		{"Dies irae, dies illa, solvet saeclum in favilla. Rex tremendae maiestatis, qui salvandos salvas gratis, salva me, fons pietatis", "la"},
+		{"Я иду один сквозь огонь", "ru"},
+		{"Я йду сам крізь вогонь і полум'я", "uk"},
+		{"Σκοτάδι και φωτιά", "el"},
+		{"闇の中で燃える炎", "ja"},
+		{"黑暗中燃烧的火焰", "zh"},
+		{"𝐈 𝐰𝐚𝐥𝐤 𝐚𝐥𝐨𝐧𝐞 𝐭𝐡𝐫𝐨𝐮𝐠𝐡 𝐭𝐡𝐞 𝐟𝐢𝐫𝐞 𝐚𝐧𝐝 𝐭𝐡𝐞 𝐟𝐥𝐚𝐦𝐞𝐬, 𝐧𝐨𝐭𝐡𝐢𝐧𝐠 𝐫𝐞𝐦𝐚𝐢𝐧𝐬 𝐨𝐟 𝐭𝐡𝐞 𝐰𝐨𝐫𝐥𝐝 𝐈 𝐤𝐧𝐞𝐰. 𝐁𝐥𝐨𝐨𝐝 𝐨𝐧 𝐦𝐲 𝐡𝐚𝐧𝐝𝐬 𝐚𝐧𝐝 𝐝𝐞𝐚𝐭𝐡 𝐢𝐧 𝐦𝐲 𝐞𝐲𝐞𝐬, 𝐭𝐡𝐞 𝐬𝐤𝐲 𝐢𝐬 𝐛𝐥𝐚𝐜𝐤 𝐚𝐧𝐝 𝐭𝐡𝐞 𝐝𝐞𝐚𝐝 𝐰𝐢𝐥𝐥 𝐫𝐢𝐬𝐞", "en"},
+		{"Ⓘ ⓦⓐⓛⓚ ⓐⓛⓞⓝⓔ ⓣⓗⓡⓞⓤⓖⓗ ⓣⓗⓔ ⓕⓘⓡⓔ ⓐⓝⓓ ⓣⓗⓔ ⓕⓛⓐⓜⓔⓢ, ⓝⓞⓣⓗⓘⓝⓖ ⓡⓔⓜⓐⓘⓝⓢ ⓞⓕ ⓣⓗⓔ ⓦⓞⓡⓛⓓ Ⓘ ⓚⓝⓔⓦ. Ⓑⓛⓞⓞⓓ ⓞⓝ ⓜⓨ ⓗⓐⓝⓓⓢ ⓐⓝⓓ ⓓⓔⓐⓣⓗ ⓘⓝ ⓜⓨ ⓔⓨⓔⓢ, ⓣⓗⓔ ⓢⓚⓨ ⓘⓢ ⓑⓛⓐⓒⓚ ⓐⓝⓓ ⓣⓗⓔ ⓓⓔⓐⓓ ⓦⓘⓛⓛ ⓡⓘⓢⓔ", "en"},
+		{"hey hey hey", Undetermined},
+		{"... !!! 123", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := DetectLanguage(tt.lyrics); got != tt.want {
+			t.Errorf("DetectLanguage(%q) = %q, want %q", tt.lyrics, got, tt.want)
+		}
+	}
+}
+
+func TestTrackLanguagePrefersStoredLanguage(t *testing.T) {
+	track := models.BandcampTrackData{Lyrics: "hey", Language: "de"}
+	if got := TrackLanguage(track); got != "de" {
+		t.Errorf("TrackLanguage = %q, want the stored %q", got, "de")
+	}
+}
+
+func TestCountVowelsAndConsonants(t *testing.T) {
+	tests := []struct {
+		word       string
+		language   string
+		vowels     int
+		consonants int
+	}{
+		{"metal", "en", 2, 3},
+		{"yes", "en", 1, 2},
+		{"tyst", "sv", 1, 3},
+		{"höst", "sv", 1, 3},
+		{"förstörelse", "sv", 4, 7},
+		{"ænd", "no", 1, 2},
+		{"été", "fr", 2, 1},
+		{"тьма", "ru", 1, 2},
+		{"мой", "ru", 1, 2},
+		{"σκοτάδι", "el", 3, 4},
+		{"闇", "ja", 0, 0},
+	}
+	for _, tt := range tests {
+		vowels, consonants := countVowelsAndConsonants(tt.word, tt.language)
+		if vowels != tt.vowels || consonants != tt.consonants {
+			t.Errorf("countVowelsAndConsonants(%q, %q) = %d, %d; want %d, %d", tt.word, tt.language, vowels, consonants, tt.vowels, tt.consonants)
+		}
+	}
+}
+
+func TestTokenizeLineSplitsHanAndKana(t *testing.T) {
+	var got []string
+	for _, token := range TokenizeLine("闇の中で燃えるメタル, fire") {
+		got = append(got, token.Word)
+	}
+	want := []string{"闇", "の", "中", "で", "燃", "える", "メタル", "fire"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("TokenizeLine = %q, want %q", got, want)
+	}
+}
+
+func TestWordLengthsCountLetters(t *testing.T) {
+	_, _, _, lengths := CalculateAndSortWordFrequencies("död", "")
+	if lengths[3] != 1 {
+		t.Errorf("word lengths = %v, want one word of 3 letters", lengths)
+	}
+}
+
+var languageTestAlbums = []models.BandcampAlbumData{
+	{ID: "1", Tracks: []models.BandcampTrackData{
+		{Name: "Fire", Lyrics: "burn burn", Language: "en"},
+		{Name: "Feuer", Lyrics: "brennt brennt brennt", Language: "de"},
+		{Name: "Instrumental"},
+	}},
+	{ID: "2", Tracks: []models.BandcampTrackData{
+		{Name: "Eis", Lyrics: "kalt kalt kalt eis", Language: "de"},
+	}},
+}
+
+func TestLanguageBreakdown(t *testing.T) {
+	got := LanguageBreakdown(languageTestAlbums)
+	want := []models.LanguageShare{
+		{Language: "de", Name: "German", Albums: 2, Tracks: 2, TotalWords: 7, UniqueWords: 3},
+		{Language: "en", Name: "English", Albums: 1, Tracks: 1, TotalWords: 2, UniqueWords: 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LanguageBreakdown = %+v, want %+v", got, want)
+	}
+}
+
+func TestFilterLanguage(t *testing.T) {
+	german := FilterLanguage(languageTestAlbums, "de")
+	if len(german) != 2 || len(german[0].Tracks) != 1 || german[0].Tracks[0].Name != "Feuer" {
+		t.Errorf("FilterLanguage(de) = %+v, want Feuer and Eis on their albums", german)
+	}
+	if len(languageTestAlbums[0].Tracks) != 3 {
+		t.Error("FilterLanguage changed the albums it was given")
+	}
+
+	want := []models.WordCount{{Word: "brennt", Count: 3}, {Word: "kalt", Count: 3}, {Word: "eis", Count: 1}}
+	if got := CorpusWordFrequencies(german); !reflect.DeepEqual(got, want) {
+		t.Errorf("CorpusWordFrequencies = %v, want %v", got, want)
+	}
+}
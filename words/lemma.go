@@ -0,0 +1,144 @@
+package words
+
+import (
+	"strings"
+
+	"millions-of-words/models"
+)
+
+// irregularLemmas maps common irregular forms to their base form. Forms that
+// are also everyday nouns ("saw", "rose", "left") are left out.
+var irregularLemmas = map[string]string{
+	"ate": "eat", "began": "begin", "begun": "begin", "bitten": "bite",
+	"bled": "bleed", "blew": "blow", "blown": "blow", "bought": "buy",
+	"broke": "break", "broken": "break", "brought": "bring", "burnt": "burn",
+	"came": "come", "caught": "catch", "children": "child", "chose": "choose",
+	"chosen": "choose", "done": "do", "drank": "drink", "drawn": "draw",
+	"dreamt": "dream", "drew": "draw", "driven": "drive", "drove": "drive",
+	"drunk": "drink", "dying": "die", "eaten": "eat", "fallen": "fall",
+	"feet": "foot", "fell": "fall", "felt": "feel", "fled": "flee",
+	"flew": "fly", "flown": "fly", "forgot": "forget", "forgotten": "forget",
+	"fought": "fight", "found": "find", "froze": "freeze", "frozen": "freeze",
+	"gave": "give", "given": "give", "going": "go", "gone": "go", "got": "get",
+	"gotten": "get", "grew": "grow", "grown": "grow", "held": "hold",
+	"hid": "hide", "hidden": "hide", "kept": "keep", "knew": "know",
+	"knives": "knife", "known": "know", "laid": "lay", "led": "lead",
+	"lit": "light", "lives": "life", "lost": "lose", "lying": "lie",
+	"made": "make", "meant": "mean", "men": "man", "met": "meet",
+	"mice": "mouse", "paid": "pay", "ran": "run", "risen": "rise",
+	"rode": "ride", "said": "say", "sang": "sing", "sank": "sink",
+	"seen": "see", "sent": "send", "shook": "shake", "shot": "shoot",
+	"slain": "slay", "slept": "sleep", "slew": "slay", "sold": "sell",
+	"sought": "seek", "spoke": "speak", "spoken": "speak", "spun": "spin",
+	"stole": "steal", "stolen": "steal", "stood": "stand", "struck": "strike",
+	"sung": "sing", "sunk": "sink", "swam": "swim", "swore": "swear",
+	"sworn": "swear", "taken": "take", "taught": "teach", "teeth": "tooth",
+	"thought": "think", "threw": "throw", "thrown": "throw", "told": "tell",
+	"took": "take", "tore": "tear", "torn": "tear", "tying": "tie",
+	"woke": "wake", "woken": "wake", "wolves": "wolf", "women": "woman",
+	"won": "win", "wore": "wear", "worn": "wear", "wept": "weep",
+	"written": "write", "wrote": "write",
+}
+
+// lemmaExceptions look inflected but are words in their own right whose
+// "base" would also be in the vocabulary.
+var lemmaExceptions = map[string]bool{
+	"ceiling": true, "during": true, "evening": true, "news": true,
+	"wedding": true,
+}
+
+// Lemmatizer folds inflected forms ("burns", "burning", "burned") onto their
+// base form. Without a dictionary the suffix rules would make up words, so a
+// base form is only used when it appears in the vocabulary the lemmatizer was
+// built from.
+type Lemmatizer struct {
+	vocabulary map[string]bool
+}
+
+// NewLemmatizer builds a lemmatizer whose known words are the given
+// frequency list, normally the whole corpus.
+func NewLemmatizer(vocabulary []models.WordCount) *Lemmatizer {
+	known := make(map[string]bool, len(vocabulary))
+	for _, wc := range vocabulary {
+		known[wc.Word] = true
+	}
+	return &Lemmatizer{vocabulary: known}
+}
+
+// Lemma returns the base form of a cleaned word, or the word itself when no
+// base form is known.
+func (l *Lemmatizer) Lemma(word string) string {
+	if base, ok := irregularLemmas[word]; ok {
+		return base
+	}
+	if lemmaExceptions[word] {
+		return word
+	}
+	for _, base := range baseCandidates(word) {
+		if base != word && l.vocabulary[base] {
+			return base
+		}
+	}
+	return word
+}
+
+// Group merges the counts of words that share a lemma, most frequent first.
+func (l *Lemmatizer) Group(frequencies []models.WordCount) []models.WordCount {
+	grouped := make(map[string]int, len(frequencies))
+	for _, wc := range frequencies {
+		grouped[l.Lemma(wc.Word)] += wc.Count
+	}
+	result := MapToSortedList(grouped)
+	if result == nil {
+		result = []models.WordCount{}
+	}
+	return result
+}
+
+// baseCandidates lists possible base forms of a word, most likely first.
+func baseCandidates(word string) []string {
+	if len(word) < 4 {
+		return nil
+	}
+	if stem, ok := strings.CutSuffix(word, "'s"); ok {
+		return []string{stem}
+	}
+
+	var candidates []string
+	switch {
+	case strings.HasSuffix(word, "ies") || strings.HasSuffix(word, "ied"):
+		// cries, cried -> cry; lies, died fall through to the e rules below.
+		if stem := word[:len(word)-3]; len(stem) >= 2 {
+			candidates = append(candidates, stem+"y")
+		}
+		candidates = append(candidates, word[:len(word)-1])
+	case strings.HasSuffix(word, "ing"):
+		candidates = append(candidates, verbStems(word[:len(word)-3])...)
+	case strings.HasSuffix(word, "ed"):
+		if !strings.HasSuffix(word, "eed") {
+			candidates = append(candidates, word[:len(word)-1])
+		}
+		candidates = append(candidates, verbStems(word[:len(word)-2])...)
+	case strings.HasSuffix(word, "es"):
+		candidates = append(candidates, word[:len(word)-1], word[:len(word)-2])
+	case strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") &&
+		!strings.HasSuffix(word, "us") && !strings.HasSuffix(word, "is"):
+		candidates = append(candidates, word[:len(word)-1])
+	}
+	return candidates
+}
+
+// verbStems turns what is left after cutting -ing or -ed into possible verbs:
+// running -> run, burning -> burn, loving -> love.
+func verbStems(stem string) []string {
+	if len(stem) < 3 || !strings.ContainsAny(stem, "aeiouy") {
+		return nil
+	}
+
+	var stems []string
+	last := stem[len(stem)-1]
+	if last == stem[len(stem)-2] && !strings.ContainsRune("aeiouls", rune(last)) {
+		stems = append(stems, stem[:len(stem)-1])
+	}
+	return append(stems, stem, stem+"e")
+}
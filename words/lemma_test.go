@@ -0,0 +1,71 @@
+package words
+
+import (
+	"reflect"
+	"testing"
+
+	"millions-of-words/models"
+)
+
+func TestLemma(t *testing.T) {
+	lemmatizer := NewLemmatizer([]models.WordCount{
+		{Word: "burn"}, {Word: "love"}, {Word: "run"}, {Word: "cry"}, {Word: "fall"},
+		{Word: "flame"}, {Word: "watch"}, {Word: "die"}, {Word: "free"}, {Word: "see"},
+		{Word: "night"}, {Word: "even"},
+	})
+
+	tests := map[string]string{
+		"burns":    "burn",
+		"burning":  "burn",
+		"burned":   "burn",
+		"loving":   "love",
+		"loved":    "love",
+		"running":  "run",
+		"falling":  "fall",
+		"cries":    "cry",
+		"cried":    "cry",
+		"died":     "die",
+		"flames":   "flame",
+		"watches":  "watch",
+		"freed":    "free",
+		"night's":  "night",
+		"went":     "went",
+		"ran":      "run",
+		"seed":     "seed",
+		"evening":  "evening",
+		"nothing":  "nothing",
+		"kiss":     "kiss",
+		"darkness": "darkness",
+	}
+	for word, want := range tests {
+		if got := lemmatizer.Lemma(word); got != want {
+			t.Errorf("Lemma(%q) = %q, want %q", word, got, want)
+		}
+	}
+}
+
+func TestLemmatizerGroup(t *testing.T) {
+	frequencies := []models.WordCount{
+		{Word: "burn", Count: 2}, {Word: "fire", Count: 4}, {Word: "burning", Count: 3},
+		{Word: "burned", Count: 1}, {Word: "fires", Count: 1},
+	}
+	got := NewLemmatizer(frequencies).Group(frequencies)
+	want := []models.WordCount{{Word: "burn", Count: 6}, {Word: "fire", Count: 5}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Group() = %v, want %v", got, want)
+	}
+}
+
+func TestStopwordsFilter(t *testing.T) {
+	stop := NewStopwords([]string{"Oh,", "  "})
+	got := stop.Filter([]models.WordCount{
+		{Word: "the", Count: 9}, {Word: "oh", Count: 5}, {Word: "fire", Count: 3},
+	})
+	want := []models.WordCount{{Word: "fire", Count: 3}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Filter() = %v, want %v", got, want)
+	}
+	if IsStopword("oh") {
+		t.Error("IsStopword(oh) = true, want extra words kept out of the built-in list")
+	}
+}
@@ -0,0 +1,207 @@
+package words
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"millions-of-words/models"
+)
+
+const (
+	// MinNGramCount is how often a phrase has to occur to be listed. Phrases
+	// seen once are not recurring, and PMI rates them far too highly.
+	MinNGramCount = 2
+
+	// N-gram rankings.
+	RankByCount         = "count"
+	RankByPMI           = "pmi"
+	RankByLogLikelihood = "llr"
+)
+
+// ngramCounter collects word and phrase counts over any number of texts, so
+// tracks, albums and the corpus are all counted the same way.
+type ngramCounter struct {
+	n           int
+	words       map[string]int
+	ngrams      map[string]int
+	totalWords  int
+	totalNGrams int
+	// prefixes counts the first n-1 words of every n-gram, which the
+	// log-likelihood of a trigram is computed against.
+	prefixes map[string]int
+}
+
+func newNGramCounter(n int) *ngramCounter {
+	return &ngramCounter{
+		n:        n,
+		words:    make(map[string]int),
+		ngrams:   make(map[string]int),
+		prefixes: make(map[string]int),
+	}
+}
+
+// add counts the phrases in lyrics. Phrases never span two lines, and an
+// ignored word breaks a phrase the same way a line end does.
+func (c *ngramCounter) add(lyrics, ignoredWords string) {
+	ignored := ParseIgnoredWords(ignoredWords)
+	for _, line := range SplitLines(ignored.StripPatterns(lyrics)) {
+		var run []string
+		flush := func() {
+			for i := 0; i+c.n <= len(run); i++ {
+				gram := run[i : i+c.n]
+				if allStopwords(gram) {
+					continue
+				}
+				c.ngrams[strings.Join(gram, " ")]++
+				c.prefixes[strings.Join(gram[:c.n-1], " ")]++
+				c.totalNGrams++
+			}
+			run = run[:0]
+		}
+
+		for _, token := range TokenizeLine(line) {
+			if ignored.Contains(token.Word) {
+				flush()
+				continue
+			}
+			c.words[token.Word]++
+			c.totalWords++
+			run = append(run, token.Word)
+		}
+		flush()
+	}
+}
+
+func allStopwords(gram []string) bool {
+	for _, word := range gram {
+		if !IsStopword(word) {
+			return false
+		}
+	}
+	return true
+}
+
+// table scores every phrase seen at least MinNGramCount times and returns
+// them most frequent first.
+func (c *ngramCounter) table() []models.NGram {
+	table := []models.NGram{}
+	for phrase, count := range c.ngrams {
+		if count < MinNGramCount {
+			continue
+		}
+		gram := strings.Fields(phrase)
+		table = append(table, models.NGram{
+			Phrase:        phrase,
+			N:             c.n,
+			Count:         count,
+			PMI:           c.pmi(gram, count),
+			LogLikelihood: c.logLikelihood(gram, count),
+		})
+	}
+	return RankNGrams(table, RankByCount)
+}
+
+// pmi is the pointwise mutual information of the phrase, in bits: how much
+// more often the words occur together than they would by chance.
+func (c *ngramCounter) pmi(gram []string, count int) float64 {
+	if c.totalNGrams == 0 || c.totalWords == 0 {
+		return 0
+	}
+	expected := 1.0
+	for _, word := range gram {
+		expected *= float64(c.words[word]) / float64(c.totalWords)
+	}
+	if expected == 0 {
+		return 0
+	}
+	return math.Log2(float64(count) / float64(c.totalNGrams) / expected)
+}
+
+// logLikelihood is Dunning's G² for the phrase, treating it as its first n-1
+// words followed by the last word. Unlike PMI it does not favour rare words.
+func (c *ngramCounter) logLikelihood(gram []string, count int) float64 {
+	prefixCount := c.prefixes[strings.Join(gram[:len(gram)-1], " ")]
+	lastCount := c.words[gram[len(gram)-1]]
+	total := c.totalNGrams
+
+	k11 := float64(count)
+	k12 := float64(prefixCount - count)
+	k21 := float64(max(lastCount-count, 0))
+	k22 := float64(max(total-prefixCount-lastCount+count, 0))
+
+	return 2 * (entropyTerm(k11, k12, k21, k22) -
+		entropyTerm(k11+k12, k21+k22) -
+		entropyTerm(k11+k21, k12+k22))
+}
+
+// entropyTerm is sum(k log k) - N log N over the counts, the building block
+// of G².
+func entropyTerm(counts ...float64) float64 {
+	total := 0.0
+	sum := 0.0
+	for _, k := range counts {
+		if k > 0 {
+			sum += k * math.Log(k)
+		}
+		total += k
+	}
+	if total == 0 {
+		return 0
+	}
+	return sum - total*math.Log(total)
+}
+
+// RankNGrams returns the phrases sorted by count, PMI or log-likelihood,
+// highest first. Ties go to the more frequent and then alphabetical phrase.
+// The input is not modified.
+func RankNGrams(ngrams []models.NGram, by string) []models.NGram {
+	ranked := append([]models.NGram{}, ngrams...)
+	score := func(ng models.NGram) float64 {
+		switch by {
+		case RankByPMI:
+			return ng.PMI
+		case RankByLogLikelihood:
+			return ng.LogLikelihood
+		}
+		return float64(ng.Count)
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		si, sj := score(ranked[i]), score(ranked[j])
+		if si != sj {
+			return si > sj
+		}
+		if ranked[i].Count != ranked[j].Count {
+			return ranked[i].Count > ranked[j].Count
+		}
+		return ranked[i].Phrase < ranked[j].Phrase
+	})
+	return ranked
+}
+
+// TrackNGrams lists the recurring n-word phrases of a track.
+func TrackNGrams(track models.BandcampTrackData, n int) []models.NGram {
+	c := newNGramCounter(n)
+	c.add(track.Lyrics, track.IgnoredWords)
+	return c.table()
+}
+
+// AlbumNGrams lists the recurring n-word phrases of an album.
+func AlbumNGrams(album models.BandcampAlbumData, n int) []models.NGram {
+	c := newNGramCounter(n)
+	for _, track := range album.Tracks {
+		c.add(track.Lyrics, track.IgnoredWords)
+	}
+	return c.table()
+}
+
+// CorpusNGrams lists the recurring n-word phrases across albums.
+func CorpusNGrams(albums []models.BandcampAlbumData, n int) []models.NGram {
+	c := newNGramCounter(n)
+	for _, album := range albums {
+		for _, track := range album.Tracks {
+			c.add(track.Lyrics, track.IgnoredWords)
+		}
+	}
+	return c.table()
+}
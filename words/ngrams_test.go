@@ -0,0 +1,102 @@
+package words
+
+import (
+	"math"
+	"testing"
+
+	"millions-of-words/models"
+)
+
+const ngramLyrics = "Into the dark we fall\nInto the dark\nthe dark of the night\nof the\nin the fire"
+
+func TestTrackNGrams(t *testing.T) {
+	track := models.BandcampTrackData{Lyrics: ngramLyrics}
+
+	bigrams := TrackNGrams(track, 2)
+	if len(bigrams) != 1 || bigrams[0].Phrase != "the dark" || bigrams[0].Count != 3 {
+		t.Fatalf("TrackNGrams(2) = %+v, want only \"the dark\" three times", bigrams)
+	}
+	// 18 words, 8 bigrams that are not all stopwords, "the" 6 times, "dark" 3.
+	if want := math.Log2((3.0 / 8) / (6.0 / 18 * 3.0 / 18)); !approxEqual(bigrams[0].PMI, want) {
+		t.Errorf("PMI = %v, want %v", bigrams[0].PMI, want)
+	}
+	if bigrams[0].LogLikelihood <= 0 {
+		t.Errorf("LogLikelihood = %v, want positive", bigrams[0].LogLikelihood)
+	}
+
+	trigrams := TrackNGrams(track, 3)
+	if len(trigrams) != 1 || trigrams[0].Phrase != "into the dark" || trigrams[0].Count != 2 || trigrams[0].N != 3 {
+		t.Errorf("TrackNGrams(3) = %+v, want only \"into the dark\" twice", trigrams)
+	}
+}
+
+func TestNGramsRespectLinesAndIgnoredWords(t *testing.T) {
+	track := models.BandcampTrackData{
+		Lyrics:       "cold fire\ncold fire\nburning cold oh fire\nburning cold oh fire",
+		IgnoredWords: "oh",
+	}
+
+	got := map[string]int{}
+	for _, ng := range TrackNGrams(track, 2) {
+		got[ng.Phrase] = ng.Count
+	}
+	want := map[string]int{"cold fire": 2, "burning cold": 2}
+	if len(got) != len(want) || got["cold fire"] != 2 || got["burning cold"] != 2 {
+		t.Errorf("TrackNGrams(2) = %v, want %v", got, want)
+	}
+}
+
+func TestAlbumAndCorpusNGrams(t *testing.T) {
+	album := models.BandcampAlbumData{Tracks: []models.BandcampTrackData{
+		{Lyrics: "black sun rising"},
+		{Lyrics: "under the black sun"},
+	}}
+
+	albumBigrams := AlbumNGrams(album, 2)
+	if len(albumBigrams) != 1 || albumBigrams[0].Phrase != "black sun" || albumBigrams[0].Count != 2 {
+		t.Errorf("AlbumNGrams(2) = %+v, want \"black sun\" twice", albumBigrams)
+	}
+
+	corpus := CorpusNGrams([]models.BandcampAlbumData{album, album}, 2)
+	if corpus[0].Phrase != "black sun" || corpus[0].Count != 4 {
+		t.Errorf("CorpusNGrams(2)[0] = %+v, want \"black sun\" four times", corpus[0])
+	}
+	if empty := CorpusNGrams(nil, 3); empty == nil || len(empty) != 0 {
+		t.Errorf("CorpusNGrams(nil) = %#v, want an empty list", empty)
+	}
+}
+
+func TestLogLikelihoodFavoursCollocations(t *testing.T) {
+	// "black metal" always occurs together; "the night" is "the" followed by
+	// a word that also follows many other words.
+	lyrics := "black metal\nblack metal\nblack metal\nthe night\nthe night\n" +
+		"a night\nsome night\nthe day\nthe war\nthe end\nthe sky"
+	ranked := RankNGrams(TrackNGrams(models.BandcampTrackData{Lyrics: lyrics}, 2), RankByLogLikelihood)
+	if ranked[0].Phrase != "black metal" {
+		t.Errorf("top collocation = %q, want \"black metal\" (%+v)", ranked[0].Phrase, ranked)
+	}
+}
+
+func TestRankNGrams(t *testing.T) {
+	ngrams := []models.NGram{
+		{Phrase: "b", Count: 5, PMI: 1, LogLikelihood: 9},
+		{Phrase: "a", Count: 5, PMI: 3, LogLikelihood: 2},
+		{Phrase: "c", Count: 2, PMI: 7, LogLikelihood: 4},
+	}
+
+	order := func(ranked []models.NGram) string {
+		s := ""
+		for _, ng := range ranked {
+			s += ng.Phrase
+		}
+		return s
+	}
+	for by, want := range map[string]string{RankByCount: "abc", RankByPMI: "cab", RankByLogLikelihood: "bca"} {
+		if got := order(RankNGrams(ngrams, by)); got != want {
+			t.Errorf("RankNGrams(%s) = %s, want %s", by, got, want)
+		}
+	}
+	if order(ngrams) != "bac" {
+		t.Error("RankNGrams modified its input")
+	}
+}
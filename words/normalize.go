@@ -0,0 +1,83 @@
+package words
+
+import (
+	"html"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// invisibleCharacters have no width and only get in the way of matching
+// words: zero-width spaces and joiners, the word joiner, byte order marks and
+// soft hyphens.
+var invisibleCharacters = strings.NewReplacer(
+	"\u200b", "", "\u200c", "", "\u200d", "", "\u2060", "", "\ufeff", "", "\u00ad", "", "\u034f", "",
+)
+
+// punctuationVariants unifies typographic quotes and dashes. Apostrophes and
+// single quotes become ', double quotes become ", hyphens and the minus sign
+// become -, and every longer dash becomes an em dash, which stays a word
+// separator where a hyphen would join two words.
+var punctuationVariants = strings.NewReplacer(
+	"‘", "'", "’", "'", "‚", "'", "‛", "'", "′", "'", "ʼ", "'", "´", "'", "`", "'",
+	"“", `"`, "”", `"`, "„", `"`, "‟", `"`, "″", `"`, "«", `"`, "»", `"`,
+	"‐", "-", "‑", "-", "−", "-",
+	"‒", "—", "–", "—", "―", "—", "⸺", "—",
+)
+
+// smallCapitals are the letters "fancy text" generators use for small caps.
+// Unicode does not count them as forms of ordinary letters, so NFKC leaves
+// them alone.
+var smallCapitals = map[rune]rune{
+	'ᴀ': 'a', 'ʙ': 'b', 'ᴄ': 'c', 'ᴅ': 'd', 'ᴇ': 'e', 'ꜰ': 'f', 'ɢ': 'g',
+	'ʜ': 'h', 'ɪ': 'i', 'ᴊ': 'j', 'ᴋ': 'k', 'ʟ': 'l', 'ᴍ': 'm', 'ɴ': 'n',
+	'ᴏ': 'o', 'ᴘ': 'p', 'ꞯ': 'q', 'ʀ': 'r', 'ꜱ': 's', 'ᴛ': 't', 'ᴜ': 'u',
+	'ᴠ': 'v', 'ᴡ': 'w', 'ʏ': 'y', 'ᴢ': 'z',
+}
+
+// plainLetter maps the fancy letters NFKC does not fold: small capitals and
+// the negative circled and squared capitals (🅐, 🅰).
+func plainLetter(r rune) rune {
+	switch {
+	case r >= 0x1f150 && r <= 0x1f169:
+		return 'A' + r - 0x1f150
+	case r >= 0x1f170 && r <= 0x1f189:
+		return 'A' + r - 0x1f170
+	}
+	if plain, ok := smallCapitals[r]; ok {
+		return plain
+	}
+	return r
+}
+
+// NormalizeText cleans up scraped lyrics so that the same word is always
+// written the same way:
+//
+//   - HTML entities are decoded, also when escaped twice;
+//   - zero-width characters and soft hyphens are removed;
+//   - typographic quotes, apostrophes and dashes are unified;
+//   - "fancy text" is folded to plain letters: NFKC takes care of fullwidth
+//     forms, ligatures and every Mathematical Alphanumeric style (bold,
+//     italic, script, fraktur, double-struck, monospace, ...), and small
+//     capitals and negative enclosed letters are mapped by hand.
+//
+// It is applied when lyrics are imported or edited, and again before they are
+// analysed so lyrics stored before it existed are counted the same way.
+// Normalising twice gives the same result as normalising once.
+func NormalizeText(text string) string {
+	// Some lyrics were escaped more than once on their way to Bandcamp
+	// ("&amp;#39;"). Every pass that changes the text makes it shorter.
+	for strings.ContainsRune(text, '&') {
+		unescaped := html.UnescapeString(text)
+		if unescaped == text {
+			break
+		}
+		text = unescaped
+	}
+	text = invisibleCharacters.Replace(text)
+	text = punctuationVariants.Replace(text)
+	text = strings.Map(plainLetter, text)
+	text = norm.NFKC.String(text)
+	// Folding can itself produce a quote variant, as "ŉ" becomes "ʼn".
+	return punctuationVariants.Replace(text)
+}
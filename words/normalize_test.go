@@ -0,0 +1,72 @@
+package words
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Each testdata/normalize/*.txt file holds lyrics as they come off Bandcamp,
+// and the .golden file next to it the same lyrics after normalisation.
+func TestNormalizeTextGolden(t *testing.T) {
+	inputs, err := filepath.Glob(filepath.Join("testdata", "normalize", "*.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) == 0 {
+		t.Fatal("no normalisation test files found")
+	}
+
+	for _, input := range inputs {
+		name := strings.TrimSuffix(filepath.Base(input), ".txt")
+		t.Run(name, func(t *testing.T) {
+			raw, err := os.ReadFile(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden, err := os.ReadFile(strings.TrimSuffix(input, ".txt") + ".golden")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := NormalizeText(string(raw))
+			gotLines := strings.Split(got, "\n")
+			wantLines := strings.Split(string(golden), "\n")
+			if len(gotLines) != len(wantLines) {
+				t.Fatalf("NormalizeText gave %d lines, want %d:\n%s", len(gotLines), len(wantLines), got)
+			}
+			for i := range wantLines {
+				if gotLines[i] != wantLines[i] {
+					t.Errorf("line %d = %q, want %q", i+1, gotLines[i], wantLines[i])
+				}
+			}
+
+			if again := NormalizeText(got); again != got {
+				t.Errorf("normalising twice changed the text:\n%q\n%q", got, again)
+			}
+		})
+	}
+}
+
+func TestNormalizedLyricsCountTheSameWords(t *testing.T) {
+	plain, _, _, _ := CalculateAndSortWordFrequencies("I'm burning in the fire", "")
+	fancy, _, _, _ := CalculateAndSortWordFrequencies("𝐈’𝐦 ʙᴜʀɴɪɴɢ in\u200b the ｆｉｒｅ", "")
+	if len(fancy) != len(plain) {
+		t.Fatalf("fancy lyrics counted %v, want %v", fancy, plain)
+	}
+	for i := range plain {
+		if fancy[i] != plain[i] {
+			t.Errorf("fancy lyrics counted %v, want %v", fancy, plain)
+			break
+		}
+	}
+}
+
+func TestIgnoredPatternsMatchFancyLyrics(t *testing.T) {
+	ignored := ParseIgnoredWords("[Ｃｈｏｒｕｓ]")
+	got := ignored.StripPatterns("[𝐂𝐡𝐨𝐫𝐮𝐬]\nfire")
+	if got != "\nfire" {
+		t.Errorf("StripPatterns = %q, want the fancy chorus marker removed", got)
+	}
+}
@@ -0,0 +1,122 @@
+package pos
+
+import (
+	"strings"
+	"sync"
+
+	"millions-of-words/models"
+	"millions-of-words/words"
+
+	"github.com/jdkato/prose/v2"
+)
+
+var (
+	modelOnce sync.Once
+	model     *prose.Model
+
+	// trackCache holds AnalyzeTrack results by album ID and track name. It
+	// only grows with the number of tracks; ClearCache empties it when an
+	// album changes.
+	trackCache sync.Map
+)
+
+// Loading the perceptron tagger is expensive, so it is done once and shared.
+func taggingModel() *prose.Model {
+	modelOnce.Do(func() {
+		doc, _ := prose.NewDocument("", prose.WithSegmentation(false), prose.WithExtraction(false))
+		model = doc.Model
+	})
+	return model
+}
+
+// Analyze tags the text and counts words per category, skipping ignored words.
+func Analyze(text, ignoredWords string) models.POSCounts {
+	var counts models.POSCounts
+	if strings.TrimSpace(text) == "" {
+		return counts
+	}
+
+	ignored := words.ParseIgnoredWords(ignoredWords)
+	text = ignored.StripPatterns(words.NormalizeText(text))
+
+	doc, err := prose.NewDocument(text,
+		prose.WithSegmentation(false),
+		prose.WithExtraction(false),
+		prose.UsingModel(taggingModel()))
+	if err != nil {
+		return counts
+	}
+
+	for _, tok := range doc.Tokens() {
+		cleaned := words.CleanWord(tok.Text)
+		if cleaned == "" || strings.HasPrefix(cleaned, "'") || ignored.Contains(cleaned) {
+			continue
+		}
+		addTag(&counts, tok.Tag)
+	}
+	return counts
+}
+
+// addTag buckets a Penn Treebank tag into our categories.
+func addTag(counts *models.POSCounts, tag string) {
+	counts.Total++
+	switch {
+	case strings.HasPrefix(tag, "NN"):
+		counts.Nouns++
+	case strings.HasPrefix(tag, "VB"), tag == "MD":
+		counts.Verbs++
+	case strings.HasPrefix(tag, "JJ"):
+		counts.Adjectives++
+	case tag == "PRP", tag == "PRP$":
+		counts.Pronouns++
+	case tag == "WP", tag == "WP$":
+		counts.Pronouns++
+		counts.QuestionWords++
+	case tag == "WDT", tag == "WRB":
+		counts.QuestionWords++
+	}
+}
+
+type trackKey struct {
+	albumID, trackName string
+}
+
+// AnalyzeTrack is Analyze with a per-track cache.
+func AnalyzeTrack(albumID string, track models.BandcampTrackData) models.POSCounts {
+	key := trackKey{albumID, track.Name}
+	if cached, ok := trackCache.Load(key); ok {
+		return cached.(models.POSCounts)
+	}
+
+	counts := Analyze(track.Lyrics, track.IgnoredWords)
+	trackCache.Store(key, counts)
+	return counts
+}
+
+func AnalyzeAlbum(album models.BandcampAlbumData) models.POSCounts {
+	var total models.POSCounts
+	for _, track := range album.Tracks {
+		total = Add(total, AnalyzeTrack(album.ID, track))
+	}
+	return total
+}
+
+// ClearCache forgets every cached track analysis. Call it whenever lyrics
+// or ignored words change.
+func ClearCache() {
+	trackCache.Range(func(key, _ interface{}) bool {
+		trackCache.Delete(key)
+		return true
+	})
+}
+
+func Add(a, b models.POSCounts) models.POSCounts {
+	return models.POSCounts{
+		Nouns:         a.Nouns + b.Nouns,
+		Verbs:         a.Verbs + b.Verbs,
+		Adjectives:    a.Adjectives + b.Adjectives,
+		Pronouns:      a.Pronouns + b.Pronouns,
+		QuestionWords: a.QuestionWords + b.QuestionWords,
+		Total:         a.Total + b.Total,
+	}
+}
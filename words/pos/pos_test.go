@@ -0,0 +1,63 @@
+package pos
+
+import (
+	"testing"
+
+	"millions-of-words/models"
+)
+
+func TestAnalyze(t *testing.T) {
+	got := Analyze("The wind blows through empty halls. Who remembers his name?", "")
+	want := models.POSCounts{
+		Nouns:         3, // wind, halls, name
+		Verbs:         2, // blows, remembers
+		Adjectives:    1,
+		Pronouns:      2,
+		QuestionWords: 1,
+		Total:         10,
+	}
+
+	if got != want {
+		t.Errorf("Analyze() = %+v, want %+v", got, want)
+	}
+}
+
+func TestAnalyzeIgnoredWords(t *testing.T) {
+	without := Analyze("[Chorus] fire burns the sky", "")
+	with := Analyze("[Chorus] fire burns the sky", "[Chorus], sky")
+
+	if with.Total != without.Total-2 {
+		t.Errorf("Total with ignored words = %d, want %d", with.Total, without.Total-2)
+	}
+}
+
+func TestAnalyzeEmpty(t *testing.T) {
+	if counts := Analyze("   ", ""); counts != (models.POSCounts{}) {
+		t.Errorf("Analyze of blank text = %+v, want zero counts", counts)
+	}
+}
+
+func TestAnalyzeTrackIsCached(t *testing.T) {
+	track := models.BandcampTrackData{Name: "Song", Lyrics: "we ride into the night"}
+
+	first := AnalyzeTrack("album", track)
+	second := AnalyzeTrack("album", track)
+	if first != second {
+		t.Errorf("cached result %+v differs from first result %+v", second, first)
+	}
+
+	album := models.BandcampAlbumData{ID: "album", Tracks: []models.BandcampTrackData{track, track}}
+	if got := AnalyzeAlbum(album); got.Total != first.Total*2 {
+		t.Errorf("AnalyzeAlbum Total = %d, want %d", got.Total, first.Total*2)
+	}
+
+	// Edited lyrics are only picked up once the cache is cleared.
+	track.Lyrics = "night"
+	if got := AnalyzeTrack("album", track); got != first {
+		t.Errorf("AnalyzeTrack before ClearCache = %+v, want the cached %+v", got, first)
+	}
+	ClearCache()
+	if got := AnalyzeTrack("album", track); got.Total != 1 {
+		t.Errorf("AnalyzeTrack after ClearCache counted %d words, want 1", got.Total)
+	}
+}
@@ -0,0 +1,193 @@
+package profanity
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"millions-of-words/models"
+	"millions-of-words/words"
+)
+
+// DefaultTerms seed an empty lexicon. They lean towards words that are
+// unambiguous in lyrics; "hell" and "damnation" are left out on purpose, since
+// metal lyrics use them literally far more often than as swearing.
+var DefaultTerms = []models.ProfanityTerm{
+	{Word: "fuck*", Category: "sexual"},
+	{Word: "motherfuck*", Category: "sexual"},
+	{Word: "cunt*", Category: "sexual"},
+	{Word: "cock", Category: "sexual"},
+	{Word: "cocks", Category: "sexual"},
+	{Word: "dick", Category: "sexual"},
+	{Word: "dicks", Category: "sexual"},
+	{Word: "pussy", Category: "sexual"},
+	{Word: "whore*", Category: "sexual"},
+	{Word: "slut*", Category: "sexual"},
+	{Word: "shit*", Category: "scatological"},
+	{Word: "bullshit*", Category: "scatological"},
+	{Word: "piss*", Category: "scatological"},
+	{Word: "crap*", Category: "scatological"},
+	{Word: "turd*", Category: "scatological"},
+	{Word: "goddamn*", Category: "blasphemous"},
+	{Word: "damn*", Category: "blasphemous"},
+	{Word: "bitch*", Category: "insult"},
+	{Word: "bastard*", Category: "insult"},
+	{Word: "asshole*", Category: "insult"},
+	{Word: "arsehole*", Category: "insult"},
+	{Word: "dickhead*", Category: "insult"},
+	{Word: "wanker*", Category: "insult"},
+	{Word: "twat*", Category: "insult"},
+}
+
+// Lexicon matches cleaned words against a list of profanity terms. It is
+// read-only once built, so one Lexicon can be shared between requests.
+type Lexicon struct {
+	exact    map[string]string
+	prefixes []models.ProfanityTerm
+}
+
+// NormalizeTerm cleans a term the same way lyrics are cleaned, keeping a
+// trailing * for prefix matches, and checks it has a word and a category.
+func NormalizeTerm(term models.ProfanityTerm) (models.ProfanityTerm, error) {
+	word := strings.TrimSpace(term.Word)
+	prefix := strings.HasSuffix(word, "*")
+	word = words.CleanWord(strings.TrimSuffix(word, "*"))
+	if word == "" {
+		return term, fmt.Errorf("profanity term needs a word")
+	}
+	if prefix {
+		word += "*"
+	}
+
+	category := strings.ToLower(strings.TrimSpace(term.Category))
+	if category == "" {
+		return term, fmt.Errorf("profanity term %q needs a category", word)
+	}
+
+	term.Word = word
+	term.Category = category
+	return term, nil
+}
+
+// NewLexicon builds a lexicon from terms. Invalid terms are skipped. When a
+// word matches several terms, an exact term wins over a prefix, and a longer
+// prefix wins over a shorter one, so "motherfuck*" can have its own category
+// next to "fuck*".
+func NewLexicon(terms []models.ProfanityTerm) *Lexicon {
+	lexicon := &Lexicon{exact: make(map[string]string)}
+	for _, term := range terms {
+		term, err := NormalizeTerm(term)
+		if err != nil {
+			continue
+		}
+		if strings.HasSuffix(term.Word, "*") {
+			term.Word = strings.TrimSuffix(term.Word, "*")
+			lexicon.prefixes = append(lexicon.prefixes, term)
+		} else {
+			lexicon.exact[term.Word] = term.Category
+		}
+	}
+
+	sort.SliceStable(lexicon.prefixes, func(i, j int) bool {
+		return len(lexicon.prefixes[i].Word) > len(lexicon.prefixes[j].Word)
+	})
+	return lexicon
+}
+
+// Category returns the category of a word, which is cleaned first. Matching
+// is per word, so "scrap" is not caught by "crap*" and "Dickens" is not a
+// "dick".
+func (l *Lexicon) Category(word string) (string, bool) {
+	if l == nil {
+		return "", false
+	}
+	word = words.CleanWord(word)
+	if word == "" {
+		return "", false
+	}
+	if category, ok := l.exact[word]; ok {
+		return category, true
+	}
+	for _, prefix := range l.prefixes {
+		if strings.HasPrefix(word, prefix.Word) {
+			return prefix.Category, true
+		}
+	}
+	return "", false
+}
+
+// CountFrequencies counts the profanities in a word frequency list, such as
+// the corpus-wide list kept by the lyrics index.
+func (l *Lexicon) CountFrequencies(frequencies []models.WordCount) models.ProfanityCounts {
+	byCategory := make(map[string]int)
+	byWord := make(map[string]int)
+	total := 0
+	for _, wc := range frequencies {
+		category, ok := l.Category(wc.Word)
+		if !ok {
+			continue
+		}
+		byCategory[category] += wc.Count
+		byWord[wc.Word] += wc.Count
+		total += wc.Count
+	}
+
+	counts := models.ProfanityCounts{Total: total}
+	if total == 0 {
+		return counts
+	}
+	for _, cc := range words.MapToSortedList(byCategory) {
+		counts.Categories = append(counts.Categories, models.CategoryCount{Category: cc.Word, Count: cc.Count})
+	}
+	counts.Words = words.MapToSortedList(byWord)
+	return counts
+}
+
+// Count counts the profanities in a sequence of cleaned words.
+func (l *Lexicon) Count(tokens []string) models.ProfanityCounts {
+	counts := make(map[string]int)
+	for _, token := range tokens {
+		counts[token]++
+	}
+	return l.CountFrequencies(words.MapToSortedList(counts))
+}
+
+// Track counts profanities in the track's lyrics. Ignored words are left out,
+// as they are everywhere else.
+func (l *Lexicon) Track(track models.BandcampTrackData) models.ProfanityCounts {
+	return l.Count(words.TrackWords(track.Lyrics, track.IgnoredWords))
+}
+
+func (l *Lexicon) Album(album models.BandcampAlbumData) models.ProfanityCounts {
+	var tokens []string
+	for _, track := range album.Tracks {
+		tokens = append(tokens, words.TrackWords(track.Lyrics, track.IgnoredWords)...)
+	}
+	return l.Count(tokens)
+}
+
+// Corpus counts profanities across albums. Callers that already have the
+// corpus word frequencies should use CountFrequencies instead.
+func (l *Lexicon) Corpus(albums []models.BandcampAlbumData) models.ProfanityCounts {
+	var tokens []string
+	for _, album := range albums {
+		for _, track := range album.Tracks {
+			tokens = append(tokens, words.TrackWords(track.Lyrics, track.IgnoredWords)...)
+		}
+	}
+	return l.Count(tokens)
+}
+
+// Categories lists the distinct categories of terms, sorted, for admin forms.
+func Categories(terms []models.ProfanityTerm) []string {
+	seen := make(map[string]bool)
+	var categories []string
+	for _, term := range terms {
+		if term.Category != "" && !seen[term.Category] {
+			seen[term.Category] = true
+			categories = append(categories, term.Category)
+		}
+	}
+	sort.Strings(categories)
+	return categories
+}
@@ -0,0 +1,122 @@
+package profanity
+
+import (
+	"reflect"
+	"testing"
+
+	"millions-of-words/models"
+)
+
+var testTerms = []models.ProfanityTerm{
+	{Word: "fuck*", Category: "sexual"},
+	{Word: "Motherfuck*", Category: " Insult "},
+	{Word: "shit*", Category: "scatological"},
+	{Word: "crap*", Category: "scatological"},
+	{Word: "dick", Category: "sexual"},
+	{Word: "", Category: "sexual"},
+	{Word: "damn", Category: ""},
+}
+
+func TestCategory(t *testing.T) {
+	lexicon := NewLexicon(testTerms)
+
+	tests := []struct {
+		word     string
+		category string
+		ok       bool
+	}{
+		{"fuck", "sexual", true},
+		{"Fucking,", "sexual", true},
+		{"motherfucker", "insult", true},
+		{"shitstorm", "scatological", true},
+		{"dick", "sexual", true},
+		{"dickens", "", false},
+		{"scrap", "", false},
+		{"damn", "", false},
+		{"fire", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		category, ok := lexicon.Category(tt.word)
+		if category != tt.category || ok != tt.ok {
+			t.Errorf("Category(%q) = %q, %v, want %q, %v", tt.word, category, ok, tt.category, tt.ok)
+		}
+	}
+}
+
+func TestTrackCounts(t *testing.T) {
+	lexicon := NewLexicon(testTerms)
+	track := models.BandcampTrackData{
+		Lyrics:       "Fuck the scrap heap\nShit, fucking shit\nMotherfucker in the crap",
+		IgnoredWords: "heap",
+	}
+
+	want := models.ProfanityCounts{
+		Total: 6,
+		Categories: []models.CategoryCount{
+			{Category: "scatological", Count: 3},
+			{Category: "sexual", Count: 2},
+			{Category: "insult", Count: 1},
+		},
+		Words: []models.WordCount{
+			{Word: "shit", Count: 2},
+			{Word: "crap", Count: 1},
+			{Word: "fuck", Count: 1},
+			{Word: "fucking", Count: 1},
+			{Word: "motherfucker", Count: 1},
+		},
+	}
+	if got := lexicon.Track(track); !reflect.DeepEqual(got, want) {
+		t.Errorf("Track() = %+v, want %+v", got, want)
+	}
+}
+
+func TestAlbumAndCorpusCounts(t *testing.T) {
+	lexicon := NewLexicon(testTerms)
+	album := models.BandcampAlbumData{Tracks: []models.BandcampTrackData{
+		{Lyrics: "fuck this"},
+		{Lyrics: "shit and fuck"},
+		{Lyrics: "clean lyrics", IgnoredWords: "fuck"},
+	}}
+
+	got := lexicon.Album(album)
+	if got.Total != 3 {
+		t.Errorf("Album().Total = %d, want 3", got.Total)
+	}
+	if corpus := lexicon.Corpus([]models.BandcampAlbumData{album, album}); corpus.Total != 6 {
+		t.Errorf("Corpus().Total = %d, want 6", corpus.Total)
+	}
+
+	clean := lexicon.Count([]string{"nothing", "to", "see"})
+	if !reflect.DeepEqual(clean, models.ProfanityCounts{}) {
+		t.Errorf("Count() of clean words = %+v, want zero value", clean)
+	}
+}
+
+func TestNormalizeTerm(t *testing.T) {
+	got, err := NormalizeTerm(models.ProfanityTerm{Word: " Bastard* ", Category: "Insult"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (models.ProfanityTerm{Word: "bastard*", Category: "insult"}); got != want {
+		t.Errorf("NormalizeTerm() = %+v, want %+v", got, want)
+	}
+
+	for _, term := range []models.ProfanityTerm{{Word: "*", Category: "insult"}, {Word: "bastard"}} {
+		if _, err := NormalizeTerm(term); err == nil {
+			t.Errorf("NormalizeTerm(%+v) expected an error", term)
+		}
+	}
+}
+
+func TestDefaultTermsAreValid(t *testing.T) {
+	for _, term := range DefaultTerms {
+		normalized, err := NormalizeTerm(term)
+		if err != nil {
+			t.Errorf("default term %+v: %v", term, err)
+		} else if normalized != term {
+			t.Errorf("default term %+v is not normalized, want %+v", term, normalized)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package words
+
+import (
+	"strings"
+
+	"millions-of-words/models"
+)
+
+// complexWordSyllables is the Gunning fog cut-off for a "complex" word.
+const complexWordSyllables = 3
+
+// Readability counts syllables in the lyrics and scores them with the Flesch
+// reading ease, Flesch-Kincaid grade and Gunning fog formulas.
+func Readability(lyrics, ignoredWords string) models.Readability {
+	var r models.Readability
+	ignored := ParseIgnoredWords(ignoredWords)
+
+	for _, line := range SplitLines(ignored.StripPatterns(lyrics)) {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		lineWords := 0
+		for _, token := range TokenizeLine(line) {
+			if ignored.Contains(token.Word) {
+				continue
+			}
+			syllables := CountSyllables(token.Word)
+			r.Syllables += syllables
+			if syllables >= complexWordSyllables {
+				r.ComplexWords++
+			}
+			lineWords++
+		}
+		if lineWords > 0 {
+			r.Words += lineWords
+			r.Lines++
+		}
+	}
+
+	return scoreReadability(r)
+}
+
+func TrackReadability(track models.BandcampTrackData) models.Readability {
+	return Readability(track.Lyrics, track.IgnoredWords)
+}
+
+// AlbumReadability adds up the counts of every track and scores the total,
+// so long tracks weigh more than short ones.
+func AlbumReadability(album models.BandcampAlbumData) models.Readability {
+	var total models.Readability
+	for _, track := range album.Tracks {
+		r := TrackReadability(track)
+		total.Words += r.Words
+		total.Lines += r.Lines
+		total.Syllables += r.Syllables
+		total.ComplexWords += r.ComplexWords
+	}
+	return scoreReadability(total)
+}
+
+func scoreReadability(r models.Readability) models.Readability {
+	if r.Words == 0 || r.Lines == 0 {
+		return models.Readability{}
+	}
+
+	wordsPerLine := float64(r.Words) / float64(r.Lines)
+	r.SyllablesPerWord = float64(r.Syllables) / float64(r.Words)
+	r.SyllablesPerLine = float64(r.Syllables) / float64(r.Lines)
+	r.FleschReadingEase = 206.835 - 1.015*wordsPerLine - 84.6*r.SyllablesPerWord
+	r.FleschKincaidGrade = 0.39*wordsPerLine + 11.8*r.SyllablesPerWord - 15.59
+	r.GunningFog = 0.4 * (wordsPerLine + 100*float64(r.ComplexWords)/float64(r.Words))
+	return r
+}
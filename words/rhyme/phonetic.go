@@ -0,0 +1,325 @@
+package rhyme
+
+import (
+	"strings"
+)
+
+// Spelling is rewritten into a rough phonetic form before rhyme keys are
+// taken. Lowercase letters are consonants and short vowels; these uppercase
+// placeholders stand for vowel sounds English spells in several ways.
+const (
+	longA = 'A' // name, rain, day
+	longE = 'E' // see, sea, scene, me, happy
+	longI = 'I' // fire, night, sky, die
+	longO = 'O' // home, road, go, show
+	longU = 'U' // moon, blue, new, tune
+	ow    = 'W' // out, now, loud
+	oy    = 'Y' // boy, coin
+	aw    = 'Q' // law, cause, saw
+)
+
+// irregular words whose spelling says little about how they sound. Values
+// are already in phonetic form.
+var irregular = map[string]string{
+	"eye":      "I",
+	"eyes":     "Is",
+	"lie":      "lI",
+	"lies":     "lIs",
+	"die":      "dI",
+	"dies":     "dIs",
+	"tie":      "tI",
+	"pie":      "pI",
+	"do":       "dU",
+	"to":       "tU",
+	"too":      "tU",
+	"two":      "tU",
+	"who":      "hU",
+	"you":      "yU",
+	"through":  "thrU",
+	"though":   "thO",
+	"although": "althO",
+	"were":     "wer",
+	"are":      "ar",
+	"have":     "hav",
+	"give":     "giv",
+	"live":     "liv",
+	"love":     "luv",
+	"above":    "abuv",
+	"dove":     "duv",
+	"come":     "kum",
+	"some":     "sum",
+	"done":     "dun",
+	"none":     "nun",
+	"one":      "wun",
+	"gone":     "gon",
+	"own":      "On",
+	"blood":    "blud",
+	"flood":    "flud",
+	"again":    "agen",
+	"said":     "sed",
+	"heart":    "hart",
+	"there":    "thAr",
+	"where":    "wAr",
+	"great":    "grAt",
+	"break":    "brAk",
+	"they":     "thA",
+	"key":      "kE",
+	"could":    "kud",
+	"would":    "wud",
+	"should":   "shud",
+	"word":     "werd",
+	"world":    "werld",
+	"work":     "werk",
+	"soul":     "sOl",
+	"whole":    "hOl",
+	"head":     "hed",
+	"dead":     "ded",
+	"bread":    "bred",
+	"dread":    "dred",
+	"death":    "deth",
+	"breath":   "breth",
+	"read":     "rEd",
+	"wind":     "wind",
+	"mind":     "mInd",
+	"kind":     "kInd",
+	"find":     "fInd",
+	"blind":    "blInd",
+	"behind":   "behInd",
+	"wild":     "wIld",
+	"child":    "chIld",
+	"cold":     "kOld",
+	"old":      "Old",
+	"hold":     "hOld",
+	"told":     "tOld",
+	"gold":     "gOld",
+	"bold":     "bOld",
+	"both":     "bOth",
+	"most":     "mOst",
+	"host":     "hOst",
+	"ghost":    "gOst",
+	"lost":     "lQst",
+	"cost":     "kQst",
+}
+
+// ow is long o in "show" but rhymes with "out" in these.
+var owAsInNow = map[string]bool{
+	"now": true, "how": true, "cow": true, "vow": true, "wow": true,
+	"allow": true, "brow": true, "plow": true, "bow": true, "somehow": true,
+	"down": true, "town": true, "crown": true, "drown": true, "frown": true,
+	"gown": true, "clown": true, "brown": true, "howl": true, "growl": true,
+	"prowl": true, "owl": true, "crowd": true, "power": true, "tower": true,
+	"flower": true, "devour": true, "cower": true, "shower": true,
+}
+
+// phonetic rewrites a cleaned word into the rough phonetic alphabet above.
+func phonetic(word string) string {
+	w := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' {
+			return r
+		}
+		return -1
+	}, strings.ToLower(word))
+	if w == "" {
+		return ""
+	}
+	if p, ok := irregular[w]; ok {
+		return p
+	}
+
+	if owAsInNow[w] {
+		w = strings.Replace(w, "ow", string(ow), 1)
+	}
+	// "sign", "align", "design": the g is silent and the i is long.
+	if strings.HasSuffix(w, "ign") {
+		w = w[:len(w)-3] + string(longI) + "n"
+	}
+	// A final ey is long e after another vowel ("money") and long a on its
+	// own ("grey").
+	if strings.HasSuffix(w, "ey") {
+		if countVowels(w[:len(w)-2]) > 0 {
+			w = w[:len(w)-2] + string(longE)
+		} else {
+			w = w[:len(w)-2] + string(longA)
+		}
+	}
+
+	for _, r := range []struct{ from, to string }{
+		{"tion", "shun"},
+		{"sion", "shun"},
+		{"ight", "It"},
+		{"igh", "I"},
+		{"ough", "Q"},
+		{"augh", "Q"},
+		{"ph", "f"},
+		{"ck", "k"},
+		{"dge", "j"},
+		{"qu", "kw"},
+		{"wh", "w"},
+		{"ee", "E"},
+		{"ea", "E"},
+		{"ai", "A"},
+		{"ay", "A"},
+		{"ei", "A"},
+		{"ey", "A"},
+		{"oa", "O"},
+		{"oe", "O"},
+		{"ow", "O"},
+		{"oo", "U"},
+		{"ew", "U"},
+		{"ue", "U"},
+		{"ui", "U"},
+		{"ou", string(ow)},
+		{"oi", string(oy)},
+		{"oy", string(oy)},
+		{"au", string(aw)},
+		{"aw", string(aw)},
+	} {
+		w = strings.ReplaceAll(w, r.from, r.to)
+	}
+
+	for _, prefix := range []string{"kn", "wr", "gn"} {
+		if strings.HasPrefix(w, prefix) {
+			w = w[1:]
+		}
+	}
+	if strings.HasSuffix(w, "mb") {
+		w = w[:len(w)-1]
+	}
+
+	return finalVowels(magicE(w))
+}
+
+var longForm = map[byte]byte{'a': longA, 'e': longE, 'i': longI, 'o': longO, 'u': longU}
+
+// magicE turns vowel + consonant + e ("fire", "home", "tune") into a long
+// vowel and drops the e. A trailing s or d is kept: "fires", "named".
+func magicE(w string) string {
+	suffix := ""
+	if n := len(w); n > 4 && (w[n-1] == 's' || w[n-1] == 'd') && w[n-2] == 'e' {
+		suffix = w[n-1:]
+		w = w[:n-1]
+	}
+
+	n := len(w)
+	if n >= 3 && w[n-1] == 'e' && isConsonant(w[n-2]) {
+		if long, ok := longForm[w[n-3]]; ok {
+			return w[:n-3] + string(long) + string(soften(w[n-2])) + suffix
+		}
+	}
+	return w + suffix
+}
+
+// soften gives c and g their sound before an e: "ice", "rage".
+func soften(c byte) byte {
+	switch c {
+	case 'c':
+		return 's'
+	case 'g':
+		return 'j'
+	}
+	return c
+}
+
+// finalVowels handles open final syllables: "sky" and "my" end in a long i,
+// "happy" in a long e, and "me", "go" are long when they are the only vowel.
+func finalVowels(w string) string {
+	n := len(w)
+	if n == 0 {
+		return w
+	}
+
+	last := w[n-1]
+	onlyVowel := countVowels(w[:n-1]) == 0
+
+	switch {
+	case last == 'y' && n > 1 && isConsonant(w[n-2]):
+		if onlyVowel {
+			return w[:n-1] + string(longI)
+		}
+		return w[:n-1] + string(longE)
+	case last == 'e' && onlyVowel && n > 1:
+		return w[:n-1] + string(longE)
+	case last == 'o' && onlyVowel:
+		return w[:n-1] + string(longO)
+	case last == 'i' && onlyVowel:
+		return w[:n-1] + string(longI)
+	case last == 'e' && n > 2 && isConsonant(w[n-2]):
+		// A silent e that magicE could not use, as in "dance" or "serve".
+		return w[:n-2] + string(soften(w[n-2]))
+	}
+	return w
+}
+
+func isVowel(b byte) bool {
+	return strings.IndexByte("aeiouAEIOUWYQ", b) >= 0
+}
+
+func isConsonant(b byte) bool {
+	return b >= 'a' && b <= 'z' && !isVowel(b) && b != 'y'
+}
+
+func countVowels(w string) int {
+	count := 0
+	for i := 0; i < len(w); i++ {
+		if isVowel(w[i]) {
+			count++
+		}
+	}
+	return count
+}
+
+// Key is the part of a word that has to match for two words to rhyme: the
+// last vowel sound and everything after it. Words without a vowel sound have
+// no key.
+func Key(word string) string {
+	p := phonetic(word)
+
+	last := -1
+	for i := len(p) - 1; i >= 0; i-- {
+		if isVowel(p[i]) {
+			last = i
+			break
+		}
+	}
+	if last < 0 {
+		return ""
+	}
+
+	return string(p[last]) + normalizeCoda(p[last+1:])
+}
+
+// normalizeCoda merges consonant spellings that sound alike and collapses
+// doubled letters, so "kill" and "hill", or "back" and "attack", line up.
+func normalizeCoda(coda string) string {
+	var b strings.Builder
+	var last byte
+	for i := 0; i < len(coda); i++ {
+		c := coda[i]
+		switch c {
+		case 'c', 'q':
+			c = 'k'
+		case 'z':
+			c = 's'
+		case 'y':
+			continue
+		case 'h':
+			// Only sh, ch, th and ph carry an h sound worth keeping.
+			if last != 's' && last != 'k' && last != 't' && last != 'p' {
+				continue
+			}
+		}
+		if c == last {
+			continue
+		}
+		b.WriteByte(c)
+		last = c
+	}
+	return b.String()
+}
+
+// Rhymes reports whether two words rhyme. Identical words count, since a
+// repeated line ending still holds a rhyme scheme together.
+func Rhymes(a, b string) bool {
+	keyA := Key(a)
+	return keyA != "" && keyA == Key(b)
+}
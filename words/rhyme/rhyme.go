@@ -0,0 +1,201 @@
+package rhyme
+
+import (
+	"sort"
+	"strings"
+
+	"millions-of-words/models"
+	"millions-of-words/words"
+)
+
+// rhymeWindow is how many lines either side of a line ending are searched
+// for a rhyme when measuring density. Stanza breaks are ignored, because a
+// lot of lyrics are pasted without them.
+const rhymeWindow = 4
+
+// Named stanza patterns. Anything else is shown as just its letters.
+var schemeNames = map[string]string{
+	"AA":   "couplet",
+	"AAAA": "monorhyme",
+	"AABB": "couplets",
+	"ABAB": "alternate",
+	"ABBA": "enclosed",
+	"ABCB": "ballad",
+	"AABA": "rubaiyat",
+	"ABAC": "alternate (half)",
+	"AAA":  "triplet",
+	"ABA":  "tercet",
+}
+
+type line struct {
+	words []string
+	key   string
+}
+
+// Analyze labels the rhyme scheme of every stanza, then measures how many
+// line endings rhyme and how many rhymes fall inside lines.
+func Analyze(lyrics, ignoredWords string) models.RhymeAnalysis {
+	var analysis models.RhymeAnalysis
+	ignored := words.ParseIgnoredWords(ignoredWords)
+
+	var stanzas [][]line
+	var current []line
+	for _, text := range words.SplitLines(ignored.StripPatterns(lyrics)) {
+		var lineWords []string
+		for _, token := range words.TokenizeLine(text) {
+			if !ignored.Contains(token.Word) {
+				lineWords = append(lineWords, token.Word)
+			}
+		}
+
+		if len(lineWords) == 0 {
+			if strings.TrimSpace(text) == "" && len(current) > 0 {
+				stanzas = append(stanzas, current)
+				current = nil
+			}
+			continue
+		}
+		current = append(current, line{words: lineWords, key: Key(lineWords[len(lineWords)-1])})
+	}
+	if len(current) > 0 {
+		stanzas = append(stanzas, current)
+	}
+
+	var all []line
+	for _, stanza := range stanzas {
+		analysis.Schemes = append(analysis.Schemes, scheme(stanza))
+		all = append(all, stanza...)
+	}
+
+	analysis.Lines = len(all)
+	for i, l := range all {
+		if rhymesNearby(all, i) {
+			analysis.RhymedLines++
+		}
+		analysis.InternalRhymes += internalRhymes(l.words)
+	}
+
+	analysis.SchemeCounts = countSchemes(analysis.Schemes)
+	return finish(analysis)
+}
+
+// scheme gives each line a letter; lines that rhyme share a letter.
+func scheme(stanza []line) string {
+	letters := make(map[string]byte)
+	next := byte('A')
+
+	var b strings.Builder
+	for _, l := range stanza {
+		letter, ok := letters[l.key]
+		if !ok || l.key == "" {
+			letter = next
+			if next < 'Z' {
+				next++
+			}
+			if l.key != "" {
+				letters[l.key] = letter
+			}
+		}
+		b.WriteByte(letter)
+	}
+	return b.String()
+}
+
+func rhymesNearby(lines []line, i int) bool {
+	if lines[i].key == "" {
+		return false
+	}
+	for j := i - rhymeWindow; j <= i+rhymeWindow; j++ {
+		if j != i && j >= 0 && j < len(lines) && lines[j].key == lines[i].key {
+			return true
+		}
+	}
+	return false
+}
+
+// internalRhymes counts words that rhyme with a later word in the same line.
+// Repeating a word is not a rhyme here, and one-letter words are skipped.
+func internalRhymes(lineWords []string) int {
+	count := 0
+	for i := 0; i < len(lineWords)-1; i++ {
+		if len(lineWords[i]) < 2 {
+			continue
+		}
+		key := Key(lineWords[i])
+		if key == "" {
+			continue
+		}
+		for _, later := range lineWords[i+1:] {
+			if later != lineWords[i] && len(later) >= 2 && Key(later) == key {
+				count++
+				break
+			}
+		}
+	}
+	return count
+}
+
+// countSchemes tallies stanza schemes of two or more lines, most common first.
+func countSchemes(schemes []string) []models.SchemeCount {
+	counts := make(map[string]int)
+	for _, s := range schemes {
+		if len(s) >= 2 {
+			counts[s]++
+		}
+	}
+
+	result := make([]models.SchemeCount, 0, len(counts))
+	for s, count := range counts {
+		result = append(result, models.SchemeCount{Scheme: s, Count: count})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Scheme < result[j].Scheme
+	})
+	return result
+}
+
+// finish fills in the fields derived from the counts.
+func finish(analysis models.RhymeAnalysis) models.RhymeAnalysis {
+	if analysis.Lines > 0 {
+		analysis.RhymeDensity = float64(analysis.RhymedLines) / float64(analysis.Lines)
+		analysis.InternalRhymeDensity = float64(analysis.InternalRhymes) / float64(analysis.Lines)
+	}
+	if len(analysis.SchemeCounts) > 0 {
+		analysis.DominantScheme = analysis.SchemeCounts[0].Scheme
+		analysis.SchemeName = Name(analysis.DominantScheme)
+	}
+	return analysis
+}
+
+// Name describes a scheme like "AABB" as "couplets". Schemes without a name
+// are returned as they are.
+func Name(scheme string) string {
+	if name, ok := schemeNames[scheme]; ok {
+		return name
+	}
+	return scheme
+}
+
+func AnalyzeTrack(track models.BandcampTrackData) models.RhymeAnalysis {
+	return Analyze(track.Lyrics, track.IgnoredWords)
+}
+
+// AnalyzeAlbum adds up the tracks. SchemeCounts then counts stanzas across the
+// whole album, and the dominant scheme is the album's most common stanza.
+func AnalyzeAlbum(album models.BandcampAlbumData) models.RhymeAnalysis {
+	var total models.RhymeAnalysis
+	var schemes []string
+	for _, track := range album.Tracks {
+		a := AnalyzeTrack(track)
+		total.Lines += a.Lines
+		total.RhymedLines += a.RhymedLines
+		total.InternalRhymes += a.InternalRhymes
+		schemes = append(schemes, a.Schemes...)
+	}
+
+	total.SchemeCounts = countSchemes(schemes)
+	return finish(total)
+}
@@ -0,0 +1,124 @@
+package rhyme
+
+import (
+	"reflect"
+	"testing"
+
+	"millions-of-words/models"
+)
+
+func TestRhymes(t *testing.T) {
+	pairs := []struct {
+		a, b string
+		want bool
+	}{
+		{"fire", "desire", true},
+		{"fire", "fires", false},
+		{"night", "light", true},
+		{"night", "bite", true},
+		{"sky", "die", true},
+		{"eye", "cry", true},
+		{"me", "sea", true},
+		{"me", "eternity", true},
+		{"free", "agree", true},
+		{"home", "alone", false},
+		{"stone", "alone", true},
+		{"go", "below", true},
+		{"now", "vow", true},
+		{"now", "show", false},
+		{"down", "crown", true},
+		{"blue", "true", true},
+		{"moon", "tune", true},
+		{"back", "attack", true},
+		{"kill", "will", true},
+		{"dance", "chance", true},
+		{"dance", "bank", false},
+		{"rain", "pain", true},
+		{"name", "flame", true},
+		{"blood", "flood", true},
+		{"love", "above", true},
+		{"love", "move", false},
+		{"nation", "station", true},
+		{"money", "honey", true},
+		{"grey", "day", true},
+		{"cold", "old", true},
+		{"night", "day", false},
+		{"line", "align", true},
+		{"1999", "night", false},
+	}
+
+	for _, p := range pairs {
+		if got := Rhymes(p.a, p.b); got != p.want {
+			t.Errorf("Rhymes(%q, %q) = %v, want %v (keys %q, %q)", p.a, p.b, got, p.want, Key(p.a), Key(p.b))
+		}
+	}
+}
+
+func TestAnalyzeSchemes(t *testing.T) {
+	lyrics := `The night is cold
+The story told
+Into the fire
+Our last desire
+
+I walk the line
+You cross the sea
+The stars align
+Eternity`
+
+	got := Analyze(lyrics, "")
+	if want := []string{"AABB", "ABAB"}; !reflect.DeepEqual(got.Schemes, want) {
+		t.Errorf("Schemes = %v, want %v", got.Schemes, want)
+	}
+	if got.Lines != 8 || got.RhymedLines != 8 {
+		t.Errorf("Lines = %d, RhymedLines = %d, want 8 and 8", got.Lines, got.RhymedLines)
+	}
+	if got.RhymeDensity != 1 {
+		t.Errorf("RhymeDensity = %v, want 1", got.RhymeDensity)
+	}
+}
+
+func TestAnalyzeDensityAndInternalRhymes(t *testing.T) {
+	lyrics := "Fight the night with all your might\nWe walk alone\nNothing here\nBurn burn burn"
+
+	got := Analyze(lyrics, "")
+	if got.RhymedLines != 0 {
+		t.Errorf("RhymedLines = %d, want 0", got.RhymedLines)
+	}
+	// fight -> night, night -> might. Repeated "burn" is not a rhyme.
+	if got.InternalRhymes != 2 {
+		t.Errorf("InternalRhymes = %d, want 2", got.InternalRhymes)
+	}
+	if got.InternalRhymeDensity != 0.5 {
+		t.Errorf("InternalRhymeDensity = %v, want 0.5", got.InternalRhymeDensity)
+	}
+}
+
+func TestAnalyzeIgnoredWords(t *testing.T) {
+	got := Analyze("[Chorus]\nI see the light\nInto the night yeah", "[Chorus], yeah")
+	if want := []string{"AA"}; !reflect.DeepEqual(got.Schemes, want) {
+		t.Errorf("Schemes = %v, want %v", got.Schemes, want)
+	}
+	if got.SchemeName != "couplet" {
+		t.Errorf("SchemeName = %q, want %q", got.SchemeName, "couplet")
+	}
+}
+
+func TestAnalyzeAlbum(t *testing.T) {
+	album := models.BandcampAlbumData{Tracks: []models.BandcampTrackData{
+		{Lyrics: "cold\ntold\nfire\ndesire"},
+		{Lyrics: "rain\npain\nstone\nalone\n\nline\nsea\nalign\nme"},
+		{Lyrics: "Instrumental"},
+	}}
+
+	got := AnalyzeAlbum(album)
+	want := []models.SchemeCount{{Scheme: "AABB", Count: 2}, {Scheme: "ABAB", Count: 1}}
+	if !reflect.DeepEqual(got.SchemeCounts, want) {
+		t.Errorf("SchemeCounts = %+v, want %+v", got.SchemeCounts, want)
+	}
+	if got.DominantScheme != "AABB" || got.SchemeName != "couplets" {
+		t.Errorf("DominantScheme = %q (%q), want AABB (couplets)", got.DominantScheme, got.SchemeName)
+	}
+	if got.Lines != 13 {
+		t.Errorf("Lines = %d, want 13", got.Lines)
+	}
+}
@@ -0,0 +1,160 @@
+package words
+
+import (
+	"math"
+
+	"millions-of-words/models"
+)
+
+const (
+	// MATTRWindow is the window size for the moving-average type-token ratio.
+	MATTRWindow = 50
+
+	// mtldThreshold is the TTR at which MTLD closes a factor (McCarthy & Jarvis).
+	mtldThreshold = 0.72
+)
+
+// LexicalRichness computes vocabulary richness measures for a sequence of
+// cleaned words, in the order they appear.
+func LexicalRichness(tokens []string) models.LexicalRichness {
+	var richness models.LexicalRichness
+	n := len(tokens)
+	if n == 0 {
+		return richness
+	}
+
+	counts := make(map[string]int)
+	for _, token := range tokens {
+		counts[token]++
+	}
+
+	// frequencySpectrum[i] is how many words occur exactly i times.
+	frequencySpectrum := make(map[int]int)
+	for _, count := range counts {
+		frequencySpectrum[count]++
+	}
+
+	v := len(counts)
+	richness.Tokens = n
+	richness.Types = v
+	richness.TTR = float64(v) / float64(n)
+	richness.MATTR = movingAverageTTR(tokens, MATTRWindow)
+	richness.MTLD = mtld(tokens)
+	richness.HapaxLegomena = frequencySpectrum[1]
+	richness.HapaxDislegomena = frequencySpectrum[2]
+
+	sum := 0.0
+	for i, vi := range frequencySpectrum {
+		sum += float64(i*i) * float64(vi)
+	}
+	richness.YulesK = 10000 * (sum - float64(n)) / (float64(n) * float64(n))
+
+	// Honoré's R divides by zero when every word is a hapax, which happens
+	// for very short texts; leave it at zero rather than report infinity.
+	if richness.HapaxLegomena < v {
+		richness.Honore = 100 * math.Log(float64(n)) / (1 - float64(richness.HapaxLegomena)/float64(v))
+	}
+
+	return richness
+}
+
+// movingAverageTTR averages the TTR of every window of the given size. Texts
+// shorter than the window fall back to the plain TTR.
+func movingAverageTTR(tokens []string, window int) float64 {
+	if len(tokens) <= window {
+		return float64(len(distinct(tokens))) / float64(len(tokens))
+	}
+
+	counts := make(map[string]int)
+	for _, token := range tokens[:window] {
+		counts[token]++
+	}
+
+	total := float64(len(counts))
+	windows := 1
+	for i := window; i < len(tokens); i++ {
+		outgoing := tokens[i-window]
+		counts[outgoing]--
+		if counts[outgoing] == 0 {
+			delete(counts, outgoing)
+		}
+		counts[tokens[i]]++
+
+		total += float64(len(counts))
+		windows++
+	}
+
+	return total / float64(windows) / float64(window)
+}
+
+// mtld is the measure of textual lexical diversity: the mean length of runs
+// of words that keep the TTR above the threshold, averaged over a forward and
+// a backward pass.
+func mtld(tokens []string) float64 {
+	reversed := make([]string, len(tokens))
+	for i, token := range tokens {
+		reversed[len(tokens)-1-i] = token
+	}
+	return (mtldPass(tokens) + mtldPass(reversed)) / 2
+}
+
+func mtldPass(tokens []string) float64 {
+	factors := 0.0
+	seen := make(map[string]bool)
+	length := 0
+
+	for _, token := range tokens {
+		length++
+		seen[token] = true
+		if float64(len(seen))/float64(length) <= mtldThreshold {
+			factors++
+			seen = make(map[string]bool)
+			length = 0
+		}
+	}
+
+	// What is left over counts as the fraction of a factor it got through.
+	if length > 0 {
+		ttr := float64(len(seen)) / float64(length)
+		factors += (1 - ttr) / (1 - mtldThreshold)
+	}
+
+	if factors == 0 {
+		return float64(len(tokens))
+	}
+	return float64(len(tokens)) / factors
+}
+
+func distinct(tokens []string) map[string]bool {
+	seen := make(map[string]bool, len(tokens))
+	for _, token := range tokens {
+		seen[token] = true
+	}
+	return seen
+}
+
+func TrackLexicalRichness(track models.BandcampTrackData) models.LexicalRichness {
+	return LexicalRichness(TrackWords(track.Lyrics, track.IgnoredWords))
+}
+
+// AlbumLexicalRichness treats the album as one text, tracks in album order.
+func AlbumLexicalRichness(album models.BandcampAlbumData) models.LexicalRichness {
+	return LexicalRichness(albumWords(album))
+}
+
+// ArtistLexicalRichness treats all of an artist's albums as one text.
+func ArtistLexicalRichness(albums []models.BandcampAlbumData) models.LexicalRichness {
+	var tokens []string
+	for _, album := range albums {
+		tokens = append(tokens, albumWords(album)...)
+	}
+	return LexicalRichness(tokens)
+}
+
+func albumWords(album models.BandcampAlbumData) []string {
+	var tokens []string
+	for _, track := range album.Tracks {
+		tokens = append(tokens, TrackWords(track.Lyrics, track.IgnoredWords)...)
+	}
+	return tokens
+}
@@ -0,0 +1,101 @@
+package words
+
+import (
+	"math"
+	"testing"
+
+	"millions-of-words/models"
+)
+
+func approxEqual(a, b float64) bool {
+	return math.Abs(a-b) < 0.001
+}
+
+func TestLexicalRichness(t *testing.T) {
+	got := LexicalRichness([]string{"a", "a", "b", "c"})
+
+	if got.Tokens != 4 || got.Types != 3 || got.HapaxLegomena != 2 || got.HapaxDislegomena != 1 {
+		t.Errorf("counts = %+v, want 4 tokens, 3 types, 2 hapax legomena, 1 hapax dislegomenon", got)
+	}
+
+	floats := []struct {
+		name      string
+		got, want float64
+	}{
+		{"TTR", got.TTR, 0.75},
+		{"MATTR", got.MATTR, 0.75},
+		{"MTLD", got.MTLD, (4 + 4/(0.25/0.28)) / 2},
+		{"YulesK", got.YulesK, 1250},
+		{"Honore", got.Honore, 300 * math.Log(4)},
+	}
+	for _, f := range floats {
+		if !approxEqual(f.got, f.want) {
+			t.Errorf("%s = %v, want %v", f.name, f.got, f.want)
+		}
+	}
+}
+
+func TestLexicalRichnessEdgeCases(t *testing.T) {
+	if got := LexicalRichness(nil); got != (models.LexicalRichness{}) {
+		t.Errorf("LexicalRichness(nil) = %+v, want zero value", got)
+	}
+
+	allHapax := LexicalRichness([]string{"one", "two", "three"})
+	if allHapax.Honore != 0 || math.IsInf(allHapax.Honore, 0) {
+		t.Errorf("Honore with only hapax legomena = %v, want 0", allHapax.Honore)
+	}
+	if allHapax.TTR != 1 {
+		t.Errorf("TTR with no repeats = %v, want 1", allHapax.TTR)
+	}
+}
+
+func TestMovingAverageTTR(t *testing.T) {
+	// Windows of two: [a a] [a b] [b b] -> 1/2, 2/2, 1/2.
+	if got := movingAverageTTR([]string{"a", "a", "b", "b"}, 2); !approxEqual(got, 2.0/3) {
+		t.Errorf("movingAverageTTR = %v, want %v", got, 2.0/3)
+	}
+}
+
+func TestRichnessIsLengthIndependent(t *testing.T) {
+	verse := []string{"the", "cold", "wind", "blows", "through", "empty", "halls", "of", "stone", "and", "the", "night", "falls"}
+
+	var short, long []string
+	for i := 0; i < 5; i++ {
+		short = append(short, verse...)
+	}
+	for i := 0; i < 50; i++ {
+		long = append(long, verse...)
+	}
+
+	s, l := LexicalRichness(short), LexicalRichness(long)
+	if l.TTR >= s.TTR/5 {
+		t.Errorf("expected plain TTR to collapse with length: short %v, long %v", s.TTR, l.TTR)
+	}
+	if !approxEqual(s.MATTR, l.MATTR) {
+		t.Errorf("MATTR changed with length: short %v, long %v", s.MATTR, l.MATTR)
+	}
+	if math.Abs(s.YulesK-l.YulesK)/l.YulesK > 0.25 {
+		t.Errorf("Yule's K changed with length: short %v, long %v", s.YulesK, l.YulesK)
+	}
+}
+
+func TestTrackWordsMatchesFrequencies(t *testing.T) {
+	lyrics := "[Chorus] Fire, FIRE!\nwalk with me"
+	ignored := "[Chorus], me"
+
+	tokens := TrackWords(lyrics, ignored)
+	want := []string{"fire", "fire", "walk", "with"}
+	if len(tokens) != len(want) {
+		t.Fatalf("TrackWords = %v, want %v", tokens, want)
+	}
+	for i := range want {
+		if tokens[i] != want[i] {
+			t.Errorf("TrackWords[%d] = %q, want %q", i, tokens[i], want[i])
+		}
+	}
+
+	album := models.BandcampAlbumData{Tracks: []models.BandcampTrackData{{Lyrics: lyrics, IgnoredWords: ignored}}}
+	if got := AlbumLexicalRichness(album); got.Tokens != 4 || got.Types != 3 {
+		t.Errorf("AlbumLexicalRichness = %+v, want 4 tokens and 3 types", got)
+	}
+}
@@ -0,0 +1,220 @@
+package words
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"millions-of-words/models"
+)
+
+// maxRepeats caps "(x99)" style notation, which is more likely a typo or a
+// lyric than a section sung 99 times.
+const maxRepeats = 20
+
+var (
+	// bracketedRepeat is repeat notation in brackets at the end of a line:
+	// "(x2)", "[3x]", "(×4)".
+	bracketedRepeat = regexp.MustCompile(`(?i)[(\[]\s*(?:[x×]\s*(\d{1,2})|(\d{1,2})\s*[x×])\s*[)\]]\s*$`)
+	// bareRepeat is the same without brackets. It must stand apart from the
+	// words before it and use a lower-case x, so "Malcolm X2" is still a lyric.
+	bareRepeat = regexp.MustCompile(`(?:^|\s)(?:[x×](\d{1,2})|(\d{1,2})[x×])\s*$`)
+	// sectionName is how a section marker starts, e.g. "Chorus", "Verse 2".
+	sectionName = regexp.MustCompile(`(?i)^(?:pre-?chorus|chorus|verse|bridge|intro|outro|refrain|hook|solo|interlude|breakdown|spoken)\b`)
+	// plainSectionName is a marker written without brackets, which is only
+	// taken as one when the line holds nothing else.
+	plainSectionName = regexp.MustCompile(`(?i)^(?:pre-?chorus|chorus|verse|bridge|intro|outro|refrain|hook|solo|interlude|breakdown)(?:\s*\d+)?$`)
+)
+
+// splitRepeat takes repeat notation off the end of a line. It returns the
+// rest of the line and the number of repeats, 1 when there is none.
+func splitRepeat(line string) (string, int) {
+	for _, pattern := range []*regexp.Regexp{bracketedRepeat, bareRepeat} {
+		match := pattern.FindStringSubmatchIndex(line)
+		if match == nil {
+			continue
+		}
+		var digits string
+		if match[2] >= 0 {
+			digits = line[match[2]:match[3]]
+		} else {
+			digits = line[match[4]:match[5]]
+		}
+		n, err := strconv.Atoi(digits)
+		if err != nil || n < 1 || n > maxRepeats {
+			return line, 1
+		}
+		return strings.TrimSpace(line[:match[0]]), n
+	}
+	return line, 1
+}
+
+// parseMarker recognises a line that starts a section, such as "[Chorus]",
+// "[Verse 2 x2]", "(Chorus)" or "Bridge:". Anything in square brackets is a
+// marker; parentheses and bare words only count when they name a section,
+// so sung asides like "(burn!)" stay lyrics.
+func parseMarker(line string) (label string, repeats int, ok bool) {
+	switch {
+	case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+		label, repeats = splitRepeat(strings.TrimSpace(line[1 : len(line)-1]))
+		return label, repeats, label != ""
+	case strings.HasPrefix(line, "(") && strings.HasSuffix(line, ")"):
+		label, repeats = splitRepeat(strings.TrimSpace(line[1 : len(line)-1]))
+		return label, repeats, sectionName.MatchString(label)
+	default:
+		label, repeats = splitRepeat(strings.TrimSpace(strings.TrimSuffix(line, ":")))
+		return label, repeats, plainSectionName.MatchString(label)
+	}
+}
+
+// ParseSections splits lyrics into sections at blank lines and section
+// markers. A marker on its own, like a second "[Chorus]" with no words under
+// it, stands for the earlier section with the same label. Repeat notation on a
+// line of its own repeats the section it closes; at the end of a line with
+// words it repeats just that line. Sections with the same words as an earlier
+// one point back at it through RepeatOf.
+func ParseSections(lyrics string) []models.LyricsSection {
+	var sections []models.LyricsSection
+	current := models.LyricsSection{Repeats: 1, RepeatOf: -1}
+
+	flush := func() {
+		next := models.LyricsSection{Repeats: 1, RepeatOf: -1}
+		switch {
+		case len(current.Lines) > 0:
+			sections = append(sections, current)
+		case current.Label != "":
+			if i := findSection(sections, current.Label); i >= 0 {
+				current.Lines = sections[i].Lines
+				current.RepeatOf = i
+				sections = append(sections, current)
+			} else {
+				// The words follow after a blank line.
+				next.Label, next.Repeats = current.Label, current.Repeats
+			}
+		}
+		current = next
+	}
+
+	for _, raw := range SplitLines(lyrics) {
+		line := strings.TrimSpace(raw)
+		if line == "" {
+			flush()
+			continue
+		}
+
+		if rest, n := splitRepeat(line); rest == "" && n > 1 {
+			switch {
+			case len(current.Lines) > 0:
+				current.Repeats *= n
+				flush()
+			case current.Label != "":
+				current.Repeats *= n
+			case len(sections) > 0:
+				sections[len(sections)-1].Repeats *= n
+			}
+			continue
+		}
+
+		if label, n, ok := parseMarker(line); ok {
+			flush()
+			current.Label, current.Repeats = label, n
+			continue
+		}
+
+		text, n := splitRepeat(line)
+		if text == "" {
+			text, n = line, 1
+		}
+		current.Lines = append(current.Lines, models.LyricsLine{Text: text, Repeats: n})
+	}
+	flush()
+
+	markRepeatedSections(sections)
+	return sections
+}
+
+// findSection returns the index of the first section with the label, or -1.
+func findSection(sections []models.LyricsSection, label string) int {
+	for i, section := range sections {
+		if strings.EqualFold(section.Label, label) {
+			return i
+		}
+	}
+	return -1
+}
+
+// markRepeatedSections points every section that has the same words as an
+// earlier one back at the first of them. Case and punctuation do not matter.
+func markRepeatedSections(sections []models.LyricsSection) {
+	first := make(map[string]int)
+	for i := range sections {
+		if sections[i].RepeatOf >= 0 {
+			continue
+		}
+		var words []string
+		for _, line := range sections[i].Lines {
+			for _, token := range TokenizeLine(line.Text) {
+				words = append(words, token.Word)
+			}
+		}
+		key := strings.Join(words, " ")
+		if key == "" {
+			continue
+		}
+		if j, ok := first[key]; ok {
+			sections[i].RepeatOf = j
+		} else {
+			first[key] = i
+		}
+	}
+}
+
+// SectionRepetition counts the words and lines of parsed lyrics with every
+// repeat sung out, and with each distinct section sung once.
+func SectionRepetition(sections []models.LyricsSection, ignoredWords string) models.Repetition {
+	ignored := ParseIgnoredWords(ignoredWords)
+
+	var repetition models.Repetition
+	for _, section := range sections {
+		if section.RepeatOf >= 0 {
+			repetition.RepeatedSections++
+		}
+		for _, line := range section.Lines {
+			words := len(ignored.tokens(line.Text))
+			repetition.WordsWithRepeats += words * line.Repeats * section.Repeats
+			repetition.LinesWithRepeats += line.Repeats * section.Repeats
+			if section.RepeatOf < 0 {
+				repetition.WordsWithoutRepeats += words
+				repetition.LinesWithoutRepeats++
+			}
+		}
+	}
+
+	if repetition.WordsWithRepeats > 0 {
+		repetition.Ratio = 1 - float64(repetition.WordsWithoutRepeats)/float64(repetition.WordsWithRepeats)
+	}
+	return repetition
+}
+
+// TrackRepetition parses a track's lyrics into sections and counts them.
+func TrackRepetition(track models.BandcampTrackData) ([]models.LyricsSection, models.Repetition) {
+	sections := ParseSections(track.Lyrics)
+	return sections, SectionRepetition(sections, track.IgnoredWords)
+}
+
+// AlbumRepetition adds up the repetition of every track on the album.
+func AlbumRepetition(album models.BandcampAlbumData) models.Repetition {
+	var total models.Repetition
+	for _, track := range album.Tracks {
+		_, repetition := TrackRepetition(track)
+		total.WordsWithRepeats += repetition.WordsWithRepeats
+		total.WordsWithoutRepeats += repetition.WordsWithoutRepeats
+		total.LinesWithRepeats += repetition.LinesWithRepeats
+		total.LinesWithoutRepeats += repetition.LinesWithoutRepeats
+		total.RepeatedSections += repetition.RepeatedSections
+	}
+	if total.WordsWithRepeats > 0 {
+		total.Ratio = 1 - float64(total.WordsWithoutRepeats)/float64(total.WordsWithRepeats)
+	}
+	return total
+}
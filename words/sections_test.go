@@ -0,0 +1,172 @@
+package words
+
+import (
+	"math"
+	"testing"
+
+	"millions-of-words/models"
+)
+
+func TestSplitRepeat(t *testing.T) {
+	tests := []struct {
+		line    string
+		rest    string
+		repeats int
+	}{
+		{"Burn it down (x4)", "Burn it down", 4},
+		{"Burn it down(x4)", "Burn it down", 4},
+		{"Burn it down [2x]", "Burn it down", 2},
+		{"Burn it down x3", "Burn it down", 3},
+		{"(×2)", "", 2},
+		{"x2", "", 2},
+		{"Malcolm X2", "Malcolm X2", 1},
+		{"Burn it down (x99)", "Burn it down (x99)", 1},
+		{"No repeats here", "No repeats here", 1},
+	}
+	for _, tt := range tests {
+		rest, repeats := splitRepeat(tt.line)
+		if rest != tt.rest || repeats != tt.repeats {
+			t.Errorf("splitRepeat(%q) = %q, %d; want %q, %d", tt.line, rest, repeats, tt.rest, tt.repeats)
+		}
+	}
+}
+
+func TestParseMarker(t *testing.T) {
+	tests := []struct {
+		line    string
+		label   string
+		repeats int
+		ok      bool
+	}{
+		{"[Chorus]", "Chorus", 1, true},
+		{"[Verse 2]", "Verse 2", 1, true},
+		{"[Chorus x2]", "Chorus", 2, true},
+		{"[Guitar Solo]", "Guitar Solo", 1, true},
+		{"(Chorus)", "Chorus", 1, true},
+		{"(burn!)", "", 0, false},
+		{"Bridge:", "Bridge", 1, true},
+		{"Chorus", "Chorus", 1, true},
+		{"Chorus of the damned", "", 0, false},
+	}
+	for _, tt := range tests {
+		label, repeats, ok := parseMarker(tt.line)
+		if ok != tt.ok || (ok && (label != tt.label || repeats != tt.repeats)) {
+			t.Errorf("parseMarker(%q) = %q, %d, %v; want %q, %d, %v", tt.line, label, repeats, ok, tt.label, tt.repeats, tt.ok)
+		}
+	}
+}
+
+const structuredLyrics = `[Verse 1]
+Cold wind blows
+Through empty halls
+
+[Chorus]
+Burn it down (x2)
+Let it fall
+
+[Verse 2]
+Night is long
+
+[Chorus]
+
+Burn it down
+Burn it down
+Let it fall
+(x2)`
+
+func TestParseSections(t *testing.T) {
+	sections := ParseSections(structuredLyrics)
+
+	if len(sections) != 5 {
+		t.Fatalf("got %d sections, want 5: %+v", len(sections), sections)
+	}
+
+	want := []struct {
+		label    string
+		lines    int
+		repeats  int
+		repeatOf int
+	}{
+		{"Verse 1", 2, 1, -1},
+		{"Chorus", 2, 1, -1},
+		{"Verse 2", 1, 1, -1},
+		{"Chorus", 2, 1, 1},
+		{"", 3, 2, -1},
+	}
+	for i, w := range want {
+		got := sections[i]
+		if got.Label != w.label || len(got.Lines) != w.lines || got.Repeats != w.repeats || got.RepeatOf != w.repeatOf {
+			t.Errorf("section %d = %+v, want label %q, %d lines, %d repeats, repeat of %d", i, got, w.label, w.lines, w.repeats, w.repeatOf)
+		}
+	}
+	if line := sections[1].Lines[0]; line != (models.LyricsLine{Text: "Burn it down", Repeats: 2}) {
+		t.Errorf("chorus first line = %+v", line)
+	}
+}
+
+func TestParseSectionsMarksSameWordsAsRepeat(t *testing.T) {
+	sections := ParseSections("Ride on\nRide on!\n\nsomething else\n\nRIDE ON,\nride on")
+
+	if len(sections) != 3 {
+		t.Fatalf("got %d sections, want 3", len(sections))
+	}
+	if sections[2].RepeatOf != 0 {
+		t.Errorf("third section RepeatOf = %d, want 0", sections[2].RepeatOf)
+	}
+	if sections[1].RepeatOf != -1 {
+		t.Errorf("second section RepeatOf = %d, want -1", sections[1].RepeatOf)
+	}
+}
+
+func TestParseSectionsLabelBeforeBlankLine(t *testing.T) {
+	sections := ParseSections("[Intro]\n\nHere we go")
+
+	if len(sections) != 1 || sections[0].Label != "Intro" || len(sections[0].Lines) != 1 {
+		t.Errorf("sections = %+v, want one Intro section with one line", sections)
+	}
+}
+
+func TestSectionRepetition(t *testing.T) {
+	repetition := SectionRepetition(ParseSections(structuredLyrics), "")
+
+	// Sung: verse 1 (6), chorus (3*2 + 3 = 9), verse 2 (3), chorus again (9),
+	// closing section twice (9*2 = 18). Written once: 6 + 6 + 3 + 9.
+	if repetition.WordsWithRepeats != 45 {
+		t.Errorf("WordsWithRepeats = %d, want 45", repetition.WordsWithRepeats)
+	}
+	if repetition.WordsWithoutRepeats != 24 {
+		t.Errorf("WordsWithoutRepeats = %d, want 24", repetition.WordsWithoutRepeats)
+	}
+	if repetition.LinesWithRepeats != 15 || repetition.LinesWithoutRepeats != 8 {
+		t.Errorf("lines = %d with repeats, %d without; want 15 and 8", repetition.LinesWithRepeats, repetition.LinesWithoutRepeats)
+	}
+	if repetition.RepeatedSections != 1 {
+		t.Errorf("RepeatedSections = %d, want 1", repetition.RepeatedSections)
+	}
+	if want := 1 - 24.0/45; math.Abs(repetition.Ratio-want) > 1e-9 {
+		t.Errorf("Ratio = %v, want %v", repetition.Ratio, want)
+	}
+}
+
+func TestSectionRepetitionWithoutRepeats(t *testing.T) {
+	repetition := SectionRepetition(ParseSections("one two three\n\nfour five"), "")
+
+	if repetition.WordsWithRepeats != 5 || repetition.WordsWithoutRepeats != 5 || repetition.Ratio != 0 {
+		t.Errorf("repetition = %+v, want 5 words either way and ratio 0", repetition)
+	}
+	if got := SectionRepetition(nil, ""); got != (models.Repetition{}) {
+		t.Errorf("SectionRepetition(nil) = %+v, want zero value", got)
+	}
+}
+
+func TestAlbumRepetition(t *testing.T) {
+	album := models.BandcampAlbumData{Tracks: []models.BandcampTrackData{
+		{Lyrics: "la la\n(x3)"},
+		{Lyrics: "no repeats"},
+	}}
+
+	repetition := AlbumRepetition(album)
+	if repetition.WordsWithRepeats != 8 || repetition.WordsWithoutRepeats != 4 || repetition.Ratio != 0.5 {
+		t.Errorf("AlbumRepetition = %+v, want 8 sung words, 4 written and ratio 0.5", repetition)
+	}
+}
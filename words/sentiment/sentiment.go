@@ -0,0 +1,267 @@
+package sentiment
+
+import (
+	"bufio"
+	_ "embed"
+	"sort"
+	"strconv"
+	"strings"
+
+	"millions-of-words/models"
+	"millions-of-words/words"
+)
+
+const (
+	// negationScope is how many words after a negator it still applies to.
+	// It never runs past the end of a line.
+	negationScope = 3
+
+	// negationFactor reverses and damps a negated word: "not happy" is
+	// negative, but less so than "sad".
+	negationFactor = -0.5
+)
+
+//go:embed lexicon.tsv
+var lexiconData string
+
+type entry struct {
+	valence  float64
+	emotions []string
+}
+
+var lexicon = parseLexicon(lexiconData)
+
+// Emotion names in the order used to break ties for the dominant emotion.
+var emotionNames = []string{"anger", "fear", "sadness", "joy", "disgust", "trust"}
+
+var negators = map[string]bool{
+	"not": true, "no": true, "never": true, "nothing": true, "nobody": true,
+	"none": true, "neither": true, "nor": true, "without": true, "cannot": true,
+	"can't": true, "don't": true, "doesn't": true, "didn't": true, "won't": true,
+	"wouldn't": true, "isn't": true, "aren't": true, "wasn't": true, "weren't": true,
+	"ain't": true, "couldn't": true, "shouldn't": true, "haven't": true, "hasn't": true,
+}
+
+func parseLexicon(data string) map[string]entry {
+	parsed := make(map[string]entry)
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) != 3 {
+			continue
+		}
+		valence, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			continue
+		}
+		e := entry{valence: valence}
+		if fields[2] != "-" {
+			e.emotions = strings.Split(fields[2], ",")
+		}
+		parsed[fields[0]] = e
+	}
+	return parsed
+}
+
+// lookup finds a word in the lexicon, falling back to its base form for the
+// common inflections: "kills", "killed", "killing", "hated", "sadness".
+func lookup(word string) (entry, bool) {
+	if e, ok := lexicon[word]; ok {
+		return e, true
+	}
+	for _, suffix := range []string{"'s", "s", "es", "ed", "d", "ing", "ly", "ness"} {
+		stem, found := strings.CutSuffix(word, suffix)
+		if !found || len(stem) < 3 {
+			continue
+		}
+		if e, ok := lexicon[stem]; ok {
+			return e, true
+		}
+		// "hating" and "hated" drop the e of "hate".
+		if e, ok := lexicon[stem+"e"]; ok && (suffix == "ing" || suffix == "ed") {
+			return e, true
+		}
+	}
+	return entry{}, false
+}
+
+func isNegator(word string) bool {
+	return negators[strings.ReplaceAll(word, "’", "'")]
+}
+
+// Analyze scores lyrics line by line, skipping ignored words. A negator turns
+// the next few words on its line around: "no hope" counts as negative and
+// adds nothing to trust or joy.
+func Analyze(lyrics, ignoredWords string) models.Sentiment {
+	var s models.Sentiment
+	ignored := words.ParseIgnoredWords(ignoredWords)
+
+	for _, line := range words.SplitLines(ignored.StripPatterns(lyrics)) {
+		negated := 0
+		for _, token := range words.TokenizeLine(line) {
+			if ignored.Contains(token.Word) {
+				continue
+			}
+			s.Words++
+
+			if isNegator(token.Word) {
+				negated = negationScope
+				continue
+			}
+
+			e, ok := lookup(token.Word)
+			if !ok {
+				if negated > 0 {
+					negated--
+				}
+				continue
+			}
+
+			s.Matched++
+			valence := e.valence
+			if negated > 0 {
+				valence *= negationFactor
+				s.Negated++
+				negated = 0
+			} else {
+				addEmotions(&s.Emotions, e.emotions, 1)
+			}
+
+			s.Valence += valence
+			if valence > 0 {
+				s.Positive++
+			} else if valence < 0 {
+				s.Negative++
+			}
+		}
+	}
+
+	return score(s)
+}
+
+func addEmotions(counts *models.Emotions, emotions []string, n int) {
+	for _, emotion := range emotions {
+		switch emotion {
+		case "anger":
+			counts.Anger += n
+		case "fear":
+			counts.Fear += n
+		case "sadness":
+			counts.Sadness += n
+		case "joy":
+			counts.Joy += n
+		case "disgust":
+			counts.Disgust += n
+		case "trust":
+			counts.Trust += n
+		}
+	}
+}
+
+func emotionCount(counts models.Emotions, emotion string) int {
+	switch emotion {
+	case "anger":
+		return counts.Anger
+	case "fear":
+		return counts.Fear
+	case "sadness":
+		return counts.Sadness
+	case "joy":
+		return counts.Joy
+	case "disgust":
+		return counts.Disgust
+	case "trust":
+		return counts.Trust
+	}
+	return 0
+}
+
+// EmotionShare is the fraction of a text's emotion words that belong to one
+// emotion, used to rank albums by emotion.
+func EmotionShare(s models.Sentiment, emotion string) float64 {
+	total := 0
+	for _, name := range emotionNames {
+		total += emotionCount(s.Emotions, name)
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(emotionCount(s.Emotions, emotion)) / float64(total)
+}
+
+// Emotions lists the emotion categories in display order.
+func Emotions() []string {
+	return append([]string(nil), emotionNames...)
+}
+
+// score fills in the derived fields from the counts.
+func score(s models.Sentiment) models.Sentiment {
+	s.Score = 0
+	if s.Words > 0 {
+		s.Score = s.Valence / float64(s.Words) * 100
+	}
+
+	s.DominantEmotion = ""
+	best := 0
+	for _, emotion := range emotionNames {
+		if count := emotionCount(s.Emotions, emotion); count > best {
+			best = count
+			s.DominantEmotion = emotion
+		}
+	}
+	return s
+}
+
+func TrackSentiment(track models.BandcampTrackData) models.Sentiment {
+	return Analyze(track.Lyrics, track.IgnoredWords)
+}
+
+// AlbumSentiment adds up the counts of every track and scores the total,
+// so long tracks weigh more than short ones.
+func AlbumSentiment(album models.BandcampAlbumData) models.Sentiment {
+	var total models.Sentiment
+	for _, track := range album.Tracks {
+		s := TrackSentiment(track)
+		total.Words += s.Words
+		total.Matched += s.Matched
+		total.Positive += s.Positive
+		total.Negative += s.Negative
+		total.Negated += s.Negated
+		total.Valence += s.Valence
+		total.Emotions.Anger += s.Emotions.Anger
+		total.Emotions.Fear += s.Emotions.Fear
+		total.Emotions.Sadness += s.Emotions.Sadness
+		total.Emotions.Joy += s.Emotions.Joy
+		total.Emotions.Disgust += s.Emotions.Disgust
+		total.Emotions.Trust += s.Emotions.Trust
+	}
+	return score(total)
+}
+
+// Arc is the album's emotional arc: one point per track with lyrics, in
+// TrackNumber order.
+func Arc(album models.BandcampAlbumData) []models.SentimentPoint {
+	tracks := append([]models.BandcampTrackData(nil), album.Tracks...)
+	sort.SliceStable(tracks, func(i, j int) bool {
+		return tracks[i].TrackNumber < tracks[j].TrackNumber
+	})
+
+	arc := make([]models.SentimentPoint, 0, len(tracks))
+	for _, track := range tracks {
+		if strings.TrimSpace(track.Lyrics) == "" {
+			continue
+		}
+		s := TrackSentiment(track)
+		arc = append(arc, models.SentimentPoint{
+			TrackNumber:     track.TrackNumber,
+			TrackName:       track.Name,
+			Score:           s.Score,
+			DominantEmotion: s.DominantEmotion,
+		})
+	}
+	return arc
+}
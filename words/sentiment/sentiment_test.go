@@ -0,0 +1,121 @@
+package sentiment
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"millions-of-words/models"
+)
+
+func TestLexiconLoads(t *testing.T) {
+	if len(lexicon) < 200 {
+		t.Fatalf("lexicon has %d words, want the bundled list", len(lexicon))
+	}
+	for word, e := range lexicon {
+		if e.valence < -3 || e.valence > 3 {
+			t.Errorf("%q has valence %v outside -3..3", word, e.valence)
+		}
+		for _, emotion := range e.emotions {
+			if emotionCount(models.Emotions{Anger: 1, Fear: 2, Sadness: 3, Joy: 4, Disgust: 5, Trust: 6}, emotion) == 0 {
+				t.Errorf("%q has unknown emotion %q", word, emotion)
+			}
+		}
+	}
+}
+
+func TestLookupInflections(t *testing.T) {
+	for _, word := range []string{"kills", "killed", "killing", "hated", "hating", "sadness", "tears"} {
+		if _, ok := lookup(word); !ok {
+			t.Errorf("lookup(%q) found nothing", word)
+		}
+	}
+	for _, word := range []string{"the", "bles", "ring"} {
+		if _, ok := lookup(word); ok {
+			t.Errorf("lookup(%q) matched, want no match", word)
+		}
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	got := Analyze("I love the light\nHate and death", "")
+	want := models.Sentiment{
+		Words:           7,
+		Matched:         4,
+		Positive:        2,
+		Negative:        2,
+		Valence:         -2,
+		Emotions:        models.Emotions{Anger: 1, Fear: 1, Sadness: 1, Joy: 2, Disgust: 1, Trust: 1},
+		DominantEmotion: "joy",
+	}
+	if math.Abs(got.Score-(-200.0/7)) > 1e-9 {
+		t.Errorf("Score = %v, want %v", got.Score, -200.0/7)
+	}
+	got.Score = 0
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Analyze() = %+v, want %+v", got, want)
+	}
+}
+
+func TestAnalyzeNegation(t *testing.T) {
+	got := Analyze("There is no hope left\nhope", "")
+	if got.Negated != 1 || got.Matched != 2 {
+		t.Fatalf("Negated = %d, Matched = %d, want 1 and 2", got.Negated, got.Matched)
+	}
+	// -1 for the negated hope, +2 for the one on its own line.
+	if got.Valence != 1 {
+		t.Errorf("Valence = %v, want 1", got.Valence)
+	}
+	if got.Emotions.Trust != 1 || got.Emotions.Joy != 1 {
+		t.Errorf("Emotions = %+v, want only the unnegated hope counted", got.Emotions)
+	}
+
+	// The scope runs out after three words.
+	if far := Analyze("never the one that I love", ""); far.Negated != 0 {
+		t.Errorf("Negated = %d for a word out of scope, want 0", far.Negated)
+	}
+	if curly := Analyze("I don’t care", ""); curly.Negated != 1 {
+		t.Errorf("Negated = %d with a curly apostrophe, want 1", curly.Negated)
+	}
+}
+
+func TestAnalyzeIgnoredWords(t *testing.T) {
+	got := Analyze("death death [Chorus: death] love", "death, [Chorus: death]")
+	if got.Words != 1 || got.Valence != 3 {
+		t.Errorf("Words = %d, Valence = %v, want ignored words skipped", got.Words, got.Valence)
+	}
+}
+
+func TestAlbumSentimentAndArc(t *testing.T) {
+	album := models.BandcampAlbumData{Tracks: []models.BandcampTrackData{
+		{Name: "Closer", TrackNumber: 3, Lyrics: "joy and love"},
+		{Name: "Opener", TrackNumber: 1, Lyrics: "fear the dark"},
+		{Name: "Instrumental", TrackNumber: 2},
+	}}
+
+	total := AlbumSentiment(album)
+	if total.Words != 6 || total.Matched != 4 {
+		t.Errorf("AlbumSentiment() = %+v, want 6 words and 4 matches", total)
+	}
+
+	arc := Arc(album)
+	if len(arc) != 2 || arc[0].TrackName != "Opener" || arc[1].TrackName != "Closer" {
+		t.Fatalf("Arc() = %+v, want Opener then Closer", arc)
+	}
+	if arc[0].Score >= 0 || arc[1].Score <= 0 {
+		t.Errorf("Arc() scores = %v, %v, want negative then positive", arc[0].Score, arc[1].Score)
+	}
+	if arc[0].DominantEmotion != "fear" || arc[1].DominantEmotion != "joy" {
+		t.Errorf("Arc() emotions = %q, %q", arc[0].DominantEmotion, arc[1].DominantEmotion)
+	}
+}
+
+func TestEmotionShare(t *testing.T) {
+	s := models.Sentiment{Emotions: models.Emotions{Anger: 3, Joy: 1}}
+	if got := EmotionShare(s, "anger"); got != 0.75 {
+		t.Errorf("EmotionShare(anger) = %v, want 0.75", got)
+	}
+	if got := EmotionShare(models.Sentiment{}, "anger"); got != 0 {
+		t.Errorf("EmotionShare() with no emotions = %v, want 0", got)
+	}
+}
@@ -0,0 +1,106 @@
+package words
+
+import (
+	"math"
+	"sort"
+
+	"millions-of-words/models"
+)
+
+// Albums are compared by the cosine similarity of their TF-IDF vectors.
+// Weighting by IDF keeps words every album uses ("the", "i") from making all
+// albums look alike.
+
+// vector turns word counts into a unit-length TF-IDF vector.
+func (c *Corpus) vector(counts map[string]int) map[string]float64 {
+	documents := float64(len(c.albums))
+	vec := make(map[string]float64, len(counts))
+	norm := 0.0
+	for word, count := range counts {
+		weight := float64(count) * math.Log((1+documents)/(1+float64(c.docFreq[word])))
+		if weight == 0 {
+			continue
+		}
+		vec[word] = weight
+		norm += weight * weight
+	}
+	norm = math.Sqrt(norm)
+	for word := range vec {
+		vec[word] /= norm
+	}
+	return vec
+}
+
+func cosine(a, b map[string]float64) float64 {
+	if len(b) < len(a) {
+		a, b = b, a
+	}
+	dot := 0.0
+	for word, weight := range a {
+		dot += weight * b[word]
+	}
+	return dot
+}
+
+func (c *Corpus) buildVectors() {
+	c.vectorsOnce.Do(func() {
+		c.vectors = make(map[string]map[string]float64, len(c.ids))
+		for _, id := range c.ids {
+			c.vectors[id] = c.vector(c.albums[id])
+		}
+
+		c.matrix = make([][]float64, len(c.ids))
+		for i := range c.ids {
+			c.matrix[i] = make([]float64, len(c.ids))
+		}
+		for i, a := range c.ids {
+			if len(c.vectors[a]) > 0 {
+				c.matrix[i][i] = 1
+			}
+			for j := i + 1; j < len(c.ids); j++ {
+				similarity := cosine(c.vectors[a], c.vectors[c.ids[j]])
+				c.matrix[i][j] = similarity
+				c.matrix[j][i] = similarity
+			}
+		}
+	})
+}
+
+// Similar ranks the other albums of the corpus by how close their vocabulary
+// is to the album's, most similar first, and keeps at most limit of them.
+// Albums that share no weighted words are left out. An album that is not in
+// the corpus is compared using its own lyrics.
+func (c *Corpus) Similar(album models.BandcampAlbumData, limit int) []models.AlbumSimilarity {
+	c.buildVectors()
+
+	vec, ok := c.vectors[album.ID]
+	if !ok {
+		counts, _ := albumWordCounts(album)
+		vec = c.vector(counts)
+	}
+
+	similar := []models.AlbumSimilarity{}
+	for _, id := range c.ids {
+		if id == album.ID {
+			continue
+		}
+		if similarity := cosine(vec, c.vectors[id]); similarity > 0 {
+			similar = append(similar, models.AlbumSimilarity{AlbumID: id, Similarity: similarity})
+		}
+	}
+	sort.SliceStable(similar, func(i, j int) bool {
+		return similar[i].Similarity > similar[j].Similarity
+	})
+	if len(similar) > limit {
+		similar = similar[:limit]
+	}
+	return similar
+}
+
+// SimilarityMatrix returns the album IDs in corpus order and the cosine
+// similarity of every pair, where matrix[i][j] compares ids[i] with ids[j].
+// The matrix is shared and must not be modified.
+func (c *Corpus) SimilarityMatrix() ([]string, [][]float64) {
+	c.buildVectors()
+	return c.ids, c.matrix
+}
@@ -0,0 +1,59 @@
+package words
+
+import (
+	"math"
+	"testing"
+
+	"millions-of-words/models"
+)
+
+func TestSimilar(t *testing.T) {
+	war := distinctiveAlbum("war", "the sword of the king\nthe sword and blood")
+	battle := distinctiveAlbum("battle", "the sword in battle\nblood and the king")
+	sea := distinctiveAlbum("sea", "the waves of the sea\nthe tide and the moon")
+	corpus := NewCorpus([]models.BandcampAlbumData{war, battle, sea})
+
+	got := corpus.Similar(war, 5)
+	if len(got) == 0 || got[0].AlbumID != "battle" {
+		t.Fatalf("Similar(war) = %+v, want battle first", got)
+	}
+	for _, s := range got {
+		if s.AlbumID == "war" {
+			t.Error("Similar() should not list the album itself")
+		}
+	}
+	if limited := corpus.Similar(war, 1); len(limited) != 1 {
+		t.Errorf("Similar(war, 1) returned %d albums", len(limited))
+	}
+
+	outside := distinctiveAlbum("outside", "sword and blood and king")
+	if got := corpus.Similar(outside, 5); len(got) == 0 || got[0].AlbumID == "sea" {
+		t.Errorf("Similar(outside) = %+v, want the sword albums first", got)
+	}
+}
+
+func TestSimilarityMatrix(t *testing.T) {
+	corpus := NewCorpus([]models.BandcampAlbumData{
+		distinctiveAlbum("a", "fire and ice"),
+		distinctiveAlbum("b", "fire and stone"),
+		distinctiveAlbum("c", "water and air"),
+	})
+
+	ids, matrix := corpus.SimilarityMatrix()
+	if len(ids) != 3 || ids[0] != "a" || len(matrix) != 3 {
+		t.Fatalf("SimilarityMatrix() ids = %v, %d rows", ids, len(matrix))
+	}
+	for i := range matrix {
+		if matrix[i][i] != 1 {
+			t.Errorf("matrix[%d][%d] = %v, want 1", i, i, matrix[i][i])
+		}
+		for j := range matrix {
+			if math.Abs(matrix[i][j]-matrix[j][i]) > 1e-12 {
+				t.Errorf("matrix is not symmetric at %d,%d", i, j)
+			}
+		}
+	}
+	if matrix[0][1] <= 0 || matrix[0][2] != 0 {
+		t.Errorf("matrix[0] = %v, want a like b and unlike c", matrix[0])
+	}
+}
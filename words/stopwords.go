@@ -0,0 +1,79 @@
+package words
+
+import (
+	"sort"
+
+	"millions-of-words/models"
+)
+
+// stopwords are function words that carry little meaning on their own. A
+// phrase made only of them ("of the", "and i") is not worth showing.
+var stopwords = map[string]bool{
+	"a": true, "about": true, "after": true, "all": true, "am": true, "an": true,
+	"and": true, "any": true, "are": true, "as": true, "at": true, "be": true,
+	"been": true, "before": true, "being": true, "but": true, "by": true,
+	"can": true, "could": true, "did": true, "do": true, "does": true,
+	"for": true, "from": true, "had": true, "has": true, "have": true,
+	"he": true, "her": true, "here": true, "him": true, "his": true, "how": true,
+	"i": true, "i'm": true, "if": true, "in": true, "into": true, "is": true,
+	"it": true, "it's": true, "its": true, "just": true, "me": true, "my": true,
+	"of": true, "off": true, "on": true, "once": true, "or": true, "our": true,
+	"out": true, "over": true, "she": true, "so": true, "some": true,
+	"such": true, "than": true, "that": true, "the": true, "their": true,
+	"them": true, "then": true, "there": true, "these": true, "they": true,
+	"this": true, "those": true, "through": true, "to": true, "too": true,
+	"up": true, "upon": true, "us": true, "was": true, "we": true, "were": true,
+	"what": true, "when": true, "where": true, "which": true, "while": true,
+	"who": true, "will": true, "with": true, "would": true, "you": true,
+	"your": true,
+}
+
+// IsStopword reports whether a cleaned word is a built-in stopword.
+func IsStopword(word string) bool {
+	return stopwords[word]
+}
+
+// DefaultStopwords returns the built-in stopwords in alphabetical order.
+func DefaultStopwords() []string {
+	list := make([]string, 0, len(stopwords))
+	for word := range stopwords {
+		list = append(list, word)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// Stopwords is the set of words left out of filtered frequency lists: the
+// built-in list plus whatever the admin has added.
+type Stopwords map[string]bool
+
+// NewStopwords combines the built-in stopwords with extra words. The extra
+// words are cleaned the same way as lyrics, so "Oh," and "oh" are the same.
+func NewStopwords(extra []string) Stopwords {
+	set := make(Stopwords, len(stopwords)+len(extra))
+	for word := range stopwords {
+		set[word] = true
+	}
+	for _, word := range extra {
+		if cleaned := CleanWord(word); cleaned != "" {
+			set[cleaned] = true
+		}
+	}
+	return set
+}
+
+// Contains reports whether a cleaned word is a stopword.
+func (s Stopwords) Contains(word string) bool {
+	return s[word]
+}
+
+// Filter returns the frequencies without stopwords, keeping their order.
+func (s Stopwords) Filter(frequencies []models.WordCount) []models.WordCount {
+	filtered := make([]models.WordCount, 0, len(frequencies))
+	for _, wc := range frequencies {
+		if !s[wc.Word] {
+			filtered = append(filtered, wc)
+		}
+	}
+	return filtered
+}
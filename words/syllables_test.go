@@ -0,0 +1,109 @@
+package words
+
+import "testing"
+
+// syllableCorpus is a set of words that trip up naive vowel counting: silent
+// e, -ed and -es endings, consonant + le, y as a vowel, split vowel pairs and
+// lyric favourites.
+var syllableCorpus = map[string]int{
+	"a":           1,
+	"the":         1,
+	"fire":        1,
+	"fires":       1,
+	"fiery":       2,
+	"hour":        1,
+	"flame":       1,
+	"flames":      1,
+	"rose":        1,
+	"roses":       2,
+	"boxes":       2,
+	"churches":    2,
+	"walked":      1,
+	"wanted":      2,
+	"faded":       2,
+	"table":       2,
+	"battle":      2,
+	"cradle":      2,
+	"whistle":     2,
+	"rhythm":      2,
+	"myth":        1,
+	"cry":         1,
+	"crying":      2,
+	"yes":         1,
+	"play":        1,
+	"every":       2,
+	"beautiful":   3,
+	"giant":       2,
+	"dial":        2,
+	"violin":      3,
+	"video":       3,
+	"stadium":     3,
+	"nation":      2,
+	"religion":    3,
+	"special":     2,
+	"quake":       1,
+	"guard":       1,
+	"usual":       3,
+	"people":      2,
+	"prism":       2,
+	"chasm":       2,
+	"free":        1,
+	"agree":       2,
+	"eye":         1,
+	"don't":       1,
+	"we're":       1,
+	"darkness":    2,
+	"eternity":    4,
+	"desolation":  4,
+	"cemetery":    4,
+	"apocalypse":  4,
+	"sacrifice":   3,
+	"blasphemy":   3,
+	"abomination": 5,
+	"evil":        2,
+	"create":      2,
+	"idea":        3,
+	"quiet":       2,
+	"poetry":      3,
+	"half-life":   2,
+	"1999":        0,
+}
+
+func TestCountSyllables(t *testing.T) {
+	for word, want := range syllableCorpus {
+		if got := CountSyllables(word); got != want {
+			t.Errorf("CountSyllables(%q) = %d, want %d", word, got, want)
+		}
+	}
+}
+
+func TestReadability(t *testing.T) {
+	// 2 lines, 7 words, 11 syllables, 1 complex word ("eternity").
+	got := Readability("the fire burns\n\nwe walk into eternity [x2]", "[x2]")
+
+	if got.Lines != 2 || got.Words != 7 || got.Syllables != 11 || got.ComplexWords != 1 {
+		t.Fatalf("counts = %+v, want 2 lines, 7 words, 11 syllables, 1 complex word", got)
+	}
+
+	floats := []struct {
+		name      string
+		got, want float64
+	}{
+		{"SyllablesPerWord", got.SyllablesPerWord, 11.0 / 7},
+		{"SyllablesPerLine", got.SyllablesPerLine, 5.5},
+		{"FleschReadingEase", got.FleschReadingEase, 206.835 - 1.015*3.5 - 84.6*11/7},
+		{"FleschKincaidGrade", got.FleschKincaidGrade, 0.39*3.5 + 11.8*11/7 - 15.59},
+		{"GunningFog", got.GunningFog, 0.4 * (3.5 + 100.0/7)},
+	}
+	for _, f := range floats {
+		if !approxEqual(f.got, f.want) {
+			t.Errorf("%s = %v, want %v", f.name, f.got, f.want)
+		}
+	}
+}
+
+func TestReadabilityEmpty(t *testing.T) {
+	if got := Readability("\n\n", ""); got.FleschReadingEase != 0 || got.Words != 0 {
+		t.Errorf("Readability of blank lyrics = %+v, want zero value", got)
+	}
+}
@@ -0,0 +1,110 @@
+package words
+
+import (
+	"sort"
+	"strconv"
+
+	"millions-of-words/models"
+)
+
+// ReleaseYear is the year an album came out, or 0 when its release date is
+// missing or not a date.
+func ReleaseYear(album models.BandcampAlbumData) int {
+	if len(album.ReleaseDate) < 4 {
+		return 0
+	}
+	year, err := strconv.Atoi(album.ReleaseDate[:4])
+	if err != nil || year <= 0 {
+		return 0
+	}
+	return year
+}
+
+// YearTimeline groups albums by release year, oldest first, and follows how
+// the vocabulary grows from one year to the next. Albums without a release
+// year are left out.
+func YearTimeline(albums []models.BandcampAlbumData) []models.TimelinePoint {
+	byYear := make(map[int][]models.BandcampAlbumData)
+	var years []int
+	for _, album := range albums {
+		year := ReleaseYear(album)
+		if year == 0 {
+			continue
+		}
+		if _, ok := byYear[year]; !ok {
+			years = append(years, year)
+		}
+		byYear[year] = append(byYear[year], album)
+	}
+	sort.Ints(years)
+
+	groups := make([][]models.BandcampAlbumData, 0, len(years))
+	labels := make([]string, 0, len(years))
+	for _, year := range years {
+		groups = append(groups, byYear[year])
+		labels = append(labels, strconv.Itoa(year))
+	}
+	return timeline(groups, labels)
+}
+
+// CareerTimeline has one point per album, in release order, so an artist's
+// albums can be followed from first to last. Albums without a release date
+// come last, in the order given.
+func CareerTimeline(albums []models.BandcampAlbumData) []models.TimelinePoint {
+	ordered := append([]models.BandcampAlbumData(nil), albums...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		a, b := ReleaseYear(ordered[i]), ReleaseYear(ordered[j])
+		if a == 0 || b == 0 {
+			return a != 0 && b == 0
+		}
+		return ordered[i].ReleaseDate < ordered[j].ReleaseDate
+	})
+
+	groups := make([][]models.BandcampAlbumData, 0, len(ordered))
+	labels := make([]string, 0, len(ordered))
+	for _, album := range ordered {
+		groups = append(groups, []models.BandcampAlbumData{album})
+		label := album.AlbumName
+		if year := ReleaseYear(album); year > 0 {
+			label += " (" + strconv.Itoa(year) + ")"
+		}
+		labels = append(labels, label)
+	}
+	return timeline(groups, labels)
+}
+
+// timeline scores each group of albums as one text. Word counts are cleaned
+// tokens, the same ones lexical richness is measured on.
+func timeline(groups [][]models.BandcampAlbumData, labels []string) []models.TimelinePoint {
+	seen := make(map[string]bool)
+	points := make([]models.TimelinePoint, 0, len(groups))
+	for i, group := range groups {
+		var tokens []string
+		for _, album := range group {
+			tokens = append(tokens, albumWords(album)...)
+		}
+
+		unique := distinct(tokens)
+		newWords := 0
+		for word := range unique {
+			if !seen[word] {
+				seen[word] = true
+				newWords++
+			}
+		}
+
+		point := models.TimelinePoint{
+			Label:       labels[i],
+			Year:        ReleaseYear(group[0]),
+			Albums:      len(group),
+			TotalWords:  len(tokens),
+			UniqueWords: len(unique),
+			NewWords:    newWords,
+			Vocabulary:  len(seen),
+			Richness:    LexicalRichness(tokens),
+		}
+		point.WordsPerAlbum = float64(point.TotalWords) / float64(point.Albums)
+		points = append(points, point)
+	}
+	return points
+}
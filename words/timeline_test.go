@@ -0,0 +1,82 @@
+package words
+
+import (
+	"testing"
+
+	"millions-of-words/models"
+)
+
+func timelineAlbum(name, releaseDate, lyrics string) models.BandcampAlbumData {
+	return models.BandcampAlbumData{
+		AlbumName:   name,
+		ReleaseDate: releaseDate,
+		Tracks:      []models.BandcampTrackData{{Lyrics: lyrics}},
+	}
+}
+
+func TestReleaseYear(t *testing.T) {
+	tests := map[string]int{"1999-05-01": 1999, "2004": 2004, "": 0, "soon": 0, "99": 0}
+	for date, want := range tests {
+		if got := ReleaseYear(models.BandcampAlbumData{ReleaseDate: date}); got != want {
+			t.Errorf("ReleaseYear(%q) = %d, want %d", date, got, want)
+		}
+	}
+}
+
+func TestYearTimeline(t *testing.T) {
+	points := YearTimeline([]models.BandcampAlbumData{
+		timelineAlbum("Later", "2001-03-01", "fire and stone"),
+		timelineAlbum("First", "1999-01-01", "fire fire ice"),
+		timelineAlbum("Same year", "2001-09-01", "ice"),
+		timelineAlbum("Undated", "", "words nobody counts"),
+	})
+
+	if len(points) != 2 {
+		t.Fatalf("got %d points, want 2: %+v", len(points), points)
+	}
+
+	first, second := points[0], points[1]
+	if first.Label != "1999" || first.Year != 1999 || first.Albums != 1 || first.TotalWords != 3 || first.UniqueWords != 2 || first.NewWords != 2 || first.Vocabulary != 2 {
+		t.Errorf("1999 = %+v", first)
+	}
+	// 2001 uses fire, and, stone and ice; only "and" and "stone" are new.
+	if second.Label != "2001" || second.Albums != 2 || second.TotalWords != 4 || second.WordsPerAlbum != 2 || second.UniqueWords != 4 || second.NewWords != 2 || second.Vocabulary != 4 {
+		t.Errorf("2001 = %+v", second)
+	}
+	if second.Richness.Tokens != 4 {
+		t.Errorf("2001 richness tokens = %d, want 4", second.Richness.Tokens)
+	}
+}
+
+func TestCareerTimeline(t *testing.T) {
+	points := CareerTimeline([]models.BandcampAlbumData{
+		timelineAlbum("Undated", "", "ice"),
+		timelineAlbum("Second", "2003-01-01", "fire ice"),
+		timelineAlbum("First", "2003-01-01", "fire"),
+		timelineAlbum("Debut", "1998-06-01", "fire"),
+	})
+
+	var labels []string
+	for _, point := range points {
+		labels = append(labels, point.Label)
+	}
+	want := []string{"Debut (1998)", "Second (2003)", "First (2003)", "Undated"}
+	if len(labels) != len(want) {
+		t.Fatalf("labels = %v, want %v", labels, want)
+	}
+	for i := range want {
+		if labels[i] != want[i] {
+			t.Fatalf("labels = %v, want %v", labels, want)
+		}
+	}
+
+	newWords := []int{1, 1, 0, 0}
+	for i, point := range points {
+		if point.NewWords != newWords[i] {
+			t.Errorf("%s new words = %d, want %d", point.Label, point.NewWords, newWords[i])
+		}
+	}
+	if points[3].Year != 0 || points[3].Vocabulary != 2 {
+		t.Errorf("undated point = %+v", points[3])
+	}
+}
@@ -6,61 +6,92 @@ import (
 	"sort"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 )
 
-func NormalizeText(text string) string {
-	//Some albums have weird apostrophes. This replaces ’ and ‘ with ASCII (')
-
-	result := strings.ReplaceAll(text, "\u2019", "'")
-	result = strings.ReplaceAll(result, "‘", "'")
-	return result
+// IgnoredWords is the parsed form of a comma separated ignored words field.
+// Entries containing brackets or colons (like "[Chorus]") are treated as
+// literal patterns to strip from the lyrics; everything else is a word.
+type IgnoredWords struct {
+	Words    map[string]bool
+	Patterns []string
 }
 
-func CalculateAndSortWordFrequencies(lyrics string, ignoredWords string) ([]models.WordCount, int, int, map[int]int) {
-	if lyrics == "" {
-		return nil, 0, 0, nil
+func ParseIgnoredWords(ignoredWords string) IgnoredWords {
+	parsed := IgnoredWords{Words: make(map[string]bool)}
+	if ignoredWords == "" {
+		return parsed
 	}
 
-	ignoredWordsMap := make(map[string]bool)
-	var ignoredPatterns []string
-	if ignoredWords != "" {
-		for _, word := range strings.Split(ignoredWords, ",") {
-			word = strings.TrimSpace(word)
-			if word != "" {
-				if strings.ContainsAny(word, "()[]{}:") {
-					ignoredPatterns = append(ignoredPatterns, word)
-				} else {
-					ignoredWordsMap[word] = true
-					cleaned := CleanWord(word)
-					if cleaned != "" {
-						ignoredWordsMap[cleaned] = true
-					}
-				}
-			}
+	for _, word := range strings.Split(ignoredWords, ",") {
+		word = strings.TrimSpace(NormalizeText(word))
+		if word == "" {
+			continue
+		}
+		if strings.ContainsAny(word, "()[]{}:") {
+			parsed.Patterns = append(parsed.Patterns, word)
+			continue
+		}
+		parsed.Words[word] = true
+		if cleaned := CleanWord(word); cleaned != "" {
+			parsed.Words[cleaned] = true
 		}
 	}
+	return parsed
+}
 
-	processedLyrics := lyrics
-	for _, pattern := range ignoredPatterns {
+// StripPatterns removes the ignored patterns from normalised lyrics, so a
+// pattern matches however fancily the lyrics spell it.
+func (iw IgnoredWords) StripPatterns(lyrics string) string {
+	lyrics = NormalizeText(lyrics)
+	for _, pattern := range iw.Patterns {
 		escapedPattern := regexp.QuoteMeta(pattern)
-		processedLyrics = regexp.MustCompile(escapedPattern).ReplaceAllString(processedLyrics, "")
+		lyrics = regexp.MustCompile(escapedPattern).ReplaceAllString(lyrics, "")
+	}
+	return lyrics
+}
+
+func (iw IgnoredWords) Contains(cleanedWord string) bool {
+	return iw.Words[cleanedWord]
+}
+
+// CalculateAndSortWordFrequencies counts the words of lyrics, most frequent
+// first, along with their vowels, consonants and word lengths. Vowels are
+// counted the English way; TrackWordFrequencies counts them for the track's
+// language.
+func CalculateAndSortWordFrequencies(lyrics string, ignoredWords string) ([]models.WordCount, int, int, map[int]int) {
+	return wordFrequencies(lyrics, ignoredWords, "")
+}
+
+// TrackWordFrequencies is CalculateAndSortWordFrequencies with vowels counted
+// for the language of the track.
+func TrackWordFrequencies(track models.BandcampTrackData) ([]models.WordCount, int, int, map[int]int) {
+	return wordFrequencies(track.Lyrics, track.IgnoredWords, TrackLanguage(track))
+}
+
+func wordFrequencies(lyrics, ignoredWords, language string) ([]models.WordCount, int, int, map[int]int) {
+	if lyrics == "" {
+		return nil, 0, 0, nil
 	}
 
+	ignored := ParseIgnoredWords(ignoredWords)
+	processedLyrics := ignored.StripPatterns(lyrics)
+
 	wordCounts := make(map[string]int)
 	vowelCount := 0
 	consonantCount := 0
 	wordLengthDistribution := make(map[int]int)
 
-	words := splitLyricsIntoWords(removeItalics(processedLyrics))
+	words := splitLyricsIntoWords(processedLyrics)
 
 	for _, word := range words {
 		cleanedWord := CleanWord(word)
-		if cleanedWord != "" && !ignoredWordsMap[cleanedWord] {
+		if cleanedWord != "" && !ignored.Contains(cleanedWord) {
 			wordCounts[cleanedWord]++
-			vowels, consonants := countVowelsAndConsonants(cleanedWord)
+			vowels, consonants := countVowelsAndConsonants(cleanedWord, language)
 			vowelCount += vowels
 			consonantCount += consonants
-			wordLengthDistribution[len(cleanedWord)]++
+			wordLengthDistribution[utf8.RuneCountInString(cleanedWord)]++
 		}
 	}
 
@@ -77,10 +108,109 @@ func CalculateAndSortWordFrequencies(lyrics string, ignoredWords string) ([]mode
 	return sortedWordCounts, vowelCount, consonantCount, wordLengthDistribution
 }
 
+// TrackWords returns the cleaned words of the lyrics in the order they are
+// sung, with ignored words and patterns removed. It counts exactly the same
+// words as CalculateAndSortWordFrequencies.
+func TrackWords(lyrics string, ignoredWords string) []string {
+	return ParseIgnoredWords(ignoredWords).tokens(lyrics)
+}
+
+// tokens is TrackWords for an already parsed ignored words field.
+func (iw IgnoredWords) tokens(text string) []string {
+	var result []string
+	for _, word := range splitLyricsIntoWords(iw.StripPatterns(text)) {
+		if cleaned := CleanWord(word); cleaned != "" && !iw.Contains(cleaned) {
+			result = append(result, cleaned)
+		}
+	}
+	return result
+}
+
 func splitLyricsIntoWords(lyrics string) []string {
-	return strings.FieldsFunc(lyrics, func(r rune) bool {
-		return unicode.IsSpace(r) || (unicode.IsPunct(r) && r != '\'' && r != '’' && r != '-')
+	var words []string
+	scanWords(lyrics, func(start, end int) {
+		words = append(words, lyrics[start:end])
 	})
+	return words
+}
+
+// scanWords calls fn with the byte offsets of every word in text, in order.
+// Words end at spaces and punctuation. Chinese and Japanese are written
+// without spaces, so each Han character is a word of its own and runs of
+// hiragana or katakana are split from whatever surrounds them.
+func scanWords(text string, fn func(start, end int)) {
+	start, startScript := -1, otherScript
+	for i, r := range text {
+		if isWordSeparator(r) {
+			if start >= 0 {
+				fn(start, i)
+				start = -1
+			}
+			continue
+		}
+		script := wordScript(r)
+		if start >= 0 && (script != startScript || script == hanScript) {
+			fn(start, i)
+			start = -1
+		}
+		if start < 0 {
+			start, startScript = i, script
+		}
+	}
+	if start >= 0 {
+		fn(start, len(text))
+	}
+}
+
+const (
+	otherScript = iota
+	hanScript
+	hiraganaScript
+	katakanaScript
+)
+
+func wordScript(r rune) int {
+	switch {
+	case unicode.Is(unicode.Han, r):
+		return hanScript
+	case unicode.Is(unicode.Hiragana, r):
+		return hiraganaScript
+	case unicode.Is(unicode.Katakana, r) || r == 'ー':
+		// The long vowel mark belongs to the katakana word it lengthens.
+		return katakanaScript
+	}
+	return otherScript
+}
+
+// SplitLines normalises lyrics and splits them into lines.
+func SplitLines(lyrics string) []string {
+	return strings.Split(strings.ReplaceAll(NormalizeText(lyrics), "\r\n", "\n"), "\n")
+}
+
+// Token is a word in a line of text. Start and End are byte offsets of the raw
+// text in the line; Word is the cleaned, lowercased form used for counting.
+type Token struct {
+	Text  string
+	Word  string
+	Start int
+	End   int
+}
+
+// TokenizeLine splits a line the same way as CalculateAndSortWordFrequencies
+// and keeps track of where each word is. Tokens that clean to nothing are
+// dropped.
+func TokenizeLine(line string) []Token {
+	var tokens []Token
+	scanWords(line, func(start, end int) {
+		if word := CleanWord(line[start:end]); word != "" {
+			tokens = append(tokens, Token{Text: line[start:end], Word: word, Start: start, End: end})
+		}
+	})
+	return tokens
+}
+
+func isWordSeparator(r rune) bool {
+	return unicode.IsSpace(r) || (unicode.IsPunct(r) && r != '\'' && r != '’' && r != '-')
 }
 
 func CleanWord(word string) string {
@@ -99,15 +229,20 @@ func CleanWord(word string) string {
 	return word
 }
 
-func countVowelsAndConsonants(word string) (int, int) {
+// countVowelsAndConsonants counts the letters of a word in any alphabet,
+// accented or not. Whether y is a vowel depends on the language.
+func countVowelsAndConsonants(word string, language string) (int, int) {
 	vowels := 0
 	consonants := 0
 	for _, r := range word {
-		switch r {
-		case 'a', 'e', 'i', 'o', 'u', 'A', 'E', 'I', 'O', 'U':
+		if !unicode.IsLetter(r) || !isAlphabetLetter(r) {
+			continue
+		}
+		base := baseLetter(r)
+		switch {
+		case vowelLetters[base] || (base == 'y' && yVowelLanguages[language]):
 			vowels++
-		case 'b', 'c', 'd', 'f', 'g', 'h', 'j', 'k', 'l', 'm', 'n', 'p', 'q', 'r', 's', 't', 'v', 'w', 'x', 'y', 'z',
-			'B', 'C', 'D', 'F', 'G', 'H', 'J', 'K', 'L', 'M', 'N', 'P', 'Q', 'R', 'S', 'T', 'V', 'W', 'X', 'Y', 'Z':
+		default:
 			consonants++
 		}
 	}
@@ -127,29 +262,10 @@ func MapToSortedList(wordCounts map[string]int) []models.WordCount {
 	return wordFrequencies
 }
 
-func removeItalics(text string) string {
-	replacer := strings.NewReplacer(
-		"𝘢", "a", "𝘣", "b", "𝘤", "c", "𝘥", "d",
-		"𝘦", "e", "𝘧", "f", "𝘨", "g", "𝘩", "h",
-		"𝘪", "i", "𝘫", "j", "𝘬", "k", "𝘭", "l",
-		"𝘮", "m", "𝘯", "n", "𝘰", "o", "𝘱", "p",
-		"𝘲", "q", "𝘳", "r", "𝘴", "s", "𝘵", "t",
-		"𝘶", "u", "𝘷", "v", "𝘸", "w", "𝘹", "x",
-		"𝘺", "y", "𝘻", "z", "𝘈", "A", "𝘉", "B",
-		"𝘊", "C", "𝘋", "D", "𝘌", "E", "𝘍", "F",
-		"𝘎", "G", "𝘏", "H", "𝘐", "I", "𝘑", "J",
-		"𝘒", "K", "𝘓", "L", "𝘔", "M", "𝘕", "N",
-		"𝘖", "O", "𝘗", "P", "𝘘", "Q", "𝘙", "R",
-		"𝘚", "S", "𝘛", "T", "𝘜", "U", "𝘝", "V",
-		"𝘞", "W", "𝘟", "X", "𝘠", "Y", "𝘡", "Z",
-	)
-	return replacer.Replace(text)
-}
-
 func AggregateWordFrequencies(album models.BandcampAlbumData) []models.WordCount {
 	wordFreqMap := make(map[string]int)
 	for _, track := range album.Tracks {
-		wordCounts, _, _, _ := CalculateAndSortWordFrequencies(track.Lyrics, track.IgnoredWords)
+		wordCounts, _, _, _ := TrackWordFrequencies(track)
 		for _, wc := range wordCounts {
 			wordFreqMap[wc.Word] += wc.Count
 		}